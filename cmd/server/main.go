@@ -16,7 +16,9 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	certaccess "github.com/radityprtama/proxygate/v6/internal/access/cert_access"
 	configaccess "github.com/radityprtama/proxygate/v6/internal/access/config_access"
+	jwtaccess "github.com/radityprtama/proxygate/v6/internal/access/jwt_access"
 	"github.com/radityprtama/proxygate/v6/internal/buildinfo"
 	"github.com/radityprtama/proxygate/v6/internal/cmd"
 	"github.com/radityprtama/proxygate/v6/internal/config"
@@ -67,6 +69,8 @@ func main() {
 	var configPath string
 	var password string
 	var completion string
+	var generateAPIKey bool
+	var apiKeyHash string
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&login, "login", false, "Login Google Account")
@@ -82,6 +86,8 @@ func main() {
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
 	flag.StringVar(&completion, "completion", "", "Generate shell completion script (bash, zsh, fish, powershell)")
 	flag.StringVar(&password, "password", "", "")
+	flag.BoolVar(&generateAPIKey, "generate-api-key", false, "Generate a client API key, store its hash in api-keys and print the plaintext once")
+	flag.StringVar(&apiKeyHash, "api-key-hash", "sha256", "Hash scheme for -generate-api-key (sha256 or bcrypt)")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -443,6 +449,8 @@ func main() {
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register()
+	jwtaccess.Register()
+	certaccess.Register()
 
 	// Handle different command modes based on the provided flags.
 
@@ -452,6 +460,9 @@ func main() {
 			os.Exit(1)
 		}
 		return
+	} else if generateAPIKey {
+		// Generate a hashed client API key
+		cmd.DoGenerateAPIKey(cfg, configFilePath, apiKeyHash)
 	} else if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
@@ -0,0 +1,203 @@
+// Package certaccess provides the "client-cert" access provider, which authenticates requests by
+// the TLS client certificate presented during the handshake and maps its subject or SAN to a
+// principal.
+package certaccess
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	sdkconfig "github.com/radityprtama/proxygate/v6/sdk/config"
+)
+
+// Supported values for the principal option.
+const (
+	PrincipalSubjectCN = "subject-cn"
+	PrincipalSubject   = "subject"
+	PrincipalSANDNS    = "san-dns"
+	PrincipalSANEmail  = "san-email"
+	PrincipalSANURI    = "san-uri"
+)
+
+// Result.Metadata keys describing the client certificate.
+const (
+	MetadataSubject = "subject"
+	MetadataIssuer  = "issuer"
+	MetadataSerial  = "serial"
+)
+
+var registerOnce sync.Once
+
+// Register ensures the client-cert access provider is available to the access manager.
+func Register() {
+	registerOnce.Do(func() {
+		sdkaccess.RegisterProvider(sdkconfig.AccessProviderTypeClientCert, newProvider)
+	})
+}
+
+type provider struct {
+	name      string
+	principal string
+	allowed   []string
+	roots     *x509.CertPool
+	root      *sdkconfig.SDKConfig
+}
+
+func newProvider(cfg *sdkconfig.AccessProvider, root *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
+	name := cfg.Name
+	if name == "" {
+		name = sdkconfig.AccessProviderTypeClientCert
+	}
+	options := cfg.Config
+	p := &provider{
+		name:      name,
+		principal: strings.ToLower(stringOption(options, "principal")),
+		allowed:   stringListOption(options, "allowed-principals"),
+		root:      root,
+	}
+	switch p.principal {
+	case "":
+		p.principal = PrincipalSubjectCN
+	case PrincipalSubjectCN, PrincipalSubject, PrincipalSANDNS, PrincipalSANEmail, PrincipalSANURI:
+	default:
+		return nil, fmt.Errorf("unsupported principal %q", p.principal)
+	}
+	if caFile := stringOption(options, "ca-file"); caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca-file: %w", err)
+		}
+		p.roots = x509.NewCertPool()
+		if !p.roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("ca-file %s contains no PEM certificates", caFile)
+		}
+	}
+	return p, nil
+}
+
+func (p *provider) Identifier() string {
+	if p == nil || p.name == "" {
+		return sdkconfig.AccessProviderTypeClientCert
+	}
+	return p.name
+}
+
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	if p == nil || r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	leaf, err := p.verify(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", sdkaccess.ErrInvalidCredential, err)
+	}
+	principal := p.principalOf(leaf)
+	if principal == "" {
+		return nil, fmt.Errorf("%w: certificate has no %s", sdkaccess.ErrInvalidCredential, p.principal)
+	}
+	if len(p.allowed) > 0 && !matchAny(p.allowed, principal) {
+		return nil, fmt.Errorf("%w: principal %q not allowed", sdkaccess.ErrInvalidCredential, principal)
+	}
+	metadata := map[string]string{
+		"source":        "client-cert",
+		MetadataSubject: leaf.Subject.String(),
+		MetadataIssuer:  leaf.Issuer.String(),
+		MetadataSerial:  leaf.SerialNumber.Text(16),
+	}
+	sdkaccess.ApplyClientKeySettings(p.root, principal, metadata)
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principal,
+		Metadata:  metadata,
+	}, nil
+}
+
+// verify returns the leaf certificate once its chain is trusted. The provider's own ca-file takes
+// precedence; otherwise the chains verified by the server against tls.client-ca are required.
+func (p *provider) verify(r *http.Request) (*x509.Certificate, error) {
+	state := r.TLS
+	leaf := state.PeerCertificates[0]
+	if p.roots == nil {
+		if len(state.VerifiedChains) == 0 {
+			return nil, fmt.Errorf("certificate was not verified; configure tls.client-ca or ca-file")
+		}
+		return leaf, nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         p.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, err
+	}
+	return leaf, nil
+}
+
+func (p *provider) principalOf(cert *x509.Certificate) string {
+	switch p.principal {
+	case PrincipalSubject:
+		return cert.Subject.String()
+	case PrincipalSANDNS:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	case PrincipalSANEmail:
+		if len(cert.EmailAddresses) > 0 {
+			return cert.EmailAddresses[0]
+		}
+	case PrincipalSANURI:
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	default:
+		return strings.TrimSpace(cert.Subject.CommonName)
+	}
+	return ""
+}
+
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if pattern == value {
+			return true
+		}
+		if ok, err := path.Match(pattern, value); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func stringOption(options map[string]any, key string) string {
+	value, _ := options[key].(string)
+	return strings.TrimSpace(value)
+}
+
+func stringListOption(options map[string]any, key string) []string {
+	switch v := options[key].(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+		return out
+	case []string:
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package certaccess
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	sdkconfig "github.com/radityprtama/proxygate/v6/sdk/config"
+)
+
+type issuer struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newCA(t *testing.T, name string) *issuer {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create ca: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &issuer{cert: cert, key: key}
+}
+
+func (ca *issuer) issue(t *testing.T, cn string, dnsNames ...string) *x509.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"example"}},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("issue cert: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func writeCA(t *testing.T, ca *issuer) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+	return path
+}
+
+func authenticate(p sdkaccess.Provider, state *tls.ConnectionState) (*sdkaccess.Result, error) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.TLS = state
+	return p.Authenticate(context.Background(), req)
+}
+
+func TestProvider_VerifiesCertificatesAgainstCAFile(t *testing.T) {
+	trusted := newCA(t, "trusted")
+	untrusted := newCA(t, "untrusted")
+	root := &sdkconfig.SDKConfig{ClientKeys: []sdkconfig.ClientKey{{Key: "billing", Admin: true, AllowedModels: []string{"gpt-*"}}}}
+	p, err := newProvider(&sdkconfig.AccessProvider{Type: sdkconfig.AccessProviderTypeClientCert, Config: map[string]any{
+		"ca-file":            writeCA(t, trusted),
+		"allowed-principals": []any{"billing", "reports-*"},
+	}}, root)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+
+	res, err := authenticate(p, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{trusted.issue(t, "billing")}})
+	if err != nil {
+		t.Fatalf("expected trusted certificate accepted, got %v", err)
+	}
+	if res.Principal != "billing" || res.Metadata[sdkaccess.MetadataAdmin] != "true" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if policy, ok := sdkaccess.PolicyFromMetadata(res.Metadata); !ok || len(policy.AllowedModels) != 1 {
+		t.Fatalf("expected client-keys policy for principal, got %+v", res.Metadata)
+	}
+	if res, err = authenticate(p, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{trusted.issue(t, "reports-eu")}}); err != nil || res.Principal != "reports-eu" {
+		t.Fatalf("expected glob-allowed principal accepted, got %+v, %v", res, err)
+	}
+
+	rejected := map[string]*x509.Certificate{
+		"untrusted":     untrusted.issue(t, "billing"),
+		"not-allowlist": trusted.issue(t, "intruder"),
+	}
+	for name, cert := range rejected {
+		if _, err = authenticate(p, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+			t.Fatalf("%s: expected invalid credential, got %v", name, err)
+		}
+	}
+	if _, err = authenticate(p, nil); !errors.Is(err, sdkaccess.ErrNotHandled) {
+		t.Fatalf("expected plain requests left to other providers, got %v", err)
+	}
+	if _, err = authenticate(p, &tls.ConnectionState{}); !errors.Is(err, sdkaccess.ErrNotHandled) {
+		t.Fatalf("expected TLS requests without certificates left to other providers, got %v", err)
+	}
+}
+
+func TestProvider_UsesServerVerifiedChainsAndSAN(t *testing.T) {
+	ca := newCA(t, "ca")
+	p, err := newProvider(&sdkconfig.AccessProvider{Type: sdkconfig.AccessProviderTypeClientCert, Config: map[string]any{
+		"principal": "san-dns",
+	}}, nil)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	cert := ca.issue(t, "ignored", "svc.internal")
+	if _, err = authenticate(p, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+		t.Fatalf("expected unverified certificate rejected, got %v", err)
+	}
+	res, err := authenticate(p, &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert, ca.cert}},
+	})
+	if err != nil || res.Principal != "svc.internal" {
+		t.Fatalf("expected SAN principal from verified chain, got %+v, %v", res, err)
+	}
+	if _, err = newProvider(&sdkconfig.AccessProvider{Config: map[string]any{"principal": "serial"}}, nil); err == nil {
+		t.Fatalf("expected unsupported principal to be rejected")
+	}
+}
@@ -2,10 +2,12 @@ package configaccess
 
 import (
 	"context"
+	"crypto/sha256"
 	"net/http"
 	"strings"
 	"sync"
 
+	"github.com/radityprtama/proxygate/v6/internal/virtualkey"
 	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
 	sdkconfig "github.com/radityprtama/proxygate/v6/sdk/config"
 )
@@ -19,24 +21,77 @@ func Register() {
 	})
 }
 
+// maxVerifiedKeys bounds the cache of presented keys already matched against a hashed entry.
+const maxVerifiedKeys = 1024
+
+// maxRejectedKeys bounds the cache of presented keys that matched no hashed entry.
+const maxRejectedKeys = 4096
+
 type provider struct {
-	name string
-	keys map[string]struct{}
+	name     string
+	keys     map[string]struct{}
+	hashed   []string
+	admins   map[string]struct{}
+	policies map[string]string
+	tags     map[string]string
+
+	// verified maps the SHA-256 of a presented key to the hashed entry it matched, so slow
+	// hashes such as bcrypt are only evaluated once per key.
+	verifiedMu sync.RWMutex
+	verified   map[[sha256.Size]byte]string
+	// rejected holds the SHA-256 of presented keys that matched no hashed entry, so a wrong key
+	// or a credential meant for another provider does not pay for the slow hashes again.
+	rejected map[[sha256.Size]byte]struct{}
 }
 
-func newProvider(cfg *sdkconfig.AccessProvider, _ *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
+func newProvider(cfg *sdkconfig.AccessProvider, root *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
 	name := cfg.Name
 	if name == "" {
 		name = sdkconfig.DefaultAccessProviderName
 	}
 	keys := make(map[string]struct{}, len(cfg.APIKeys))
+	var hashed []string
 	for _, key := range cfg.APIKeys {
 		if key == "" {
 			continue
 		}
+		if sdkaccess.IsHashedKey(key) {
+			hashed = append(hashed, key)
+			continue
+		}
 		keys[key] = struct{}{}
 	}
-	return &provider{name: name, keys: keys}, nil
+	admins := make(map[string]struct{})
+	policies := make(map[string]string)
+	tags := make(map[string]string)
+	if root != nil {
+		for i := range root.ClientKeys {
+			clientKey := &root.ClientKeys[i]
+			if clientKey.Key == "" {
+				continue
+			}
+			if clientKey.Admin {
+				admins[clientKey.Key] = struct{}{}
+			}
+			if len(clientKey.Tags) > 0 {
+				tags[clientKey.Key] = strings.Join(clientKey.Tags, ",")
+			}
+			policy := sdkaccess.PolicyFromClientKey(clientKey)
+			if !policy.Empty() {
+				policies[clientKey.Key] = policy.Encode()
+			}
+		}
+	}
+	return &provider{
+		name:     name,
+		keys:     keys,
+		hashed:   hashed,
+		admins:   admins,
+		policies: policies,
+		tags:     tags,
+		verified: make(map[[sha256.Size]byte]string),
+		rejected: make(map[[sha256.Size]byte]struct{}),
+	}, nil
 }
 
 func (p *provider) Identifier() string {
@@ -50,7 +105,7 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 	if p == nil {
 		return nil, sdkaccess.ErrNotHandled
 	}
-	if len(p.keys) == 0 {
+	if len(p.keys) == 0 && len(p.hashed) == 0 {
 		return nil, sdkaccess.ErrNotHandled
 	}
 	authHeader := r.Header.Get("Authorization")
@@ -83,20 +138,85 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		if candidate.value == "" {
 			continue
 		}
-		if _, ok := p.keys[candidate.value]; ok {
-			return &sdkaccess.Result{
-				Provider:  p.Identifier(),
-				Principal: candidate.value,
-				Metadata: map[string]string{
-					"source": candidate.source,
-				},
-			}, nil
+		// Hashed keys are identified by their stored hash so the plaintext never becomes the principal.
+		principal, ok := p.match(candidate.value)
+		if !ok {
+			continue
+		}
+		metadata := map[string]string{
+			"source": candidate.source,
+		}
+		if _, admin := p.admins[principal]; admin {
+			metadata[sdkaccess.MetadataAdmin] = "true"
 		}
+		if policy, ok := p.policies[principal]; ok {
+			metadata[sdkaccess.MetadataPolicy] = policy
+		}
+		if tags, ok := p.tags[principal]; ok {
+			metadata[sdkaccess.MetadataTags] = tags
+		}
+		return &sdkaccess.Result{
+			Provider:  p.Identifier(),
+			Principal: principal,
+			Metadata:  metadata,
+		}, nil
 	}
 
 	return nil, sdkaccess.ErrInvalidCredential
 }
 
+// match returns the configured key entry that value satisfies: the key itself for plaintext
+// entries, or the stored hash for hashed entries. A bcrypt entry costs tens of milliseconds per
+// comparison, so hashed entries make failed authentication expensive; values that cannot be
+// config keys are never hashed and the outcome for each presented value is cached.
+func (p *provider) match(value string) (string, bool) {
+	if _, ok := p.keys[value]; ok {
+		return value, true
+	}
+	if len(p.hashed) == 0 || !hashCandidate(value) {
+		return "", false
+	}
+	digest := sha256.Sum256([]byte(value))
+	p.verifiedMu.RLock()
+	stored, ok := p.verified[digest]
+	_, rejected := p.rejected[digest]
+	p.verifiedMu.RUnlock()
+	if ok {
+		return stored, true
+	}
+	if rejected {
+		return "", false
+	}
+	for _, stored = range p.hashed {
+		if !sdkaccess.VerifyClientKey(stored, value) {
+			continue
+		}
+		p.verifiedMu.Lock()
+		if len(p.verified) >= maxVerifiedKeys {
+			clear(p.verified)
+		}
+		p.verified[digest] = stored
+		p.verifiedMu.Unlock()
+		return stored, true
+	}
+	p.verifiedMu.Lock()
+	if len(p.rejected) >= maxRejectedKeys {
+		clear(p.rejected)
+	}
+	p.rejected[digest] = struct{}{}
+	p.verifiedMu.Unlock()
+	return "", false
+}
+
+// hashCandidate reports whether value may be checked against hashed entries. Virtual keys and
+// JWTs are handled by their own providers, so they are not run through the slow hashes.
+func hashCandidate(value string) bool {
+	if virtualkey.IsVirtualKey(value) {
+		return false
+	}
+	return strings.Count(value, ".") != 2 || !strings.HasPrefix(value, "eyJ")
+}
+
 func extractBearerToken(header string) string {
 	if header == "" {
 		return ""
@@ -0,0 +1,290 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxJWKSSize bounds a JWKS document fetched from a URL.
+const maxJWKSSize = 1 << 20
+
+// jwk is one entry of a JSON Web Key Set (RFC 7517).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type verificationKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// parseJWKS decodes the signing keys of a JWKS document. Encryption keys and key types that
+// cannot verify signatures are skipped.
+func parseJWKS(data []byte) ([]verificationKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+	keys := make([]verificationKey, 0, len(doc.Keys))
+	for _, entry := range doc.Keys {
+		if entry.Use != "" && entry.Use != "sig" {
+			continue
+		}
+		key, err := entry.publicKey()
+		if err != nil {
+			log.Debugf("jwt access: skipping jwks key %q: %v", entry.Kid, err)
+			continue
+		}
+		keys = append(keys, verificationKey{kid: entry.Kid, alg: entry.Alg, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("unsupported exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(raw), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// keySet caches the keys of a JWKS file or URL and reloads them once the refresh interval has
+// passed, or sooner when a token names an unknown key ID. Reloads run in the background, one at
+// a time, and the previous keys keep serving until the new set is in place.
+type keySet struct {
+	source  string
+	isURL   bool
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        []verificationKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+	// loading is closed when the reload in flight finishes; nil when none is running.
+	loading chan struct{}
+}
+
+func newKeySet(source string, refresh time.Duration) *keySet {
+	source = strings.TrimSpace(source)
+	lower := strings.ToLower(source)
+	return &keySet{
+		source:  source,
+		isURL:   strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://"),
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// warm starts loading the keys in the background.
+func (s *keySet) warm() {
+	s.mu.Lock()
+	s.startReloadLocked(time.Now())
+	s.mu.Unlock()
+}
+
+// lookup returns the candidate keys for kid, reloading the set when it is stale or kid is unknown.
+// Cached keys that match are returned without waiting for the reload; otherwise lookup waits for
+// it until ctx ends.
+func (s *keySet) lookup(ctx context.Context, kid string) []verificationKey {
+	s.mu.Lock()
+	now := time.Now()
+	matched := matchKeys(s.keys, kid)
+	stale := s.loadedAt.IsZero() || now.Sub(s.loadedAt) >= s.refresh
+	if !stale && kid != "" && len(matched) == 0 {
+		// A rotated signing key shows up as an unknown kid; reload, but not more than
+		// once per minimum back-off so bogus tokens cannot hammer the JWKS endpoint.
+		stale = true
+	}
+	var loading <-chan struct{}
+	if stale {
+		loading = s.startReloadLocked(now)
+	}
+	s.mu.Unlock()
+	if len(matched) > 0 || loading == nil {
+		return matched
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-loading:
+	case <-ctx.Done():
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return matchKeys(s.keys, kid)
+}
+
+// startReloadLocked returns the channel of the reload in flight, starting one when none runs and
+// the back-off allows. It returns nil when no reload is running.
+func (s *keySet) startReloadLocked(now time.Time) <-chan struct{} {
+	if s.loading != nil {
+		return s.loading
+	}
+	if !s.attemptedAt.IsZero() && now.Sub(s.attemptedAt) < minReloadInterval(s.refresh) {
+		return nil
+	}
+	s.attemptedAt = now
+	loading := make(chan struct{})
+	s.loading = loading
+	go s.reload(loading)
+	return loading
+}
+
+// reload fetches the key set and swaps it in, keeping the previous keys on failure. The fetch is
+// detached from any request so an abandoned request does not cut it short.
+func (s *keySet) reload(done chan struct{}) {
+	keys, err := s.load(context.Background())
+	s.mu.Lock()
+	if err != nil {
+		log.Warnf("jwt access: failed to load jwks from %s: %v", s.source, err)
+	} else {
+		s.keys = keys
+		s.loadedAt = time.Now()
+	}
+	s.loading = nil
+	s.mu.Unlock()
+	close(done)
+}
+
+func minReloadInterval(refresh time.Duration) time.Duration {
+	if refresh < 30*time.Second {
+		return refresh
+	}
+	return 30 * time.Second
+}
+
+func matchKeys(keys []verificationKey, kid string) []verificationKey {
+	if kid == "" {
+		return keys
+	}
+	var out []verificationKey
+	for _, key := range keys {
+		if key.kid == kid {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func (s *keySet) load(ctx context.Context) ([]verificationKey, error) {
+	if !s.isURL {
+		data, err := os.ReadFile(s.source)
+		if err != nil {
+			return nil, err
+		}
+		return parseJWKS(data)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("jwt access: close jwks response: %v", errClose)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
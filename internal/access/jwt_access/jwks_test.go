@@ -0,0 +1,55 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeySet_ServesCachedKeysDuringRefresh(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	doc := fmt.Sprintf(`{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k1","x":%q}]}`, base64.RawURLEncoding.EncodeToString(public))
+	release := make(chan struct{})
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests > 1 {
+			<-release
+		}
+		_, _ = w.Write([]byte(doc))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	set := newKeySet(server.URL, time.Hour)
+	if keys := set.lookup(context.Background(), "k1"); len(keys) != 1 {
+		t.Fatalf("expected the first lookup to wait for the initial load, got %d keys", len(keys))
+	}
+
+	// Force the set stale; the refresh now hangs until release is closed.
+	set.mu.Lock()
+	set.loadedAt = time.Now().Add(-2 * time.Hour)
+	set.attemptedAt = time.Time{}
+	set.mu.Unlock()
+	started := time.Now()
+	if keys := set.lookup(context.Background(), "k1"); len(keys) != 1 {
+		t.Fatalf("expected cached keys during a refresh, got %d", len(keys))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if keys := set.lookup(ctx, "unknown"); len(keys) != 0 {
+		t.Fatalf("expected no keys for an unknown kid, got %d", len(keys))
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("lookups blocked behind the refresh for %v", elapsed)
+	}
+}
@@ -0,0 +1,366 @@
+// Package jwtaccess provides the "jwt" access provider, which authenticates requests carrying an
+// OIDC/JWT bearer token by validating its signature against a JWKS and checking the issuer,
+// audience and expiry claims.
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	sdkconfig "github.com/radityprtama/proxygate/v6/sdk/config"
+)
+
+const (
+	defaultRefreshInterval = 10 * time.Minute
+	defaultLeeway          = time.Minute
+)
+
+// Result.Metadata keys populated from token claims.
+const (
+	MetadataSubject = "subject"
+	MetadataGroups  = "groups"
+	MetadataIssuer  = "issuer"
+)
+
+var registerOnce sync.Once
+
+// Register ensures the jwt access provider is available to the access manager.
+func Register() {
+	registerOnce.Do(func() {
+		sdkaccess.RegisterProvider(sdkconfig.AccessProviderTypeJWT, newProvider)
+	})
+}
+
+type provider struct {
+	name         string
+	keys         *keySet
+	issuer       string
+	audiences    []string
+	leeway       time.Duration
+	subjectClaim string
+	groupsClaim  string
+	adminGroups  map[string]struct{}
+	root         *sdkconfig.SDKConfig
+	now          func() time.Time
+}
+
+func newProvider(cfg *sdkconfig.AccessProvider, root *sdkconfig.SDKConfig) (sdkaccess.Provider, error) {
+	name := cfg.Name
+	if name == "" {
+		name = sdkconfig.AccessProviderTypeJWT
+	}
+	options := cfg.Config
+	jwksFile := stringOption(options, "jwks-file")
+	jwksURL := stringOption(options, "jwks-url")
+	if (jwksFile == "") == (jwksURL == "") {
+		return nil, fmt.Errorf("exactly one of jwks-file or jwks-url is required")
+	}
+	source := jwksFile
+	if source == "" {
+		source = jwksURL
+	}
+	refresh, err := durationOption(options, "refresh-interval", defaultRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+	if refresh <= 0 {
+		refresh = defaultRefreshInterval
+	}
+	leeway, err := durationOption(options, "leeway", defaultLeeway)
+	if err != nil {
+		return nil, err
+	}
+	p := &provider{
+		name:         name,
+		keys:         newKeySet(source, refresh),
+		issuer:       stringOption(options, "issuer"),
+		audiences:    stringListOption(options, "audience"),
+		leeway:       leeway,
+		subjectClaim: stringOption(options, "subject-claim"),
+		groupsClaim:  stringOption(options, "groups-claim"),
+		adminGroups:  make(map[string]struct{}),
+		root:         root,
+		now:          time.Now,
+	}
+	if p.subjectClaim == "" {
+		p.subjectClaim = "sub"
+	}
+	if p.groupsClaim == "" {
+		p.groupsClaim = "groups"
+	}
+	for _, group := range stringListOption(options, "admin-groups") {
+		p.adminGroups[group] = struct{}{}
+	}
+	// Warm the cache in the background so configuration mistakes surface in the log without
+	// holding up a config reload; failures are retried on demand.
+	p.keys.warm()
+	return p, nil
+}
+
+func (p *provider) Identifier() string {
+	if p == nil || p.name == "" {
+		return sdkconfig.AccessProviderTypeJWT
+	}
+	return p.name
+}
+
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	if p == nil {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if header == "" {
+		return nil, sdkaccess.ErrNoCredentials
+	}
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	token = strings.TrimSpace(token)
+	// Leave opaque API keys to the other providers.
+	if strings.Count(token, ".") != 2 {
+		return nil, sdkaccess.ErrNotHandled
+	}
+	claims, err := p.verify(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", sdkaccess.ErrInvalidCredential, err)
+	}
+	return p.result(claims)
+}
+
+// verify checks the token signature and registered claims and returns the decoded claims.
+func (p *provider) verify(ctx context.Context, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range p.keys.lookup(ctx, header.Kid) {
+		if key.alg != "" && key.alg != header.Alg {
+			continue
+		}
+		if verifySignature(header.Alg, key.key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("signature verification failed")
+	}
+
+	claims := make(map[string]any)
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("claims: %w", err)
+	}
+	now := p.now()
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return nil, fmt.Errorf("token has no exp claim")
+	}
+	if !now.Before(exp.Add(p.leeway)) {
+		return nil, fmt.Errorf("token expired")
+	}
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(p.leeway).Before(nbf) {
+		return nil, fmt.Errorf("token not valid yet")
+	}
+	if p.issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != p.issuer {
+			return nil, fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if len(p.audiences) > 0 && !audienceMatches(claims["aud"], p.audiences) {
+		return nil, fmt.Errorf("token audience not accepted")
+	}
+	return claims, nil
+}
+
+// result maps verified claims to the principal and metadata, attaching any per-key settings
+// configured under client-keys for the subject.
+func (p *provider) result(claims map[string]any) (*sdkaccess.Result, error) {
+	subject, _ := claims[p.subjectClaim].(string)
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return nil, fmt.Errorf("%w: token has no %s claim", sdkaccess.ErrInvalidCredential, p.subjectClaim)
+	}
+	groups := stringList(claims[p.groupsClaim])
+	metadata := map[string]string{
+		"source":        "jwt",
+		MetadataSubject: subject,
+	}
+	if iss, _ := claims["iss"].(string); iss != "" {
+		metadata[MetadataIssuer] = iss
+	}
+	if len(groups) > 0 {
+		metadata[MetadataGroups] = strings.Join(groups, ",")
+	}
+	for _, group := range groups {
+		if _, ok := p.adminGroups[group]; ok {
+			metadata[sdkaccess.MetadataAdmin] = "true"
+			break
+		}
+	}
+	sdkaccess.ApplyClientKeySettings(p.root, subject, metadata)
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: subject,
+		Metadata:  metadata,
+	}, nil
+}
+
+func decodeSegment(segment string, out any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// verifySignature checks a JWS signature for the supported RSA, RSA-PSS, ECDSA and EdDSA algorithms.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) bool {
+	switch alg {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		hashID, digest := digestFor(alg[2:], signed)
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(pub, hashID, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(pub, hashID, digest, signature) == nil
+	case "ES256", "ES384", "ES512":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		_, digest := digestFor(alg[2:], signed)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	case "EdDSA":
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, signature)
+	}
+	return false
+}
+
+func digestFor(bits string, data []byte) (crypto.Hash, []byte) {
+	var (
+		id crypto.Hash
+		h  hash.Hash
+	)
+	switch bits {
+	case "384":
+		id, h = crypto.SHA384, sha512.New384()
+	case "512":
+		id, h = crypto.SHA512, sha512.New()
+	default:
+		id, h = crypto.SHA256, sha256.New()
+	}
+	h.Write(data)
+	return id, h.Sum(nil)
+}
+
+func numericClaim(claims map[string]any, name string) (time.Time, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Second))), true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.Unix(0, int64(f*float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
+
+func audienceMatches(raw any, accepted []string) bool {
+	for _, aud := range stringList(raw) {
+		for _, want := range accepted {
+			if aud == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringList reads a claim that may be a single string or an array of strings.
+func stringList(raw any) []string {
+	switch v := raw.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+		return out
+	case []string:
+		return v
+	}
+	return nil
+}
+
+func stringOption(options map[string]any, key string) string {
+	value, _ := options[key].(string)
+	return strings.TrimSpace(value)
+}
+
+func stringListOption(options map[string]any, key string) []string {
+	return stringList(options[key])
+}
+
+func durationOption(options map[string]any, key string, fallback time.Duration) (time.Duration, error) {
+	switch v := options[key].(type) {
+	case nil:
+		return fallback, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return fallback, nil
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid %s %q", key, v)
+		}
+		return d, nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	}
+	return 0, fmt.Errorf("invalid %s", key)
+}
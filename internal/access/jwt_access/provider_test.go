@@ -0,0 +1,163 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	sdkconfig "github.com/radityprtama/proxygate/v6/sdk/config"
+)
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]any {
+	return map[string]any{"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]any {
+	return map[string]any{"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+func jwksDocument(t *testing.T, keys ...map[string]any) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+	return data
+}
+
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]any{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + b64(signature)
+}
+
+func authenticate(p sdkaccess.Provider, token string) (*sdkaccess.Result, error) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return p.Authenticate(context.Background(), req)
+}
+
+func TestProvider_ValidatesTokensAgainstJWKSFile(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwksDocument(t, rsaJWK("rsa-1", rsaKey), ecJWK("ec-1", ecKey)), 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	root := &sdkconfig.SDKConfig{ClientKeys: []sdkconfig.ClientKey{{Key: "alice", AllowedModels: []string{"gpt-*"}}}}
+	p, err := newProvider(&sdkconfig.AccessProvider{Type: sdkconfig.AccessProviderTypeJWT, Config: map[string]any{
+		"jwks-file":    path,
+		"issuer":       "https://idp.example.com",
+		"audience":     []any{"proxygate"},
+		"admin-groups": []any{"ops"},
+	}}, root)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	valid := map[string]any{"sub": "alice", "iss": "https://idp.example.com", "aud": "proxygate", "exp": exp, "groups": []string{"dev", "ops"}}
+
+	res, err := authenticate(p, signToken(t, "RS256", "rsa-1", rsaKey, valid))
+	if err != nil {
+		t.Fatalf("expected RS256 token accepted, got %v", err)
+	}
+	if res.Principal != "alice" || res.Metadata[MetadataGroups] != "dev,ops" || res.Metadata[sdkaccess.MetadataAdmin] != "true" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if policy, ok := sdkaccess.PolicyFromMetadata(res.Metadata); !ok || len(policy.AllowedModels) != 1 {
+		t.Fatalf("expected client-keys policy for subject, got %+v", res.Metadata)
+	}
+	if _, err = authenticate(p, signToken(t, "ES256", "ec-1", ecKey, valid)); err != nil {
+		t.Fatalf("expected ES256 token accepted, got %v", err)
+	}
+
+	cases := map[string]string{
+		"expired":      signToken(t, "RS256", "rsa-1", rsaKey, map[string]any{"sub": "alice", "iss": "https://idp.example.com", "aud": "proxygate", "exp": time.Now().Add(-time.Hour).Unix()}),
+		"issuer":       signToken(t, "RS256", "rsa-1", rsaKey, map[string]any{"sub": "alice", "iss": "https://evil.example.com", "aud": "proxygate", "exp": exp}),
+		"audience":     signToken(t, "RS256", "rsa-1", rsaKey, map[string]any{"sub": "alice", "iss": "https://idp.example.com", "aud": "other", "exp": exp}),
+		"wrong-key":    signToken(t, "ES256", "rsa-1", ecKey, valid),
+		"unsigned-alg": signToken(t, "none", "rsa-1", rsaKey, valid),
+	}
+	for name, token := range cases {
+		if _, err = authenticate(p, token); !errors.Is(err, sdkaccess.ErrInvalidCredential) {
+			t.Fatalf("%s: expected invalid credential, got %v", name, err)
+		}
+	}
+	if _, err = authenticate(p, "sk-static-api-key"); !errors.Is(err, sdkaccess.ErrNotHandled) {
+		t.Fatalf("expected opaque keys to be left to other providers, got %v", err)
+	}
+}
+
+func TestProvider_ReloadsJWKSURLOnKeyRotation(t *testing.T) {
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	var rotated atomic.Bool
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		if rotated.Load() {
+			_, _ = w.Write(jwksDocument(t, rsaJWK("new", newKey)))
+			return
+		}
+		_, _ = w.Write(jwksDocument(t, rsaJWK("old", oldKey)))
+	}))
+	defer server.Close()
+
+	p, err := newProvider(&sdkconfig.AccessProvider{Type: sdkconfig.AccessProviderTypeJWT, Config: map[string]any{
+		"jwks-url":         server.URL,
+		"refresh-interval": "1ms",
+	}}, nil)
+	if err != nil {
+		t.Fatalf("newProvider: %v", err)
+	}
+	claims := map[string]any{"sub": "svc", "exp": time.Now().Add(time.Hour).Unix()}
+	if _, err = authenticate(p, signToken(t, "RS256", "old", oldKey, claims)); err != nil {
+		t.Fatalf("expected token signed by initial key accepted, got %v", err)
+	}
+	rotated.Store(true)
+	time.Sleep(5 * time.Millisecond)
+	if _, err = authenticate(p, signToken(t, "RS256", "new", newKey, claims)); err != nil {
+		t.Fatalf("expected token signed by rotated key accepted, got %v", err)
+	}
+	if fetches.Load() < 2 {
+		t.Fatalf("expected the JWKS to be fetched again, got %d fetches", fetches.Load())
+	}
+}
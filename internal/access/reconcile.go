@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/radityprtama/proxygate/v6/internal/config"
+	"github.com/radityprtama/proxygate/v6/internal/virtualkey"
 	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
 	sdkConfig "github.com/radityprtama/proxygate/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -51,7 +52,10 @@ func ReconcileProviders(oldCfg, newCfg *config.Config, existing []sdkaccess.Prov
 			continue
 		}
 
-		forceRebuild := strings.EqualFold(strings.TrimSpace(providerCfg.Type), sdkConfig.AccessProviderTypeConfigAPIKey)
+		providerType := strings.TrimSpace(providerCfg.Type)
+		forceRebuild := strings.EqualFold(providerType, sdkConfig.AccessProviderTypeConfigAPIKey) ||
+			((strings.EqualFold(providerType, sdkConfig.AccessProviderTypeJWT) ||
+				strings.EqualFold(providerType, sdkConfig.AccessProviderTypeClientCert)) && !clientKeysEqual(oldCfg, newCfg))
 		if oldCfgProvider, ok := oldCfgMap[key]; ok {
 			isAliased := oldCfgProvider == providerCfg
 			if !forceRebuild && !isAliased && providerConfigEqual(oldCfgProvider, providerCfg) {
@@ -80,12 +84,12 @@ func ReconcileProviders(oldCfg, newCfg *config.Config, existing []sdkaccess.Prov
 		finalIDs[key] = struct{}{}
 	}
 
-	if len(result) == 0 {
+	if !newCfg.HasConfigAPIKeyProvider() {
 		if inline := sdkConfig.MakeInlineAPIKeyProvider(newCfg.APIKeys); inline != nil {
 			key := providerIdentifier(inline)
 			if key != "" {
 				if oldCfgProvider, ok := oldCfgMap[key]; ok {
-					if providerConfigEqual(oldCfgProvider, inline) {
+					if providerConfigEqual(oldCfgProvider, inline) && clientKeysEqual(oldCfg, newCfg) {
 						if existingProvider, okExisting := existingMap[key]; okExisting {
 							result = append(result, existingProvider)
 							finalIDs[key] = struct{}{}
@@ -111,6 +115,12 @@ func ReconcileProviders(oldCfg, newCfg *config.Config, existing []sdkaccess.Prov
 	inlineDone:
 	}
 
+	// Virtual keys are managed at runtime, so their provider is always present.
+	if _, ok := finalIDs[virtualkey.ProviderName]; !ok {
+		result = append(result, virtualkey.AccessProvider())
+		finalIDs[virtualkey.ProviderName] = struct{}{}
+	}
+
 	removedSet := make(map[string]struct{})
 	for id := range existingMap {
 		if _, ok := finalIDs[id]; !ok {
@@ -176,7 +186,7 @@ func accessProviderMap(cfg *config.Config) map[string]*sdkConfig.AccessProvider
 		}
 		result[key] = providerCfg
 	}
-	if len(result) == 0 && len(cfg.APIKeys) > 0 {
+	if !cfg.HasConfigAPIKeyProvider() && len(cfg.APIKeys) > 0 {
 		if provider := sdkConfig.MakeInlineAPIKeyProvider(cfg.APIKeys); provider != nil {
 			if key := providerIdentifier(provider); key != "" {
 				result[key] = provider
@@ -197,7 +207,7 @@ func collectProviderEntries(cfg *config.Config) []*sdkConfig.AccessProvider {
 			entries = append(entries, providerCfg)
 		}
 	}
-	if len(entries) == 0 && len(cfg.APIKeys) > 0 {
+	if !cfg.HasConfigAPIKeyProvider() && len(cfg.APIKeys) > 0 {
 		if inline := sdkConfig.MakeInlineAPIKeyProvider(cfg.APIKeys); inline != nil {
 			entries = append(entries, inline)
 		}
@@ -268,3 +278,12 @@ func stringSetEqual(a, b []string) bool {
 	}
 	return len(seen) == 0
 }
+
+// clientKeysEqual reports whether per-key settings are unchanged, since inline, JWT and
+// client-cert providers capture them at build time.
+func clientKeysEqual(oldCfg, newCfg *config.Config) bool {
+	if oldCfg == nil || newCfg == nil {
+		return oldCfg == newCfg
+	}
+	return reflect.DeepEqual(oldCfg.ClientKeys, newCfg.ClientKeys)
+}
@@ -376,7 +376,15 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 		"runtime_only":   runtimeOnly,
 		"source":         "memory",
 		"size":           int64(0),
+		"priority":       coreauth.AuthPriority(auth),
+		"weight":         coreauth.AuthWeight(auth),
 	}
+	if limits := coreauth.AuthThrottleLimits(auth); limits.MaxConcurrent > 0 || limits.MinInterval > 0 {
+		entry["max_concurrent"] = limits.MaxConcurrent
+		entry["min_interval_ms"] = limits.MinInterval.Milliseconds()
+	}
+	h.addAuthLoadFields(entry, auth.ID)
+	addRateLimitFields(entry, auth)
 	if email := authEmail(auth); email != "" {
 		entry["email"] = email
 	}
@@ -417,6 +425,55 @@ func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
 	return entry
 }
 
+// addAuthLoadFields exposes the live in-flight counters and TTFB averages used by load-aware selection.
+func (h *Handler) addAuthLoadFields(entry gin.H, authID string) {
+	if h.authManager == nil {
+		return
+	}
+	loads := h.authManager.AuthLoads(authID)
+	var inFlight int64
+	models := make(gin.H, len(loads))
+	for model, load := range loads {
+		inFlight += load.InFlight
+		modelEntry := gin.H{
+			"in_flight":    load.InFlight,
+			"samples":      load.Samples,
+			"ttfb_ewma_ms": load.TTFB.Milliseconds(),
+		}
+		if !load.LastSampleAt.IsZero() {
+			modelEntry["last_sample_at"] = load.LastSampleAt
+		}
+		models[model] = modelEntry
+	}
+	entry["in_flight"] = inFlight
+	if len(models) > 0 {
+		entry["load"] = models
+	}
+}
+
+// addRateLimitFields exposes the per-model quota snapshots parsed from upstream rate-limit headers.
+func addRateLimitFields(entry gin.H, auth *coreauth.Auth) {
+	now := time.Now()
+	quotas := make(gin.H)
+	for model, state := range auth.ModelStates {
+		if state == nil || state.RateLimit == nil {
+			continue
+		}
+		exhausted, recoverAt := state.RateLimit.Exhausted(now)
+		quota := gin.H{
+			"snapshot":  state.RateLimit,
+			"exhausted": exhausted,
+		}
+		if exhausted {
+			quota["recover_at"] = recoverAt
+		}
+		quotas[model] = quota
+	}
+	if len(quotas) > 0 {
+		entry["rate_limits"] = quotas
+	}
+}
+
 func authEmail(auth *coreauth.Auth) string {
 	if auth == nil {
 		return ""
@@ -0,0 +1,60 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/budget"
+)
+
+// GetBudgets lists the token budget consumption and limits of every tracked client key.
+func (h *Handler) GetBudgets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"budgets": budget.Default().Snapshot(time.Now())})
+}
+
+// ResetBudget clears the consumption of the key given by ?key=, or of every key when ?all=true
+// is supplied. ?period=daily|monthly limits the reset to one period.
+func (h *Handler) ResetBudget(c *gin.Context) {
+	key := strings.TrimSpace(c.Query("key"))
+	if all := c.Query("all"); all != "true" && all != "1" && all != "*" && key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+	count, err := budget.Default().Reset(key, strings.ToLower(strings.TrimSpace(c.Query("period"))))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if key != "" && count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "reset": count})
+}
+
+type budgetAdjustRequest struct {
+	Key          string `json:"key"`
+	Period       string `json:"period"`
+	InputTokens  int64  `json:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens"`
+	TotalTokens  int64  `json:"total_tokens"`
+}
+
+// AdjustBudget adds the token deltas in the request body to the current consumption of a key.
+// Negative deltas give tokens back.
+func (h *Handler) AdjustBudget(c *gin.Context) {
+	var body budgetAdjustRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	delta := budget.Usage{InputTokens: body.InputTokens, OutputTokens: body.OutputTokens, TotalTokens: body.TotalTokens}
+	period := strings.ToLower(strings.TrimSpace(body.Period))
+	if err := budget.Default().Adjust(strings.TrimSpace(body.Key), period, delta); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
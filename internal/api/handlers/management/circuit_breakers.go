@@ -0,0 +1,41 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCircuitBreakers lists the per-provider circuit breaker state.
+func (h *Handler) GetCircuitBreakers(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"circuit-breakers": h.authManager.BreakerStatuses()})
+}
+
+// ResetCircuitBreaker closes the breaker for the provider given by ?provider=, or every breaker
+// when ?all=true is supplied.
+func (h *Handler) ResetCircuitBreaker(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	if all := c.Query("all"); all == "true" || all == "1" || all == "*" {
+		h.authManager.ResetBreaker("")
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		return
+	}
+	provider := strings.TrimSpace(c.Query("provider"))
+	if provider == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "provider is required"})
+		return
+	}
+	if !h.authManager.ResetBreaker(provider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "circuit breaker not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
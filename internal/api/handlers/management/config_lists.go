@@ -3,10 +3,12 @@ package management
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/radityprtama/proxygate/v6/internal/config"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
 )
 
 // Generic helpers for list[string]
@@ -109,14 +111,45 @@ func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.c
 func (h *Handler) PutAPIKeys(c *gin.Context) {
 	h.putStringList(c, func(v []string) {
 		h.cfg.APIKeys = append([]string(nil), v...)
-		h.cfg.Access.Providers = nil
+		h.cfg.RemoveConfigAPIKeyProviders()
 	}, nil)
 }
 func (h *Handler) PatchAPIKeys(c *gin.Context) {
-	h.patchStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
+	h.patchStringList(c, &h.cfg.APIKeys, func() { h.cfg.RemoveConfigAPIKeyProviders() })
 }
 func (h *Handler) DeleteAPIKeys(c *gin.Context) {
-	h.deleteFromStringList(c, &h.cfg.APIKeys, func() { h.cfg.Access.Providers = nil })
+	h.deleteFromStringList(c, &h.cfg.APIKeys, func() { h.cfg.RemoveConfigAPIKeyProviders() })
+}
+
+// GenerateAPIKey creates a random client key, stores only its hash in api-keys and returns the
+// plaintext. The plaintext cannot be retrieved again. An optional {"hash": "sha256"|"bcrypt"}
+// body selects the hash scheme.
+func (h *Handler) GenerateAPIKey(c *gin.Context) {
+	var body struct {
+		Hash string `json:"hash"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	plaintext, hashed, err := sdkaccess.GenerateClientKey(body.Hash)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg.APIKeys = append(h.cfg.APIKeys, hashed)
+	h.cfg.RemoveConfigAPIKeyProviders()
+	if err = config.SaveConfigPreserveComments(h.configFilePath, h.cfg); err != nil {
+		h.cfg.APIKeys = h.cfg.APIKeys[:len(h.cfg.APIKeys)-1]
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to save config: %v", err)})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"api-key": plaintext, "hash": hashed})
 }
 
 // gemini-api-key: []GeminiKey
@@ -770,6 +803,40 @@ func (h *Handler) PutAmpRestrictManagementToLocalhost(c *gin.Context) {
 	h.updateBoolField(c, func(v bool) { h.cfg.AmpCode.RestrictManagementToLocalhost = v })
 }
 
+// GetPricing returns the model pricing table.
+func (h *Handler) GetPricing(c *gin.Context) {
+	if h == nil || h.cfg == nil {
+		c.JSON(200, gin.H{"pricing": []config.ModelPrice{}})
+		return
+	}
+	c.JSON(200, gin.H{"pricing": h.cfg.Pricing})
+}
+
+// PutPricing replaces the model pricing table.
+func (h *Handler) PutPricing(c *gin.Context) {
+	var body struct {
+		Value []config.ModelPrice `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(400, gin.H{"error": "invalid body"})
+		return
+	}
+	for _, entry := range body.Value {
+		if strings.TrimSpace(entry.Model) == "" {
+			c.JSON(400, gin.H{"error": "pricing entry missing model"})
+			return
+		}
+		if entry.Input < 0 || entry.Output < 0 ||
+			(entry.CachedInput != nil && *entry.CachedInput < 0) ||
+			(entry.Reasoning != nil && *entry.Reasoning < 0) {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("pricing entry %q has a negative price", entry.Model)})
+			return
+		}
+	}
+	h.cfg.Pricing = body.Value
+	h.persist(c)
+}
+
 // GetAmpModelMappings returns the ampcode model mappings.
 func (h *Handler) GetAmpModelMappings(c *gin.Context) {
 	if h == nil || h.cfg == nil {
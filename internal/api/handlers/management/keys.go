@@ -0,0 +1,77 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/virtualkey"
+)
+
+// ListVirtualKeys returns every virtual key. Secrets are never included.
+func (h *Handler) ListVirtualKeys(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"keys": virtualkey.Default().List()})
+}
+
+// GetVirtualKey returns the virtual key named by the :id path parameter.
+func (h *Handler) GetVirtualKey(c *gin.Context) {
+	key, ok := virtualkey.Default().Get(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": virtualkey.ErrNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"key": key})
+}
+
+// CreateVirtualKey creates a virtual key from the JSON body ({name, owner, tags, policy, budget})
+// and returns its secret. The secret is shown only in this response.
+func (h *Handler) CreateVirtualKey(c *gin.Context) {
+	var spec virtualkey.Spec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	key, secret, err := virtualkey.Default().Create(c.Request.Context(), spec)
+	if err != nil {
+		writeVirtualKeyError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"key": key, "api-key": secret})
+}
+
+// PatchVirtualKey updates the fields present in the JSON body of the virtual key named by :id.
+func (h *Handler) PatchVirtualKey(c *gin.Context) {
+	var spec virtualkey.Spec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	key, err := virtualkey.Default().Update(c.Request.Context(), strings.TrimSpace(c.Param("id")), spec)
+	if err != nil {
+		writeVirtualKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"key": key})
+}
+
+// DeleteVirtualKey revokes the virtual key named by :id.
+func (h *Handler) DeleteVirtualKey(c *gin.Context) {
+	if err := virtualkey.Default().Delete(c.Request.Context(), strings.TrimSpace(c.Param("id"))); err != nil {
+		writeVirtualKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func writeVirtualKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, virtualkey.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, virtualkey.ErrInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,20 +1,363 @@
 package management
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/radityprtama/proxygate/v6/internal/usage"
+	log "github.com/sirupsen/logrus"
 )
 
-// GetUsageStatistics returns the in-memory request statistics snapshot.
+// defaultUsageDetailLimit caps the per-request rows returned from a persistent usage store.
+const defaultUsageDetailLimit = 1000
+
+// GetUsageStatistics returns the request statistics snapshot. With a persistent usage store it
+// is built from the stored rollups; otherwise from the in-memory statistics. The optional from
+// and to parameters (RFC 3339, YYYY-MM-DD or unix seconds) restrict it to a time range, and
+// granularity (hour or day) selects the rollups returned as series.
 func (h *Handler) GetUsageStatistics(c *gin.Context) {
+	response, snapshot, ok := h.usageSnapshot(c)
+	if !ok {
+		return
+	}
+	response["usage"] = snapshot
+	response["failed_requests"] = snapshot.FailureCount
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUsageCost returns the priced cost of usage by client key, model and credential. It accepts
+// the same range parameters as GetUsageStatistics.
+func (h *Handler) GetUsageCost(c *gin.Context) {
+	response, snapshot, ok := h.usageSnapshot(c)
+	if !ok {
+		return
+	}
+	delete(response, "series")
+	byKey := make(map[string]float64, len(snapshot.APIs))
+	for key, api := range snapshot.APIs {
+		byKey[key] = api.TotalCost
+	}
+	byModel := snapshot.CostByModel
+	if byModel == nil {
+		byModel = map[string]float64{}
+	}
+	byCredential := snapshot.CostByCredential
+	if byCredential == nil {
+		byCredential = map[string]float64{}
+	}
+	response["total_cost"] = snapshot.TotalCost
+	response["by_key"] = byKey
+	response["by_model"] = byModel
+	response["by_credential"] = byCredential
+	response["priced"] = usage.CurrentPricing() != nil
+	c.JSON(http.StatusOK, response)
+}
+
+// usageSnapshot parses the range parameters and builds the matching snapshot, from the
+// persistent usage store when one is installed. It writes the error response and reports false
+// when the request is invalid or the store fails.
+func (h *Handler) usageSnapshot(c *gin.Context) (gin.H, usage.StatisticsSnapshot, bool) {
 	var snapshot usage.StatisticsSnapshot
+	from, errFrom := parseUsageTime(c.Query("from"), false)
+	if errFrom != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", errFrom)})
+		return nil, snapshot, false
+	}
+	to, errTo := parseUsageTime(c.Query("to"), true)
+	if errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", errTo)})
+		return nil, snapshot, false
+	}
+	granularity := strings.ToLower(strings.TrimSpace(c.DefaultQuery("granularity", usage.GranularityHour)))
+	if granularity != usage.GranularityHour && granularity != usage.GranularityDay {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid granularity: must be hour or day"})
+		return nil, snapshot, false
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return nil, snapshot, false
+	}
+	if limit == 0 {
+		limit = defaultUsageDetailLimit
+	}
+
+	response := gin.H{}
+	if !from.IsZero() {
+		response["from"] = from
+	}
+	if !to.IsZero() {
+		response["to"] = to
+	}
+
+	if sink := usage.PersistentSink(); sink != nil {
+		result, err := sink.Query(c.Request.Context(), usage.Query{
+			From:        from,
+			To:          to,
+			Granularity: granularity,
+			DetailLimit: limit,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to query usage store: %v", err)})
+			return nil, snapshot, false
+		}
+		series := result.Buckets
+		if series == nil {
+			series = []usage.Bucket{}
+		}
+		response["granularity"] = granularity
+		response["series"] = series
+		return response, usage.SnapshotFromQuery(result, granularity), true
+	}
+
 	if h != nil && h.usageStats != nil {
-		snapshot = h.usageStats.Snapshot()
+		snapshot = usage.FilterSnapshot(h.usageStats.Snapshot(), from, to)
+	}
+	return response, snapshot, true
+}
+
+// usageExportFlushRows is the number of exported rows written between flushes to the client.
+const usageExportFlushRows = 500
+
+// usageExportRow is one exported group. Dimensions outside the requested grouping are omitted.
+type usageExportRow struct {
+	Key             *string `json:"key,omitempty"`
+	Model           *string `json:"model,omitempty"`
+	Provider        *string `json:"provider,omitempty"`
+	Auth            *string `json:"auth,omitempty"`
+	Requests        int64   `json:"requests"`
+	Successes       int64   `json:"successes"`
+	Failures        int64   `json:"failures"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	CachedTokens    int64   `json:"cached_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	Cost            float64 `json:"cost"`
+}
+
+var usageExportCounterColumns = []string{
+	"requests", "successes", "failures", "input_tokens", "output_tokens",
+	"reasoning_tokens", "cached_tokens", "total_tokens", "cost",
+}
+
+// ExportUsage streams usage aggregated by group_by (key, model, provider and/or auth) over the
+// optional from/to range as CSV or JSON lines (format=csv|jsonl). With a persistent usage store
+// it reads the daily rollups when both bounds fall on UTC day boundaries and the hourly rollups
+// otherwise; granularity overrides the choice.
+func (h *Handler) ExportUsage(c *gin.Context) {
+	from, errFrom := parseUsageTime(c.Query("from"), false)
+	if errFrom != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", errFrom)})
+		return
+	}
+	to, errTo := parseUsageTime(c.Query("to"), true)
+	if errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", errTo)})
+		return
+	}
+	groupBy, errGroup := usage.ParseGroupBy(c.Query("group_by"))
+	if errGroup != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid group_by: %v", errGroup)})
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "csv")))
+	if format != "csv" && format != "jsonl" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format: must be csv or jsonl"})
+		return
+	}
+	granularity := strings.ToLower(strings.TrimSpace(c.Query("granularity")))
+	switch granularity {
+	case "":
+		granularity = usage.GranularityHour
+		if isDayBoundary(from) && isDayBoundary(to) {
+			granularity = usage.GranularityDay
+		}
+	case usage.GranularityHour, usage.GranularityDay:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid granularity: must be hour or day"})
+		return
+	}
+	query := usage.ExportQuery{From: from, To: to, Granularity: granularity, GroupBy: groupBy}
+
+	// Headers are sent with the first row, so a failure before any output still gets a JSON error.
+	var writer usageExportWriter
+	written := 0
+	start := func() error {
+		filename := "usage-export." + format
+		if !from.IsZero() || !to.IsZero() {
+			filename = fmt.Sprintf("usage-%s-%s.%s", usageExportBound(from), usageExportBound(to), format)
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		if format == "csv" {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			writer = &csvUsageExportWriter{w: csv.NewWriter(c.Writer), groupBy: groupBy}
+		} else {
+			c.Header("Content-Type", "application/x-ndjson")
+			writer = &jsonlUsageExportWriter{enc: json.NewEncoder(c.Writer), groupBy: groupBy}
+		}
+		c.Status(http.StatusOK)
+		return writer.begin()
+	}
+	emit := func(group usage.Bucket) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.write(group); err != nil {
+			return err
+		}
+		written++
+		if written%usageExportFlushRows == 0 {
+			if err := writer.flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	}
+
+	var err error
+	if sink := usage.PersistentSink(); sink != nil {
+		err = usage.AggregateSink(c.Request.Context(), sink, query, emit)
+	} else if h != nil && h.usageStats != nil {
+		err = h.usageStats.Aggregate(query, emit)
+	}
+	if err == nil && writer == nil {
+		err = start()
+	}
+	if err == nil {
+		err = writer.flush()
+		c.Writer.Flush()
+	}
+	if err == nil {
+		return
+	}
+	if writer == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to export usage: %v", err)})
+		return
+	}
+	// The response is already under way; the truncated body is all the client can get.
+	log.Errorf("usage export aborted after %d rows: %v", written, err)
+}
+
+// usageExportWriter encodes exported groups in one output format.
+type usageExportWriter interface {
+	begin() error
+	write(group usage.Bucket) error
+	flush() error
+}
+
+type csvUsageExportWriter struct {
+	w       *csv.Writer
+	groupBy usage.GroupBy
+}
+
+func (e *csvUsageExportWriter) begin() error {
+	header := append(append([]string{}, e.groupBy...), usageExportCounterColumns...)
+	return e.w.Write(header)
+}
+
+func (e *csvUsageExportWriter) write(group usage.Bucket) error {
+	record := make([]string, 0, len(e.groupBy)+len(usageExportCounterColumns))
+	for _, dim := range e.groupBy {
+		record = append(record, usage.Dimension(group, dim))
+	}
+	record = append(record,
+		strconv.FormatInt(group.Requests, 10),
+		strconv.FormatInt(group.Requests-group.Failures, 10),
+		strconv.FormatInt(group.Failures, 10),
+		strconv.FormatInt(group.Tokens.InputTokens, 10),
+		strconv.FormatInt(group.Tokens.OutputTokens, 10),
+		strconv.FormatInt(group.Tokens.ReasoningTokens, 10),
+		strconv.FormatInt(group.Tokens.CachedTokens, 10),
+		strconv.FormatInt(group.Tokens.TotalTokens, 10),
+		strconv.FormatFloat(group.Cost, 'f', -1, 64),
+	)
+	return e.w.Write(record)
+}
+
+func (e *csvUsageExportWriter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type jsonlUsageExportWriter struct {
+	enc     *json.Encoder
+	groupBy usage.GroupBy
+}
+
+func (e *jsonlUsageExportWriter) begin() error { return nil }
+
+func (e *jsonlUsageExportWriter) write(group usage.Bucket) error {
+	return e.enc.Encode(usageExportRowFromBucket(group, e.groupBy))
+}
+
+func (e *jsonlUsageExportWriter) flush() error { return nil }
+
+func usageExportRowFromBucket(group usage.Bucket, groupBy usage.GroupBy) usageExportRow {
+	row := usageExportRow{
+		Requests:        group.Requests,
+		Successes:       group.Requests - group.Failures,
+		Failures:        group.Failures,
+		InputTokens:     group.Tokens.InputTokens,
+		OutputTokens:    group.Tokens.OutputTokens,
+		ReasoningTokens: group.Tokens.ReasoningTokens,
+		CachedTokens:    group.Tokens.CachedTokens,
+		TotalTokens:     group.Tokens.TotalTokens,
+		Cost:            group.Cost,
+	}
+	for _, dim := range groupBy {
+		value := usage.Dimension(group, dim)
+		switch dim {
+		case usage.GroupByKey:
+			row.Key = &value
+		case usage.GroupByModel:
+			row.Model = &value
+		case usage.GroupByProvider:
+			row.Provider = &value
+		case usage.GroupByAuth:
+			row.Auth = &value
+		}
+	}
+	return row
+}
+
+func isDayBoundary(t time.Time) bool {
+	return t.IsZero() || t.Equal(usage.BucketStart(t, usage.GranularityDay))
+}
+
+func usageExportBound(t time.Time) string {
+	if t.IsZero() {
+		return "all"
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// parseUsageTime parses a range bound. A bare date used as the upper bound covers that whole day.
+func parseUsageTime(raw string, upper bool) (time.Time, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		if upper {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0).UTC(), nil
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"usage":           snapshot,
-		"failed_requests": snapshot.FailureCount,
-	})
+	return time.Time{}, fmt.Errorf("must be RFC 3339, YYYY-MM-DD or unix seconds")
 }
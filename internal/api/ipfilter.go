@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/config"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	log "github.com/sirupsen/logrus"
+)
+
+// clientAddrKey is the gin context key holding the resolved client address.
+const clientAddrKey = "clientAddr"
+
+// ipFilterRules is the compiled form of config.IPFilter.
+type ipFilterRules struct {
+	allow   []netip.Prefix
+	deny    []netip.Prefix
+	trusted []netip.Prefix
+	// denyAll is set when an allow list was configured but none of its entries parsed, so a
+	// typo fails closed instead of opening the API.
+	denyAll bool
+}
+
+func compileIPFilter(cfg config.IPFilter) *ipFilterRules {
+	rules := &ipFilterRules{
+		allow:   parseCIDRList("ip-filter.allow", cfg.Allow),
+		deny:    parseCIDRList("ip-filter.deny", cfg.Deny),
+		trusted: parseCIDRList("ip-filter.trusted-proxies", cfg.TrustedProxies),
+	}
+	rules.denyAll = len(cfg.Allow) > 0 && len(rules.allow) == 0
+	return rules
+}
+
+// parseCIDRList parses entries one by one so a single invalid entry is reported and skipped
+// rather than discarding the whole list on reload.
+func parseCIDRList(field string, values []string) []netip.Prefix {
+	out := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		prefixes, err := sdkaccess.ParseCIDRs([]string{value})
+		if err != nil {
+			log.Warnf("%s: %v", field, err)
+			continue
+		}
+		out = append(out, prefixes...)
+	}
+	return out
+}
+
+// applyIPFilter swaps in the network rules of cfg and reports invalid per-key networks, which
+// KeyPolicy.CheckAddr skips.
+func (s *Server) applyIPFilter(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	s.ipFilter.Store(compileIPFilter(cfg.IPFilter))
+	for i := range cfg.ClientKeys {
+		parseCIDRList(fmt.Sprintf("client-keys[%d].allowed-cidrs", i), cfg.ClientKeys[i].AllowedCIDRs)
+		parseCIDRList(fmt.Sprintf("client-keys[%d].denied-cidrs", i), cfg.ClientKeys[i].DeniedCIDRs)
+	}
+}
+
+// ipFilterMiddleware resolves the client address and rejects requests from networks outside
+// ip-filter. It runs before authentication so denied networks cannot probe keys.
+func (s *Server) ipFilterMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.admitClientAddr(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// ipFilteredAuthMiddleware runs the ip-filter check and then authentication as one handler, for
+// modules that take a single auth middleware for their routes.
+func (s *Server) ipFilteredAuthMiddleware() gin.HandlerFunc {
+	auth := AuthMiddleware(s.accessManager)
+	return func(c *gin.Context) {
+		if !s.admitClientAddr(c) {
+			return
+		}
+		auth(c)
+	}
+}
+
+// admitClientAddr resolves the client address and aborts the request when ip-filter denies it.
+func (s *Server) admitClientAddr(c *gin.Context) bool {
+	rules := s.ipFilter.Load()
+	if rules == nil {
+		rules = &ipFilterRules{}
+	}
+	addr := sdkaccess.ClientAddr(c.Request, rules.trusted)
+	c.Set(clientAddrKey, addr)
+	if rules.denyAll || !sdkaccess.AddrAllowed(rules.allow, rules.deny, addr) {
+		log.Debugf("ip-filter rejected %s %s from %s", c.Request.Method, c.Request.URL.Path, addr)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "source address not allowed"})
+		return false
+	}
+	return true
+}
+
+// requestClientAddr returns the address resolved by ipFilterMiddleware, falling back to the
+// connection's remote address on routes without the filter.
+func requestClientAddr(c *gin.Context) netip.Addr {
+	if raw, ok := c.Get(clientAddrKey); ok {
+		if addr, okAddr := raw.(netip.Addr); okAddr {
+			return addr
+		}
+	}
+	return sdkaccess.ClientAddr(c.Request, nil)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/config"
+	"github.com/radityprtama/proxygate/v6/internal/metrics"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	"github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// applyMetricsConfig swaps in the /metrics settings of cfg.
+func (s *Server) applyMetricsConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	settings := cfg.Metrics
+	s.metricsConfig.Store(&settings)
+}
+
+// serveMetrics writes the proxy metrics. It answers 404 while metrics are disabled and 401
+// when a token is configured and the request does not present it.
+func (s *Server) serveMetrics(c *gin.Context) {
+	settings := s.metricsConfig.Load()
+	if settings == nil || !settings.Enable {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if token := strings.TrimSpace(settings.Token); token != "" {
+		provided := strings.TrimSpace(c.GetHeader("Authorization"))
+		if len(provided) > 7 && strings.EqualFold(provided[:7], "bearer ") {
+			provided = strings.TrimSpace(provided[7:])
+		}
+		if !sdkaccess.VerifyClientKey(token, provided) {
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics token"})
+			return
+		}
+	}
+	c.Header("Content-Type", metricsContentType)
+	c.Status(http.StatusOK)
+	_, _ = metrics.Default().WriteTo(c.Writer)
+}
+
+// credentialStates lists the manager's credentials for the credential status gauge.
+func credentialStates(manager *auth.Manager) func() []metrics.CredentialState {
+	return func() []metrics.CredentialState {
+		now := time.Now()
+		auths := manager.List()
+		out := make([]metrics.CredentialState, 0, len(auths))
+		for _, a := range auths {
+			if a == nil {
+				continue
+			}
+			out = append(out, metrics.CredentialState{Provider: a.Provider, ID: a.ID, State: credentialState(a, now)})
+		}
+		return out
+	}
+}
+
+func credentialState(a *auth.Auth, now time.Time) string {
+	if a.Disabled || a.Status == auth.StatusDisabled {
+		return metrics.CredentialDisabled
+	}
+	if a.Unavailable && a.NextRetryAfter.After(now) {
+		return metrics.CredentialCooling
+	}
+	for _, state := range a.ModelStates {
+		if state != nil && state.Unavailable && state.NextRetryAfter.After(now) {
+			return metrics.CredentialCooling
+		}
+	}
+	return metrics.CredentialActive
+}
@@ -14,11 +14,14 @@ import (
 // registration. Modules can use the Gin engine to attach routes, the shared
 // BaseAPIHandler for constructing SDK-specific handlers, and the resolved
 // authentication middleware for protecting routes that require API keys.
+// RequestMiddleware runs ahead of AuthMiddleware on routes that serve model
+// requests, so they get the same metrics and usage timing as the core API.
 type Context struct {
-	Engine         *gin.Engine
-	BaseHandler    *handlers.BaseAPIHandler
-	Config         *config.Config
-	AuthMiddleware gin.HandlerFunc
+	Engine            *gin.Engine
+	BaseHandler       *handlers.BaseAPIHandler
+	Config            *config.Config
+	AuthMiddleware    gin.HandlerFunc
+	RequestMiddleware []gin.HandlerFunc
 }
 
 // RouteModule represents a pluggable routing module that can register routes
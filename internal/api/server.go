@@ -26,6 +26,7 @@ import (
 	"github.com/radityprtama/proxygate/v6/internal/config"
 	"github.com/radityprtama/proxygate/v6/internal/logging"
 	"github.com/radityprtama/proxygate/v6/internal/managementasset"
+	"github.com/radityprtama/proxygate/v6/internal/metrics"
 	"github.com/radityprtama/proxygate/v6/internal/usage"
 	"github.com/radityprtama/proxygate/v6/internal/util"
 	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
@@ -148,6 +149,12 @@ type Server struct {
 	wsAuthChanged func(bool, bool)
 	wsAuthEnabled atomic.Bool
 
+	// ipFilter holds the compiled ip-filter rules for the inference routes.
+	ipFilter atomic.Pointer[ipFilterRules]
+
+	// metricsConfig holds the /metrics endpoint settings.
+	metricsConfig atomic.Pointer[config.MetricsConfig]
+
 	// management handler
 	mgmt *managementHandlers.Handler
 
@@ -250,7 +257,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Save initial YAML snapshot
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 	s.applyAccessConfig(nil, cfg)
+	s.applyIPFilter(cfg)
+	s.applyMetricsConfig(cfg)
 	if authManager != nil {
+		metrics.SetCredentialSource(credentialStates(authManager))
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second)
 	}
 	managementasset.SetCurrentConfig(cfg)
@@ -273,12 +283,14 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	s.setupRoutes()
 
 	// Register Amp module using V2 interface with Context
-	s.ampModule = ampmodule.NewLegacy(accessManager, AuthMiddleware(accessManager))
+	// Provider routes go through metrics, usage timing and ip-filter like the /v1 routes.
+	s.ampModule = ampmodule.NewLegacy(accessManager, s.ipFilteredAuthMiddleware())
 	ctx := modules.Context{
-		Engine:         engine,
-		BaseHandler:    s.handlers,
-		Config:         cfg,
-		AuthMiddleware: AuthMiddleware(accessManager),
+		Engine:            engine,
+		BaseHandler:       s.handlers,
+		Config:            cfg,
+		AuthMiddleware:    s.ipFilteredAuthMiddleware(),
+		RequestMiddleware: []gin.HandlerFunc{metrics.Middleware(), handlers.UsageTimingMiddleware()},
 	}
 	if err := modules.RegisterModule(ctx, s.ampModule); err != nil {
 		log.Errorf("Failed to register Amp module: %v", err)
@@ -321,7 +333,7 @@ func (s *Server) setupRoutes() {
 
 	// OpenAI compatible API routes
 	v1 := s.engine.Group("/v1")
-	v1.Use(AuthMiddleware(s.accessManager))
+	v1.Use(metrics.Middleware(), handlers.UsageTimingMiddleware(), s.ipFilterMiddleware(), AuthMiddleware(s.accessManager))
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
@@ -333,7 +345,7 @@ func (s *Server) setupRoutes() {
 
 	// Gemini compatible API routes
 	v1beta := s.engine.Group("/v1beta")
-	v1beta.Use(AuthMiddleware(s.accessManager))
+	v1beta.Use(metrics.Middleware(), handlers.UsageTimingMiddleware(), s.ipFilterMiddleware(), AuthMiddleware(s.accessManager))
 	{
 		v1beta.GET("/models", geminiHandlers.GeminiModels)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
@@ -351,7 +363,8 @@ func (s *Server) setupRoutes() {
 			},
 		})
 	})
-	s.engine.POST("/v1internal:method", geminiCLIHandlers.CLIHandler)
+	s.engine.POST("/v1internal:method", metrics.Middleware(), handlers.UsageTimingMiddleware(), s.ipFilterMiddleware(), geminiCLIHandlers.CLIHandler)
+	s.engine.GET("/metrics", s.serveMetrics)
 
 	// OAuth callback endpoints (reuse main server port)
 	// These endpoints receive provider redirects and persist
@@ -485,6 +498,10 @@ func (s *Server) registerManagementRoutes() {
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
+		mgmt.GET("/usage/cost", s.mgmt.GetUsageCost)
+		mgmt.GET("/usage/export", s.mgmt.ExportUsage)
+		mgmt.GET("/pricing", s.mgmt.GetPricing)
+		mgmt.PUT("/pricing", s.mgmt.PutPricing)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
@@ -519,6 +536,13 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.POST("/api-keys/generate", s.mgmt.GenerateAPIKey)
+
+		mgmt.GET("/keys", s.mgmt.ListVirtualKeys)
+		mgmt.POST("/keys", s.mgmt.CreateVirtualKey)
+		mgmt.GET("/keys/:id", s.mgmt.GetVirtualKey)
+		mgmt.PATCH("/keys/:id", s.mgmt.PatchVirtualKey)
+		mgmt.DELETE("/keys/:id", s.mgmt.DeleteVirtualKey)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
@@ -590,6 +614,13 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
+		mgmt.GET("/circuit-breakers", s.mgmt.GetCircuitBreakers)
+		mgmt.POST("/circuit-breakers/reset", s.mgmt.ResetCircuitBreaker)
+
+		mgmt.GET("/budgets", s.mgmt.GetBudgets)
+		mgmt.POST("/budgets/reset", s.mgmt.ResetBudget)
+		mgmt.POST("/budgets/adjust", s.mgmt.AdjustBudget)
+
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
 		mgmt.GET("/codex-auth-url", s.mgmt.RequestCodexToken)
 		mgmt.GET("/gemini-cli-auth-url", s.mgmt.RequestGeminiCLIToken)
@@ -753,6 +784,14 @@ func (s *Server) Start() error {
 		if cert == "" || key == "" {
 			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
 		}
+		tlsCfg, errTLS := clientAuthTLSConfig(s.cfg.TLS)
+		if errTLS != nil {
+			return fmt.Errorf("failed to start HTTPS server: %v", errTLS)
+		}
+		if tlsCfg != nil {
+			s.server.TLSConfig = tlsCfg
+			log.Infof("TLS client certificates enabled (client-auth: %s)", s.cfg.TLS.ClientAuth)
+		}
 		log.Debugf("Starting API server on %s with TLS", s.server.Addr)
 		if errServeTLS := s.server.ListenAndServeTLS(cert, key); errServeTLS != nil && !errors.Is(errServeTLS, http.ErrServerClosed) {
 			return fmt.Errorf("failed to start HTTPS server: %v", errServeTLS)
@@ -936,6 +975,8 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.applyAccessConfig(oldCfg, cfg)
+	s.applyIPFilter(cfg)
+	s.applyMetricsConfig(cfg)
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
@@ -1012,6 +1053,12 @@ func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 		result, err := manager.Authenticate(c.Request.Context(), c.Request)
 		if err == nil {
 			if result != nil {
+				if policy, ok := sdkaccess.PolicyFromMetadata(result.Metadata); ok {
+					if errAddr := policy.CheckAddr(requestClientAddr(c)); errAddr != nil {
+						c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errAddr.Error()})
+						return
+					}
+				}
 				c.Set("apiKey", result.Principal)
 				c.Set("accessProvider", result.Provider)
 				if len(result.Metadata) > 0 {
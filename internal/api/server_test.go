@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -109,3 +110,109 @@ func TestAmpProviderModelRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestIPFilterMiddleware(t *testing.T) {
+	server := newTestServer(t)
+	server.applyIPFilter(&proxyconfig.Config{SDKConfig: sdkconfig.SDKConfig{IPFilter: sdkconfig.IPFilter{
+		Allow:          []string{"10.0.0.0/8"},
+		Deny:           []string{"10.66.0.0/16"},
+		TrustedProxies: []string{"192.0.2.10"},
+	}}})
+
+	testCases := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		wantForbidden bool
+	}{
+		{name: "allowed network", remoteAddr: "10.1.2.3:5000"},
+		{name: "denied subnet", remoteAddr: "10.66.1.1:5000", wantForbidden: true},
+		{name: "outside allow list", remoteAddr: "203.0.113.7:5000", wantForbidden: true},
+		{name: "forwarded by trusted proxy", remoteAddr: "192.0.2.10:443", forwardedFor: "10.1.2.3"},
+		{name: "spoofed entry before real client", remoteAddr: "192.0.2.10:443", forwardedFor: "10.1.2.3, 203.0.113.7", wantForbidden: true},
+		{name: "forwarded by untrusted peer", remoteAddr: "203.0.113.7:5000", forwardedFor: "10.1.2.3", wantForbidden: true},
+	}
+	for _, tc := range testCases {
+		for _, path := range []string{"/v1/models", "/api/provider/openai/v1/models"} {
+			t.Run(tc.name+" "+path, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.RemoteAddr = tc.remoteAddr
+				req.Header.Set("Authorization", "Bearer test-key")
+				if tc.forwardedFor != "" {
+					req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+				}
+				rr := httptest.NewRecorder()
+				server.engine.ServeHTTP(rr, req)
+				if forbidden := rr.Code == http.StatusForbidden; forbidden != tc.wantForbidden {
+					t.Fatalf("got status %d, want forbidden=%v; body=%s", rr.Code, tc.wantForbidden, rr.Body.String())
+				}
+			})
+		}
+	}
+
+	server.applyIPFilter(&proxyconfig.Config{})
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.RemoteAddr = "203.0.113.7:5000"
+	rr := httptest.NewRecorder()
+	server.engine.ServeHTTP(rr, req)
+	if rr.Code == http.StatusForbidden {
+		t.Fatalf("expected reloaded empty filter to admit all networks, got %d", rr.Code)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := newTestServer(t)
+	if _, err := server.handlers.AuthManager.Register(context.Background(), &auth.Auth{ID: "claude-disabled.json", Provider: "claude", Disabled: true}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+
+	scrape := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := scrape(""); rr.Code != http.StatusNotFound {
+		t.Fatalf("disabled metrics: got status %d, want 404", rr.Code)
+	}
+
+	hashed, err := sdkaccess.HashClientKey("scrape-token", "")
+	if err != nil {
+		t.Fatalf("hash token: %v", err)
+	}
+	server.applyMetricsConfig(&proxyconfig.Config{Metrics: proxyconfig.MetricsConfig{Enable: true, Token: hashed}})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer test-key")
+	server.engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	if rr := scrape("wrong"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: got status %d, want 401", rr.Code)
+	}
+	rr := scrape("scrape-token")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("scrape: got status %d; body=%s", rr.Code, rr.Body.String())
+	}
+	if contentType := rr.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", contentType)
+	}
+	body := rr.Body.String()
+	for _, want := range []string{
+		"# TYPE proxygate_requests_total counter",
+		`proxygate_requests_total{format="openai",model="",provider="",status="200"}`,
+		"# TYPE proxygate_request_duration_seconds histogram",
+		`proxygate_request_duration_seconds_bucket{format="openai",model="",provider="",status="200",le="+Inf"}`,
+		`proxygate_credential_status{provider="claude",auth="claude-disabled.json",state="disabled"} 1`,
+		`proxygate_credential_status{provider="claude",auth="claude-disabled.json",state="active"} 0`,
+		"# TYPE proxygate_tokens_total counter",
+		"# TYPE proxygate_translator_errors_total counter",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
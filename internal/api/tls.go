@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/radityprtama/proxygate/v6/internal/config"
+)
+
+// clientAuthTLSConfig builds the server TLS configuration requesting client certificates as
+// configured by tls.client-auth. It returns nil when client certificates are not requested.
+//
+// Without tls.client-ca the handshake only collects the certificates; verification is then left
+// to the client-cert access provider and its own ca-file.
+func clientAuthTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	mode := strings.ToLower(strings.TrimSpace(cfg.ClientAuth))
+	if mode == "" || mode == "none" {
+		return nil, nil
+	}
+	var pool *x509.CertPool
+	if caPath := strings.TrimSpace(cfg.ClientCA); caPath != "" {
+		data, err := os.ReadFile(caPath)
+		if err != nil {
+			return nil, fmt.Errorf("read tls.client-ca: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("tls.client-ca %s contains no PEM certificates", caPath)
+		}
+	}
+	tlsCfg := &tls.Config{ClientCAs: pool}
+	switch mode {
+	case "request":
+		tlsCfg.ClientAuth = tls.RequestClientCert
+		if pool != nil {
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	case "require":
+		tlsCfg.ClientAuth = tls.RequireAnyClientCert
+		if pool != nil {
+			tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	default:
+		return nil, fmt.Errorf("unsupported tls.client-auth %q", cfg.ClientAuth)
+	}
+	return tlsCfg, nil
+}
@@ -0,0 +1,447 @@
+// Package budget enforces per-client-key token budgets over UTC days and calendar months.
+// Consumption is accumulated from usage records and persisted through the auth store's state
+// documents so that running totals survive restarts.
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	internalusage "github.com/radityprtama/proxygate/v6/internal/usage"
+	coreauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// StateDocument is the StateStore document name holding budget consumption.
+const StateDocument = "budgets"
+
+// flushInterval throttles how often consumption is written to the store.
+const flushInterval = 30 * time.Second
+
+// saveTimeout bounds a single background write.
+const saveTimeout = 30 * time.Second
+
+// Budget periods.
+const (
+	PeriodDaily   = "daily"
+	PeriodMonthly = "monthly"
+)
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{tracker: defaultTracker})
+}
+
+// Limits caps the tokens consumed in one period. Zero disables a limit; a negative per-key
+// value disables the default for that key.
+type Limits struct {
+	TotalTokens  int64 `json:"total_tokens,omitempty"`
+	InputTokens  int64 `json:"input_tokens,omitempty"`
+	OutputTokens int64 `json:"output_tokens,omitempty"`
+}
+
+func (l Limits) enabled() bool {
+	return l.TotalTokens > 0 || l.InputTokens > 0 || l.OutputTokens > 0
+}
+
+func (l Limits) merge(override Limits) Limits {
+	if override.TotalTokens != 0 {
+		l.TotalTokens = override.TotalTokens
+	}
+	if override.InputTokens != 0 {
+		l.InputTokens = override.InputTokens
+	}
+	if override.OutputTokens != 0 {
+		l.OutputTokens = override.OutputTokens
+	}
+	return l
+}
+
+// Budget holds the daily and monthly limits of a key.
+type Budget struct {
+	Daily   Limits `json:"daily,omitempty"`
+	Monthly Limits `json:"monthly,omitempty"`
+}
+
+func (b Budget) enabled() bool {
+	return b.Daily.enabled() || b.Monthly.enabled()
+}
+
+// Usage is the consumption recorded for one period.
+type Usage struct {
+	// Period identifies the day ("2006-01-02") or month ("2006-01") the totals belong to.
+	Period       string `json:"period"`
+	InputTokens  int64  `json:"input_tokens"`
+	OutputTokens int64  `json:"output_tokens"`
+	TotalTokens  int64  `json:"total_tokens"`
+}
+
+func (u *Usage) add(delta Usage) {
+	u.InputTokens = max(u.InputTokens+delta.InputTokens, 0)
+	u.OutputTokens = max(u.OutputTokens+delta.OutputTokens, 0)
+	u.TotalTokens = max(u.TotalTokens+delta.TotalTokens, 0)
+}
+
+// rollover resets the totals when they belong to an earlier period.
+func (u *Usage) rollover(period string) {
+	if u.Period != period {
+		*u = Usage{Period: period}
+	}
+}
+
+type keyUsage struct {
+	Daily   Usage `json:"daily"`
+	Monthly Usage `json:"monthly"`
+}
+
+func (k *keyUsage) rollover(now time.Time) {
+	k.Daily.rollover(dayKey(now))
+	k.Monthly.rollover(monthKey(now))
+}
+
+func (k *keyUsage) period(name string) *Usage {
+	switch name {
+	case PeriodDaily:
+		return &k.Daily
+	case PeriodMonthly:
+		return &k.Monthly
+	}
+	return nil
+}
+
+// PeriodStatus reports the consumption and limits of one period.
+type PeriodStatus struct {
+	Usage
+	Limits  Limits    `json:"limits"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// KeyStatus reports the budget state of one key.
+type KeyStatus struct {
+	Daily   PeriodStatus `json:"daily"`
+	Monthly PeriodStatus `json:"monthly"`
+}
+
+// Decision describes an exhausted budget.
+type Decision struct {
+	// Period is PeriodDaily or PeriodMonthly.
+	Period string
+	// Limit names the exhausted limit: total_tokens, input_tokens or output_tokens.
+	Limit string
+	Max   int64
+	Used  int64
+	// ResetAt is when the period rolls over and the budget is available again.
+	ResetAt time.Time
+}
+
+// Tracker accumulates consumption per client key and enforces the configured budgets.
+type Tracker struct {
+	mu       sync.Mutex
+	defaults Budget
+	perKey   map[string]Budget
+	usage    map[string]*keyUsage
+	store    coreauth.StateStore
+	dirty    bool
+	timer    *time.Timer
+}
+
+// New returns an unconfigured tracker that admits everything.
+func New() *Tracker {
+	return &Tracker{perKey: make(map[string]Budget), usage: make(map[string]*keyUsage)}
+}
+
+var defaultTracker = New()
+
+// Default returns the process-wide tracker fed by usage records.
+func Default() *Tracker { return defaultTracker }
+
+// Configure replaces the default and per-key budgets. Recorded consumption is kept.
+func (t *Tracker) Configure(defaults Budget, perKey map[string]Budget) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.defaults = defaults
+	t.perKey = make(map[string]Budget, len(perKey))
+	for key, budget := range perKey {
+		t.perKey[key] = budget
+	}
+}
+
+func (t *Tracker) budgetLocked(key string) Budget {
+	budget := t.defaults
+	if override, ok := t.perKey[key]; ok {
+		budget.Daily = budget.Daily.merge(override.Daily)
+		budget.Monthly = budget.Monthly.merge(override.Monthly)
+	}
+	return budget
+}
+
+// SetStore sets where consumption is persisted. A nil store keeps totals in memory only.
+func (t *Tracker) SetStore(store coreauth.StateStore) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store = store
+}
+
+// Load replaces the in-memory totals with those saved in the store.
+func (t *Tracker) Load(ctx context.Context) error {
+	t.mu.Lock()
+	store := t.store
+	t.mu.Unlock()
+	if store == nil {
+		return nil
+	}
+	data, err := store.LoadState(ctx, StateDocument)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*keyUsage)
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &loaded); err != nil {
+			return fmt.Errorf("budget: unmarshal state: %w", err)
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, usage := range loaded {
+		if usage == nil {
+			continue
+		}
+		if current := t.usage[key]; current != nil {
+			// Usage recorded before the load completed is added on top of the saved totals.
+			usage.rollover(time.Now())
+			current.rollover(time.Now())
+			usage.Daily.add(current.Daily)
+			usage.Monthly.add(current.Monthly)
+		}
+		t.usage[key] = usage
+	}
+	return nil
+}
+
+// Flush writes pending consumption to the store immediately.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	store := t.store
+	if store == nil || !t.dirty {
+		t.mu.Unlock()
+		return nil
+	}
+	data, err := json.Marshal(t.usage)
+	t.dirty = false
+	t.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("budget: marshal state: %w", err)
+	}
+	if err = store.SaveState(ctx, StateDocument, data); err != nil {
+		t.mu.Lock()
+		t.dirty = true
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// markDirtyLocked schedules a background save.
+func (t *Tracker) markDirtyLocked() {
+	t.dirty = true
+	if t.store == nil || t.timer != nil {
+		return
+	}
+	t.timer = time.AfterFunc(flushInterval, func() {
+		t.mu.Lock()
+		t.timer = nil
+		t.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+		defer cancel()
+		if err := t.Flush(ctx); err != nil {
+			log.Warnf("budget: failed to persist usage: %v", err)
+		}
+	})
+}
+
+// Record adds consumption for key at now. Keys without a budget are not tracked.
+func (t *Tracker) Record(key string, delta Usage, now time.Time) {
+	if t == nil || key == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.budgetLocked(key).enabled() {
+		return
+	}
+	usage := t.usage[key]
+	if usage == nil {
+		usage = &keyUsage{}
+		t.usage[key] = usage
+	}
+	usage.rollover(now)
+	usage.Daily.add(delta)
+	usage.Monthly.add(delta)
+	t.markDirtyLocked()
+}
+
+// Check reports whether key has exhausted a budget at now.
+func (t *Tracker) Check(key string, now time.Time) (Decision, bool) {
+	if t == nil || key == "" {
+		return Decision{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	budget := t.budgetLocked(key)
+	if !budget.enabled() {
+		return Decision{}, false
+	}
+	usage := t.usage[key]
+	if usage == nil {
+		return Decision{}, false
+	}
+	usage.rollover(now)
+	if decision, exceeded := exhausted(PeriodDaily, budget.Daily, usage.Daily, nextDay(now)); exceeded {
+		return decision, true
+	}
+	return exhausted(PeriodMonthly, budget.Monthly, usage.Monthly, nextMonth(now))
+}
+
+func exhausted(period string, limits Limits, usage Usage, resetAt time.Time) (Decision, bool) {
+	checks := []struct {
+		name  string
+		limit int64
+		used  int64
+	}{
+		{"total_tokens", limits.TotalTokens, usage.TotalTokens},
+		{"input_tokens", limits.InputTokens, usage.InputTokens},
+		{"output_tokens", limits.OutputTokens, usage.OutputTokens},
+	}
+	for _, check := range checks {
+		if check.limit > 0 && check.used >= check.limit {
+			return Decision{Period: period, Limit: check.name, Max: check.limit, Used: check.used, ResetAt: resetAt}, true
+		}
+	}
+	return Decision{}, false
+}
+
+// Snapshot returns the status of every key with a budget or recorded consumption.
+func (t *Tracker) Snapshot(now time.Time) map[string]KeyStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys := make(map[string]struct{}, len(t.usage)+len(t.perKey))
+	for key := range t.usage {
+		keys[key] = struct{}{}
+	}
+	for key := range t.perKey {
+		keys[key] = struct{}{}
+	}
+	out := make(map[string]KeyStatus, len(keys))
+	for key := range keys {
+		budget := t.budgetLocked(key)
+		var usage keyUsage
+		if current := t.usage[key]; current != nil {
+			current.rollover(now)
+			usage = *current
+		} else {
+			usage.rollover(now)
+		}
+		out[key] = KeyStatus{
+			Daily:   PeriodStatus{Usage: usage.Daily, Limits: budget.Daily, ResetAt: nextDay(now)},
+			Monthly: PeriodStatus{Usage: usage.Monthly, Limits: budget.Monthly, ResetAt: nextMonth(now)},
+		}
+	}
+	return out
+}
+
+// Reset clears consumption for key, or for every key when key is empty. An empty period clears
+// both periods. It returns the number of keys affected.
+func (t *Tracker) Reset(key, period string) (int, error) {
+	if period != "" && period != PeriodDaily && period != PeriodMonthly {
+		return 0, fmt.Errorf("unknown budget period %q", period)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	count := 0
+	for k, usage := range t.usage {
+		if key != "" && k != key {
+			continue
+		}
+		usage.rollover(now)
+		if period == "" || period == PeriodDaily {
+			usage.Daily = Usage{Period: dayKey(now)}
+		}
+		if period == "" || period == PeriodMonthly {
+			usage.Monthly = Usage{Period: monthKey(now)}
+		}
+		count++
+	}
+	if count > 0 {
+		t.markDirtyLocked()
+	}
+	return count, nil
+}
+
+// Adjust adds delta to the current consumption of key in period. Negative values give tokens back;
+// totals never drop below zero.
+func (t *Tracker) Adjust(key, period string, delta Usage) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.usage[key]
+	if usage == nil {
+		usage = &keyUsage{}
+	}
+	usage.rollover(time.Now())
+	target := usage.period(period)
+	if target == nil {
+		return fmt.Errorf("unknown budget period %q", period)
+	}
+	target.add(delta)
+	t.usage[key] = usage
+	t.markDirtyLocked()
+	return nil
+}
+
+func dayKey(now time.Time) string   { return now.UTC().Format("2006-01-02") }
+func monthKey(now time.Time) string { return now.UTC().Format("2006-01") }
+
+func nextDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextMonth(now time.Time) time.Time {
+	y, m, _ := now.UTC().Date()
+	return time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// usagePlugin charges reported token usage to the tracker.
+type usagePlugin struct {
+	tracker *Tracker
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	detail := record.Detail
+	delta := Usage{
+		InputTokens:  detail.InputTokens,
+		OutputTokens: detail.OutputTokens,
+		TotalTokens:  detail.TotalTokens,
+	}
+	if internalusage.ReasoningReportedSeparately(record.Provider) {
+		delta.OutputTokens += detail.ReasoningTokens
+	}
+	if delta.TotalTokens == 0 {
+		delta.TotalTokens = delta.InputTokens + delta.OutputTokens
+	}
+	if delta.InputTokens == 0 && delta.OutputTokens == 0 && delta.TotalTokens == 0 {
+		return
+	}
+	p.tracker.Record(record.APIKey, delta, time.Now())
+}
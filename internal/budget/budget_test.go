@@ -0,0 +1,108 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+)
+
+type memoryStateStore struct {
+	docs map[string][]byte
+}
+
+func (s *memoryStateStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	return s.docs[name], nil
+}
+
+func (s *memoryStateStore) SaveState(_ context.Context, name string, data []byte) error {
+	s.docs[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func TestTracker_DailyBudgetRejectsUntilRollover(t *testing.T) {
+	tracker := New()
+	tracker.Configure(Budget{Daily: Limits{TotalTokens: 100}}, map[string]Budget{"unlimited": {Daily: Limits{TotalTokens: -1}}})
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tracker.Record("key", Usage{InputTokens: 60, OutputTokens: 30, TotalTokens: 90}, now)
+	if _, exceeded := tracker.Check("key", now); exceeded {
+		t.Fatalf("expected key under budget")
+	}
+	tracker.Record("key", Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, now)
+	decision, exceeded := tracker.Check("key", now)
+	if !exceeded || decision.Period != PeriodDaily || decision.Used != 105 {
+		t.Fatalf("expected daily budget exhausted, got %+v", decision)
+	}
+	if want := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC); !decision.ResetAt.Equal(want) {
+		t.Fatalf("expected reset at %s, got %s", want, decision.ResetAt)
+	}
+	if _, exceeded = tracker.Check("key", now.Add(12*time.Hour)); exceeded {
+		t.Fatalf("expected budget to roll over at midnight UTC")
+	}
+
+	tracker.Record("unlimited", Usage{TotalTokens: 1000}, now)
+	if _, exceeded = tracker.Check("unlimited", now); exceeded {
+		t.Fatalf("expected per-key override to disable the budget")
+	}
+}
+
+func TestTracker_PersistsThroughStore(t *testing.T) {
+	store := &memoryStateStore{docs: make(map[string][]byte)}
+	now := time.Now()
+
+	tracker := New()
+	tracker.Configure(Budget{Monthly: Limits{OutputTokens: 50}}, nil)
+	tracker.SetStore(store)
+	tracker.Record("key", Usage{OutputTokens: 50, TotalTokens: 50}, now)
+	if err := tracker.Flush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	restored := New()
+	restored.Configure(Budget{Monthly: Limits{OutputTokens: 50}}, nil)
+	restored.SetStore(store)
+	if err := restored.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	decision, exceeded := restored.Check("key", now)
+	if !exceeded || decision.Period != PeriodMonthly || decision.Limit != "output_tokens" {
+		t.Fatalf("expected restored monthly budget exhausted, got %+v", decision)
+	}
+
+	if err := restored.Adjust("key", PeriodMonthly, Usage{OutputTokens: -20}); err != nil {
+		t.Fatalf("adjust: %v", err)
+	}
+	if _, exceeded = restored.Check("key", now); exceeded {
+		t.Fatalf("expected adjustment to free budget")
+	}
+	restored.Record("key", Usage{OutputTokens: 20}, now)
+	if count, _ := restored.Reset("", ""); count != 1 {
+		t.Fatalf("expected one key reset, got %d", count)
+	}
+	if status := restored.Snapshot(now)["key"]; status.Monthly.OutputTokens != 0 {
+		t.Fatalf("expected reset totals, got %+v", status)
+	}
+}
+
+func TestUsagePlugin_CountsReasoningOncePerProviderStyle(t *testing.T) {
+	tracker := New()
+	tracker.Configure(Budget{Daily: Limits{OutputTokens: 1000}}, nil)
+	plugin := usagePlugin{tracker: tracker}
+	detail := coreusage.Detail{InputTokens: 10, OutputTokens: 40, ReasoningTokens: 30, TotalTokens: 80}
+
+	// OpenAI-style providers already include reasoning in OutputTokens.
+	plugin.HandleUsage(context.Background(), coreusage.Record{APIKey: "openai-key", Provider: "openai", Detail: detail})
+	// Gemini-family providers report it separately.
+	detail.OutputTokens = 10
+	plugin.HandleUsage(context.Background(), coreusage.Record{APIKey: "gemini-key", Provider: "gemini", Detail: detail})
+
+	snapshot := tracker.Snapshot(time.Now())
+	if got := snapshot["openai-key"].Daily.OutputTokens; got != 40 {
+		t.Fatalf("openai output tokens = %d, want 40", got)
+	}
+	if got := snapshot["gemini-key"].Daily.OutputTokens; got != 40 {
+		t.Fatalf("gemini output tokens = %d, want 40", got)
+	}
+}
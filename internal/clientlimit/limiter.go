@@ -0,0 +1,233 @@
+// Package clientlimit enforces per-client-key request and token rate limits.
+// Requests are admitted against a requests-per-minute bucket, and the tokens reported
+// through usage records are charged afterwards against a tokens-per-minute bucket.
+package clientlimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+)
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{limiter: defaultLimiter})
+}
+
+// Limits holds the per-minute allowances of a client key. Zero disables a limit.
+type Limits struct {
+	RPM int
+	TPM int
+}
+
+func (l Limits) enabled() bool {
+	return l.RPM > 0 || l.TPM > 0
+}
+
+// Decision reports the outcome of an admission check for the most constrained bucket.
+type Decision struct {
+	Allowed bool
+	// Limit is the per-minute capacity of the reported bucket.
+	Limit int
+	// Remaining is what is left in the reported bucket after this request.
+	Remaining int
+	// Reset is how long until the reported bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long a rejected caller should wait.
+	RetryAfter time.Duration
+	// Tokens is true when the tokens-per-minute bucket produced the decision.
+	Tokens bool
+}
+
+// bucket is a token bucket refilled continuously at capacity per minute. The level may go
+// negative when usage charged after the fact exceeds what was left.
+type bucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+func newBucket(capacity int, now time.Time) *bucket {
+	return &bucket{capacity: float64(capacity), level: float64(capacity), updated: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated)
+	if elapsed <= 0 {
+		return
+	}
+	b.level = math.Min(b.capacity, b.level+b.capacity*elapsed.Minutes())
+	b.updated = now
+}
+
+func (b *bucket) resize(capacity int) {
+	b.capacity = float64(capacity)
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+}
+
+// wait returns how long until the level reaches need.
+func (b *bucket) wait(need float64) time.Duration {
+	if b.level >= need || b.capacity <= 0 {
+		return 0
+	}
+	return time.Duration((need - b.level) / b.capacity * float64(time.Minute))
+}
+
+func (b *bucket) remaining() int {
+	if b.level <= 0 {
+		return 0
+	}
+	return int(b.level)
+}
+
+type keyState struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+// Limiter tracks rate-limit buckets per client key.
+type Limiter struct {
+	mu       sync.Mutex
+	defaults Limits
+	perKey   map[string]Limits
+	states   map[string]*keyState
+}
+
+// New returns an unconfigured limiter that admits everything.
+func New() *Limiter {
+	return &Limiter{perKey: make(map[string]Limits), states: make(map[string]*keyState)}
+}
+
+var defaultLimiter = New()
+
+// Default returns the process-wide limiter fed by usage records.
+func Default() *Limiter { return defaultLimiter }
+
+// Configure replaces the default and per-key limits. Bucket levels carry over so a reload
+// does not hand out a fresh allowance. A negative per-key value disables that limit for the key.
+func (l *Limiter) Configure(defaults Limits, perKey map[string]Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaults = defaults
+	l.perKey = make(map[string]Limits, len(perKey))
+	for key, limits := range perKey {
+		l.perKey[key] = limits
+	}
+	for key, state := range l.states {
+		limits := l.limitsLocked(key)
+		if !limits.enabled() {
+			delete(l.states, key)
+			continue
+		}
+		state.requests = resizeBucket(state.requests, limits.RPM)
+		state.tokens = resizeBucket(state.tokens, limits.TPM)
+	}
+}
+
+func resizeBucket(b *bucket, capacity int) *bucket {
+	if capacity <= 0 {
+		return nil
+	}
+	if b == nil {
+		return newBucket(capacity, time.Now())
+	}
+	b.resize(capacity)
+	return b
+}
+
+func (l *Limiter) limitsLocked(key string) Limits {
+	limits := l.defaults
+	if override, ok := l.perKey[key]; ok {
+		if override.RPM != 0 {
+			limits.RPM = override.RPM
+		}
+		if override.TPM != 0 {
+			limits.TPM = override.TPM
+		}
+	}
+	return limits
+}
+
+func (l *Limiter) stateLocked(key string, limits Limits, now time.Time) *keyState {
+	state := l.states[key]
+	if state == nil {
+		state = &keyState{}
+		if limits.RPM > 0 {
+			state.requests = newBucket(limits.RPM, now)
+		}
+		if limits.TPM > 0 {
+			state.tokens = newBucket(limits.TPM, now)
+		}
+		l.states[key] = state
+	}
+	return state
+}
+
+// Allow admits one request for key at now, consuming a request slot when admitted. It returns
+// ok=false when no limit applies to the key.
+func (l *Limiter) Allow(key string, now time.Time) (Decision, bool) {
+	if l == nil || key == "" {
+		return Decision{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limits := l.limitsLocked(key)
+	if !limits.enabled() {
+		return Decision{}, false
+	}
+	state := l.stateLocked(key, limits, now)
+	if state.tokens != nil {
+		state.tokens.refill(now)
+		if state.tokens.level < 1 {
+			wait := state.tokens.wait(1)
+			return Decision{Limit: limits.TPM, Remaining: 0, Reset: state.tokens.wait(state.tokens.capacity), RetryAfter: wait, Tokens: true}, true
+		}
+	}
+	if state.requests != nil {
+		state.requests.refill(now)
+		if state.requests.level < 1 {
+			wait := state.requests.wait(1)
+			return Decision{Limit: limits.RPM, Remaining: 0, Reset: state.requests.wait(state.requests.capacity), RetryAfter: wait}, true
+		}
+		state.requests.level--
+		return Decision{Allowed: true, Limit: limits.RPM, Remaining: state.requests.remaining(), Reset: state.requests.wait(state.requests.capacity)}, true
+	}
+	return Decision{Allowed: true, Limit: limits.TPM, Remaining: state.tokens.remaining(), Reset: state.tokens.wait(state.tokens.capacity), Tokens: true}, true
+}
+
+// Charge deducts tokens consumed by a completed request from the key's token bucket.
+func (l *Limiter) Charge(key string, tokens int64, now time.Time) {
+	if l == nil || key == "" || tokens <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limits := l.limitsLocked(key)
+	if limits.TPM <= 0 {
+		return
+	}
+	state := l.stateLocked(key, limits, now)
+	if state.tokens == nil {
+		return
+	}
+	state.tokens.refill(now)
+	state.tokens.level -= float64(tokens)
+}
+
+// usagePlugin charges reported token usage to the limiter.
+type usagePlugin struct {
+	limiter *Limiter
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	p.limiter.Charge(record.APIKey, tokens, time.Now())
+}
@@ -0,0 +1,51 @@
+package clientlimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter_RequestsPerMinute(t *testing.T) {
+	limiter := New()
+	limiter.Configure(Limits{RPM: 2}, map[string]Limits{"unlimited": {RPM: -1}})
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		decision, limited := limiter.Allow("key", now)
+		if !limited || !decision.Allowed {
+			t.Fatalf("request %d: expected admission, got %+v", i, decision)
+		}
+	}
+	decision, _ := limiter.Allow("key", now)
+	if decision.Allowed || decision.Remaining != 0 || decision.RetryAfter <= 0 || decision.RetryAfter > 30*time.Second {
+		t.Fatalf("expected rejection with ~30s retry, got %+v", decision)
+	}
+	if decision, _ = limiter.Allow("key", now.Add(31*time.Second)); !decision.Allowed {
+		t.Fatalf("expected refill after half a minute, got %+v", decision)
+	}
+	if _, limited := limiter.Allow("unlimited", now); limited {
+		t.Fatalf("expected per-key override to disable the limit")
+	}
+}
+
+func TestLimiter_TokensChargedAfterUsage(t *testing.T) {
+	limiter := New()
+	limiter.Configure(Limits{TPM: 1000}, nil)
+	now := time.Now()
+	if decision, _ := limiter.Allow("key", now); !decision.Allowed || !decision.Tokens {
+		t.Fatalf("expected admission from token bucket, got %+v", decision)
+	}
+	limiter.Charge("key", 1500, now)
+	decision, _ := limiter.Allow("key", now)
+	if decision.Allowed || !decision.Tokens {
+		t.Fatalf("expected token debt to reject, got %+v", decision)
+	}
+	if decision.RetryAfter < 29*time.Second {
+		t.Fatalf("expected retry after debt is repaid, got %v", decision.RetryAfter)
+	}
+
+	// Reloading keeps the current level instead of granting a fresh allowance.
+	limiter.Configure(Limits{TPM: 2000}, nil)
+	if decision, _ = limiter.Allow("key", now); decision.Allowed {
+		t.Fatalf("expected reload to keep the debt, got %+v", decision)
+	}
+}
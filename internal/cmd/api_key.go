@@ -0,0 +1,41 @@
+// Package cmd contains CLI helpers. This file implements generating client API keys whose
+// hash is stored in the configuration while the plaintext is printed once.
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/radityprtama/proxygate/v6/internal/config"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	sdkAuth "github.com/radityprtama/proxygate/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoGenerateAPIKey creates a random client API key, appends its hash to api-keys in the
+// configuration file and prints the plaintext. Only the hash is persisted.
+func DoGenerateAPIKey(cfg *config.Config, configFilePath, scheme string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	plaintext, hashed, err := sdkaccess.GenerateClientKey(scheme)
+	if err != nil {
+		log.Errorf("generate-api-key: %v", err)
+		return
+	}
+	cfg.APIKeys = append(cfg.APIKeys, hashed)
+	cfg.RemoveConfigAPIKeyProviders()
+	if err = config.SaveConfigPreserveComments(configFilePath, cfg); err != nil {
+		log.Errorf("generate-api-key: failed to save config: %v", err)
+		return
+	}
+	if persister, ok := sdkAuth.GetTokenStore().(interface {
+		PersistConfig(ctx context.Context) error
+	}); ok {
+		if errPersist := persister.PersistConfig(context.Background()); errPersist != nil {
+			log.Warnf("generate-api-key: failed to persist config to store: %v", errPersist)
+		}
+	}
+	fmt.Printf("Generated API key (shown once, store it now): %s\n", plaintext)
+	fmt.Printf("Stored hash: %s\n", hashed)
+}
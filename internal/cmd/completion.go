@@ -33,7 +33,7 @@ func generateBashCompletion(w io.Writer, name string) error {
 	script := fmt.Sprintf(`# Bash completion for %[1]s
 _%[1]s_completions() {
     local cur="${COMP_WORDS[COMP_CWORD]}"
-    local opts="-login -codex-login -claude-login -qwen-login -iflow-login -iflow-cookie -no-browser -antigravity-login -project_id -config -vertex-import -completion -help"
+    local opts="-login -codex-login -claude-login -qwen-login -iflow-login -iflow-cookie -no-browser -antigravity-login -project_id -config -vertex-import -generate-api-key -api-key-hash -completion -help"
     
     COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
     return 0
@@ -62,6 +62,8 @@ __%[1]s_complete() {
         '-project_id[Project ID (Gemini only, not required)]:project id:'
         '-config[Configure File Path]:config file:_files'
         '-vertex-import[Import Vertex service account key JSON file]:json file:_files -g "*.json"'
+        '-generate-api-key[Generate a hashed client API key]'
+        '-api-key-hash[Hash scheme for -generate-api-key]:scheme:(sha256 bcrypt)'
         '-completion[Generate shell completion script]:shell:(bash zsh fish powershell)'
         '-help[Show help]'
     )
@@ -89,6 +91,8 @@ complete -c %[1]s -l antigravity-login -d 'Login to Antigravity using OAuth'
 complete -c %[1]s -l project_id -d 'Project ID (Gemini only)' -r
 complete -c %[1]s -l config -d 'Configure File Path' -r -F
 complete -c %[1]s -l vertex-import -d 'Import Vertex service account key JSON file' -r -F
+complete -c %[1]s -l generate-api-key -d 'Generate a hashed client API key'
+complete -c %[1]s -l api-key-hash -d 'Hash scheme for -generate-api-key' -r -a 'sha256 bcrypt'
 complete -c %[1]s -l completion -d 'Generate shell completion script' -r -a 'bash zsh fish powershell'
 complete -c %[1]s -s h -l help -d 'Show help'
 `, name)
@@ -114,6 +118,8 @@ Register-ArgumentCompleter -Native -CommandName %[1]s -ScriptBlock {
         [CompletionResult]::new('-project_id', '-project_id', [CompletionResultType]::ParameterName, 'Project ID (Gemini only)')
         [CompletionResult]::new('-config', '-config', [CompletionResultType]::ParameterName, 'Configure File Path')
         [CompletionResult]::new('-vertex-import', '-vertex-import', [CompletionResultType]::ParameterName, 'Import Vertex service account key JSON file')
+        [CompletionResult]::new('-generate-api-key', '-generate-api-key', [CompletionResultType]::ParameterName, 'Generate a hashed client API key')
+        [CompletionResult]::new('-api-key-hash', '-api-key-hash', [CompletionResultType]::ParameterName, 'Hash scheme for -generate-api-key')
         [CompletionResult]::new('-completion', '-completion', [CompletionResultType]::ParameterName, 'Generate shell completion script')
         [CompletionResult]::new('-help', '-help', [CompletionResultType]::ParameterName, 'Show help')
     )
@@ -52,6 +52,15 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageStore persists usage statistics instead of keeping them in memory.
+	UsageStore UsageStoreConfig `yaml:"usage-store" json:"usage-store"`
+
+	// Pricing prices usage records so statistics report cost alongside tokens.
+	Pricing []ModelPrice `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+
+	// Metrics exposes Prometheus metrics at /metrics.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -59,6 +68,9 @@ type Config struct {
 	RequestRetry int `yaml:"request-retry" json:"request-retry"`
 	// MaxRetryInterval defines the maximum wait time in seconds before retrying a cooled-down credential.
 	MaxRetryInterval int `yaml:"max-retry-interval" json:"max-retry-interval"`
+	// MaxQueueWait bounds, in seconds, how long a request queues for a throttled credential
+	// before moving on to the next one. Zero uses the default of 30 seconds.
+	MaxQueueWait int `yaml:"max-queue-wait" json:"max-queue-wait"`
 
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
@@ -66,6 +78,12 @@ type Config struct {
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
+	// Routing controls how credentials are selected for each request.
+	Routing RoutingConfig `yaml:"routing" json:"routing"`
+
+	// CircuitBreaker stops routing to a provider after repeated upstream failures.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuit-breaker" json:"circuit-breaker"`
+
 	// GeminiKey defines Gemini API key configurations with optional routing overrides.
 	GeminiKey []GeminiKey `yaml:"gemini-api-key" json:"gemini-api-key"`
 
@@ -85,6 +103,10 @@ type Config struct {
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
+	// ModelFallbacks maps a model to the ordered substitutes tried when every credential for it is
+	// cooling down, unavailable, or failing with upstream 5xx errors.
+	ModelFallbacks map[string][]string `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
 	// OAuthExcludedModels defines per-provider global model exclusions applied to OAuth/file-backed auth entries.
 	OAuthExcludedModels map[string][]string `yaml:"oauth-excluded-models,omitempty" json:"oauth-excluded-models,omitempty"`
 
@@ -102,6 +124,11 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ClientCA is the path to a PEM bundle of CAs trusted to sign client certificates.
+	ClientCA string `yaml:"client-ca,omitempty" json:"client-ca,omitempty"`
+	// ClientAuth selects whether client certificates are requested: "none" (default), "request"
+	// (verified when presented, so key-based clients keep working) or "require".
+	ClientAuth string `yaml:"client-auth,omitempty" json:"client-auth,omitempty"`
 }
 
 // RemoteManagement holds management API configuration under 'remote-management'.
@@ -135,6 +162,88 @@ type QuotaExceeded struct {
 	SwitchPreviewModel bool `yaml:"switch-preview-model" json:"switch-preview-model"`
 }
 
+// RoutingConfig selects the credential selection strategy used by the auth manager.
+type RoutingConfig struct {
+	// Strategy names the selector: "round-robin" (default), "weighted-priority" or "least-loaded".
+	Strategy string `yaml:"strategy" json:"strategy"`
+
+	// SessionAffinity keeps multi-turn conversations on the credential that served their first turn.
+	SessionAffinity bool `yaml:"session-affinity" json:"session-affinity"`
+
+	// SessionAffinityTTL is how long, in seconds, an idle session stays pinned. Defaults to 3600.
+	SessionAffinityTTL int `yaml:"session-affinity-ttl,omitempty" json:"session-affinity-ttl,omitempty"`
+}
+
+// ModelPrice sets per-million-token prices for the models matching Model. When several entries
+// match, one restricted to the serving provider wins over an unrestricted one, an exact name over
+// a wildcard pattern, and a longer pattern over a shorter one.
+type ModelPrice struct {
+	// Model is the model name or wildcard pattern (e.g., "claude-sonnet-*").
+	Model string `yaml:"model" json:"model"`
+	// Provider restricts the entry to one provider key (e.g., "vertex"), overriding unrestricted entries.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Input is the price of uncached input tokens.
+	Input float64 `yaml:"input" json:"input"`
+	// Output is the price of output tokens.
+	Output float64 `yaml:"output" json:"output"`
+	// CachedInput is the price of cache-read input tokens. Defaults to Input.
+	CachedInput *float64 `yaml:"cached-input,omitempty" json:"cached-input,omitempty"`
+	// Reasoning is the price of reasoning tokens. Defaults to Output.
+	Reasoning *float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+}
+
+// MetricsConfig controls the Prometheus /metrics endpoint.
+type MetricsConfig struct {
+	// Enable serves /metrics on the API port.
+	Enable bool `yaml:"enable" json:"enable"`
+
+	// Token, when set, must be presented as a bearer token to scrape /metrics. It may be stored
+	// plaintext or hashed like client keys ("sha256:..." or "bcrypt:...").
+	Token string `yaml:"token,omitempty" json:"-"`
+}
+
+// UsageStoreConfig selects a durable backend for usage statistics. Records are kept raw for
+// RawRetentionDays and rolled up into hourly and daily aggregates.
+type UsageStoreConfig struct {
+	// Type is "file" or "postgres". Empty keeps statistics in memory.
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+
+	// Path is the directory of the file store. Defaults to "usage" next to the config file.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+
+	// DSN is the Postgres connection string. Defaults to the PGSTORE_DSN environment variable.
+	DSN string `yaml:"dsn,omitempty" json:"-"`
+
+	// Schema is the optional Postgres schema of the usage tables.
+	Schema string `yaml:"schema,omitempty" json:"schema,omitempty"`
+
+	// Table prefixes the Postgres usage tables. Defaults to "usage".
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+
+	// RawRetentionDays is how long per-request rows are kept. Defaults to 7; negative keeps them forever.
+	RawRetentionDays int `yaml:"raw-retention-days,omitempty" json:"raw-retention-days,omitempty"`
+
+	// HourlyRetentionDays is how long hourly rollups are kept. Defaults to 90; negative keeps
+	// them forever. Daily rollups are always kept.
+	HourlyRetentionDays int `yaml:"hourly-retention-days,omitempty" json:"hourly-retention-days,omitempty"`
+}
+
+// CircuitBreakerConfig configures the per-provider circuit breaker.
+type CircuitBreakerConfig struct {
+	// Enabled turns the circuit breaker on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// FailureThreshold is the number of consecutive upstream failures, across all credentials
+	// of a provider, that opens the breaker. Defaults to 5.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+
+	// OpenSeconds is how long an open breaker rejects requests before admitting probes. Defaults to 30.
+	OpenSeconds int `yaml:"open-seconds,omitempty" json:"open-seconds,omitempty"`
+
+	// HalfOpenProbes caps concurrent probe requests while the breaker is half-open. Defaults to 1.
+	HalfOpenProbes int `yaml:"half-open-probes,omitempty" json:"half-open-probes,omitempty"`
+}
+
 // AmpModelMapping defines a model name mapping for Amp CLI requests.
 // When Amp requests a model that isn't available locally, this mapping
 // allows routing to an alternative model that IS available.
@@ -177,14 +286,37 @@ type PayloadConfig struct {
 	Default []PayloadRule `yaml:"default" json:"default"`
 	// Override defines rules that always set parameters, overwriting any existing values.
 	Override []PayloadRule `yaml:"override" json:"override"`
+	// Delete defines rules that remove parameters from the payload.
+	Delete []PayloadDeleteRule `yaml:"delete,omitempty" json:"delete,omitempty"`
 }
 
 // PayloadRule describes a single rule targeting a list of models with parameter updates.
 type PayloadRule struct {
-	// Models lists model entries with name pattern and protocol constraint.
+	// Models lists model entries with name pattern and protocol constraint. It may be omitted
+	// when ClientKeys or Tags select the rule, in which case every model matches.
 	Models []PayloadModelRule `yaml:"models" json:"models"`
+	// ClientKeys restricts the rule to client keys, matched by principal or virtual key name
+	// ('*' wildcard).
+	ClientKeys []string `yaml:"client-keys,omitempty" json:"client-keys,omitempty"`
+	// Tags restricts the rule to client keys carrying any of these tags.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
 	// Params maps JSON paths (gjson/sjson syntax) to values written into the payload.
 	Params map[string]any `yaml:"params" json:"params"`
+	// SystemPrompt is written as the system prompt in the payload's own format. Default rules
+	// only add it when the request has none; override rules prepend it to the existing one.
+	SystemPrompt string `yaml:"system-prompt,omitempty" json:"system-prompt,omitempty"`
+}
+
+// PayloadDeleteRule removes parameters from the payloads of matching requests.
+type PayloadDeleteRule struct {
+	// Models lists model entries with name pattern and protocol constraint.
+	Models []PayloadModelRule `yaml:"models" json:"models"`
+	// ClientKeys restricts the rule to client keys, as in PayloadRule.
+	ClientKeys []string `yaml:"client-keys,omitempty" json:"client-keys,omitempty"`
+	// Tags restricts the rule to client keys carrying any of these tags.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// Params lists JSON paths (gjson/sjson syntax) removed from the payload.
+	Params []string `yaml:"params" json:"params"`
 }
 
 // PayloadModelRule ties a model name pattern to a specific translator protocol.
@@ -204,6 +336,18 @@ type ClaudeKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/claude-sonnet-4").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Priority places this credential in a selection tier; higher tiers are preferred by the weighted selector.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Weight sets the relative share of traffic this credential receives within its priority tier.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the number of requests executing on this credential at once.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MinInterval spaces out request starts on this credential (e.g. "500ms", "2s").
+	MinInterval string `yaml:"min-interval,omitempty" json:"min-interval,omitempty"`
+
 	// BaseURL is the base URL for the Claude API endpoint.
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -239,6 +383,18 @@ type CodexKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gpt-5-codex").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Priority places this credential in a selection tier; higher tiers are preferred by the weighted selector.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Weight sets the relative share of traffic this credential receives within its priority tier.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the number of requests executing on this credential at once.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MinInterval spaces out request starts on this credential (e.g. "500ms", "2s").
+	MinInterval string `yaml:"min-interval,omitempty" json:"min-interval,omitempty"`
+
 	// BaseURL is the base URL for the Codex API endpoint.
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
@@ -262,6 +418,18 @@ type GeminiKey struct {
 	// Prefix optionally namespaces models for this credential (e.g., "teamA/gemini-3-pro-preview").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
+	// Priority places this credential in a selection tier; higher tiers are preferred by the weighted selector.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Weight sets the relative share of traffic this credential receives within its priority tier.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the number of requests executing on this credential at once.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MinInterval spaces out request starts on this credential (e.g. "500ms", "2s").
+	MinInterval string `yaml:"min-interval,omitempty" json:"min-interval,omitempty"`
+
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
@@ -304,6 +472,18 @@ type OpenAICompatibilityAPIKey struct {
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// Priority places this credential in a selection tier; higher tiers are preferred by the weighted selector.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// Weight sets the relative share of traffic this credential receives within its priority tier.
+	Weight int `yaml:"weight,omitempty" json:"weight,omitempty"`
+
+	// MaxConcurrent caps the number of requests executing on this credential at once.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MinInterval spaces out request starts on this credential (e.g. "500ms", "2s").
+	MinInterval string `yaml:"min-interval,omitempty" json:"min-interval,omitempty"`
 }
 
 // OpenAICompatibilityModel represents a model configuration for OpenAI compatibility,
@@ -429,6 +609,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize OAuth provider model exclusion map.
 	cfg.OAuthExcludedModels = NormalizeOAuthExcludedModels(cfg.OAuthExcludedModels)
 
+	// Normalize model fallback chains.
+	cfg.SanitizeModelFallbacks()
+
 	if cfg.legacyMigrationPending {
 		fmt.Println("Detected legacy configuration keys, attempting to persist the normalized config...")
 		if !optional && configFile != "" {
@@ -530,6 +713,41 @@ func (cfg *Config) SanitizeGeminiKeys() {
 	cfg.GeminiKey = out
 }
 
+// SanitizeModelFallbacks trims model names, drops empty chains, self references and duplicates.
+func (cfg *Config) SanitizeModelFallbacks() {
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return
+	}
+	out := make(map[string][]string, len(cfg.ModelFallbacks))
+	for model, chain := range cfg.ModelFallbacks {
+		key := strings.TrimSpace(model)
+		if key == "" {
+			continue
+		}
+		seen := map[string]struct{}{strings.ToLower(key): {}}
+		cleaned := make([]string, 0, len(chain))
+		for _, fallback := range chain {
+			trimmed := strings.TrimSpace(fallback)
+			lower := strings.ToLower(trimmed)
+			if trimmed == "" {
+				continue
+			}
+			if _, exists := seen[lower]; exists {
+				continue
+			}
+			seen[lower] = struct{}{}
+			cleaned = append(cleaned, trimmed)
+		}
+		if len(cleaned) > 0 {
+			out[key] = cleaned
+		}
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	cfg.ModelFallbacks = out
+}
+
 func normalizeModelPrefix(prefix string) string {
 	trimmed := strings.TrimSpace(prefix)
 	trimmed = strings.Trim(trimmed, "/")
@@ -551,7 +769,7 @@ func syncInlineAccessProvider(cfg *Config) {
 			cfg.APIKeys = append([]string(nil), provider.APIKeys...)
 		}
 	}
-	cfg.Access.Providers = nil
+	cfg.RemoveConfigAPIKeyProviders()
 }
 
 // looksLikeBcrypt returns true if the provided string appears to be a bcrypt hash.
@@ -4,6 +4,8 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import "time"
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -20,10 +22,125 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// ClientKeys attaches per-key settings to client API keys accepted by the access providers.
+	ClientKeys []ClientKey `yaml:"client-keys,omitempty" json:"client-keys,omitempty"`
+
+	// ClientRateLimit sets the default per-key request and token rate limits.
+	ClientRateLimit ClientRateLimit `yaml:"client-rate-limit,omitempty" json:"client-rate-limit,omitempty"`
+
+	// ClientBudget sets the default per-key daily and monthly token budgets.
+	ClientBudget TokenBudget `yaml:"client-budget,omitempty" json:"client-budget,omitempty"`
+
+	// IPFilter restricts which source networks may call the inference routes.
+	IPFilter IPFilter `yaml:"ip-filter,omitempty" json:"ip-filter,omitempty"`
+
 	// Access holds request authentication provider configuration.
 	Access AccessConfig `yaml:"auth,omitempty" json:"auth,omitempty"`
 }
 
+// IPFilter holds source network rules for /v1, /v1beta and /v1internal. Entries are CIDR
+// blocks or single addresses.
+type IPFilter struct {
+	// Allow lists networks admitted; empty admits every network not denied.
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+
+	// Deny lists networks rejected; they take precedence over Allow.
+	Deny []string `yaml:"deny,omitempty" json:"deny,omitempty"`
+
+	// TrustedProxies lists reverse proxies whose X-Forwarded-For header is honoured. Without
+	// entries the connection's remote address is always used.
+	TrustedProxies []string `yaml:"trusted-proxies,omitempty" json:"trusted-proxies,omitempty"`
+}
+
+// ClientKey holds the settings of one client API key.
+type ClientKey struct {
+	// Key is the client API key these settings apply to.
+	Key string `yaml:"key" json:"key"`
+
+	// Admin allows the key to steer routing with the X-ProxyGate-* hint headers.
+	Admin bool `yaml:"admin,omitempty" json:"admin,omitempty"`
+
+	// Tags label the key for payload rules that select consumers by tag.
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
+	// AllowedModels lists model globs the key may request (e.g. "gpt-5*"). Empty allows all.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// AllowedProviders lists provider keys (e.g. "claude", "gemini-cli") the key may reach.
+	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+
+	// AllowedPrefixes lists credential prefixes the key must address (e.g. "teamA/...").
+	AllowedPrefixes []string `yaml:"allowed-prefixes,omitempty" json:"allowed-prefixes,omitempty"`
+
+	// DefaultPrefix is prepended to models requested without an allowed prefix, confining the
+	// key to that prefix's credentials without changing client model names.
+	DefaultPrefix string `yaml:"default-prefix,omitempty" json:"default-prefix,omitempty"`
+
+	// AllowedCIDRs lists source networks (CIDR or single address) the key may be used from.
+	AllowedCIDRs []string `yaml:"allowed-cidrs,omitempty" json:"allowed-cidrs,omitempty"`
+
+	// DeniedCIDRs lists source networks the key is rejected from; they win over AllowedCIDRs.
+	DeniedCIDRs []string `yaml:"denied-cidrs,omitempty" json:"denied-cidrs,omitempty"`
+
+	// ExpiresAt rejects the key from this RFC 3339 timestamp on.
+	ExpiresAt time.Time `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+
+	// Disabled rejects every request made with the key.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// RPM overrides the default requests-per-minute limit; negative disables it for this key.
+	RPM int `yaml:"rpm,omitempty" json:"rpm,omitempty"`
+
+	// TPM overrides the default tokens-per-minute limit; negative disables it for this key.
+	TPM int `yaml:"tpm,omitempty" json:"tpm,omitempty"`
+
+	// Budget overrides the default token budgets; negative limits disable them for this key.
+	Budget TokenBudget `yaml:"budget,omitempty" json:"budget,omitempty"`
+}
+
+// ClientRateLimit holds per-minute allowances applied to each client key. Zero disables a limit.
+type ClientRateLimit struct {
+	// RPM caps requests per minute.
+	RPM int `yaml:"rpm,omitempty" json:"rpm,omitempty"`
+
+	// TPM caps input plus output tokens per minute, charged as usage is reported.
+	TPM int `yaml:"tpm,omitempty" json:"tpm,omitempty"`
+}
+
+// TokenBudget holds the token allowances of a client key per UTC day and calendar month.
+type TokenBudget struct {
+	// Daily resets at 00:00 UTC.
+	Daily BudgetLimits `yaml:"daily,omitempty" json:"daily,omitempty"`
+
+	// Monthly resets on the first day of each month, 00:00 UTC.
+	Monthly BudgetLimits `yaml:"monthly,omitempty" json:"monthly,omitempty"`
+}
+
+// BudgetLimits caps the tokens consumed in one budget period. Zero disables a limit.
+type BudgetLimits struct {
+	// TotalTokens caps input plus output tokens.
+	TotalTokens int64 `yaml:"total-tokens,omitempty" json:"total-tokens,omitempty"`
+
+	// InputTokens caps prompt tokens.
+	InputTokens int64 `yaml:"input-tokens,omitempty" json:"input-tokens,omitempty"`
+
+	// OutputTokens caps completion tokens, including reasoning.
+	OutputTokens int64 `yaml:"output-tokens,omitempty" json:"output-tokens,omitempty"`
+}
+
+// ClientKeySettings returns the settings configured for key, or nil when none exist.
+func (c *SDKConfig) ClientKeySettings(key string) *ClientKey {
+	if c == nil || key == "" {
+		return nil
+	}
+	for i := range c.ClientKeys {
+		if c.ClientKeys[i].Key == key {
+			return &c.ClientKeys[i]
+		}
+	}
+	return nil
+}
+
 // AccessConfig groups request authentication providers.
 type AccessConfig struct {
 	// Providers lists configured authentication providers.
@@ -52,6 +169,12 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeJWT is the built-in provider validating OIDC/JWT bearer tokens against a JWKS.
+	AccessProviderTypeJWT = "jwt"
+
+	// AccessProviderTypeClientCert is the built-in provider authenticating TLS client certificates.
+	AccessProviderTypeClientCert = "client-cert"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
@@ -72,6 +195,29 @@ func (c *SDKConfig) ConfigAPIKeyProvider() *AccessProvider {
 	return nil
 }
 
+// RemoveConfigAPIKeyProviders drops inline API key provider entries, whose keys live in api-keys,
+// while keeping other provider types such as jwt.
+func (c *SDKConfig) RemoveConfigAPIKeyProviders() {
+	if c == nil {
+		return
+	}
+	kept := c.Access.Providers[:0]
+	for _, provider := range c.Access.Providers {
+		if provider.Type != AccessProviderTypeConfigAPIKey {
+			kept = append(kept, provider)
+		}
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	c.Access.Providers = kept
+}
+
+// HasConfigAPIKeyProvider reports whether an inline API key provider is configured explicitly.
+func (c *SDKConfig) HasConfigAPIKeyProvider() bool {
+	return c.ConfigAPIKeyProvider() != nil
+}
+
 // MakeInlineAPIKeyProvider constructs an inline API key provider configuration.
 // It returns nil when no keys are supplied.
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
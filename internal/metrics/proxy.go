@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+	sdktranslator "github.com/radityprtama/proxygate/v6/sdk/translator"
+)
+
+// Credential states reported by the credential status gauge.
+const (
+	CredentialActive   = "active"
+	CredentialCooling  = "cooling"
+	CredentialDisabled = "disabled"
+)
+
+var credentialStates = []string{CredentialActive, CredentialCooling, CredentialDisabled}
+
+// requestLatencyBuckets are the upper bounds, in seconds, of the request latency histogram.
+var requestLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+var defaultRegistry = NewRegistry()
+
+var (
+	requestsTotal = defaultRegistry.NewCounterVec("proxygate_requests_total",
+		"Inference requests served, by inbound format, model, provider and HTTP status.",
+		"format", "model", "provider", "status")
+	requestDuration = defaultRegistry.NewHistogramVec("proxygate_request_duration_seconds",
+		"Inference request latency in seconds, including streaming, by inbound format, model, provider and HTTP status.",
+		requestLatencyBuckets, "format", "model", "provider", "status")
+	tokensTotal = defaultRegistry.NewCounterVec("proxygate_tokens_total",
+		"Tokens reported by upstream usage, by provider, model and token type.",
+		"provider", "model", "type")
+	retriesTotal = defaultRegistry.NewCounterVec("proxygate_retries_total",
+		"Failed upstream attempts marked against a credential, each retried on another credential or after a cooldown.",
+		"provider", "status")
+	cooldownsTotal = defaultRegistry.NewCounterVec("proxygate_cooldowns_total",
+		"Credential cooldowns started, by provider and reason.",
+		"provider", "reason")
+	executorErrorsTotal = defaultRegistry.NewCounterVec("proxygate_executor_errors_total",
+		"Errors returned by provider executors, by provider and HTTP status (0 when unknown).",
+		"provider", "status")
+	translatorErrorsTotal = defaultRegistry.NewCounterVec("proxygate_translator_errors_total",
+		"Translator failures (panics or invalid JSON output), by source format, target format and stage.",
+		"from", "to", "stage")
+	credentialStatus = defaultRegistry.NewGaugeFunc("proxygate_credential_status",
+		"Credential state: 1 for the current state of each credential, 0 otherwise.",
+		nil, "provider", "auth", "state")
+)
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{})
+	sdktranslator.SetErrorObserver(func(from, to sdktranslator.Format, stage string) {
+		translatorErrorsTotal.Inc(from.String(), to.String(), stage)
+	})
+}
+
+// Default returns the registry holding the proxy metrics.
+func Default() *Registry { return defaultRegistry }
+
+// requestLabelsKey stores the labels of the in-flight request in the gin context.
+const requestLabelsKey = "metricsRequestLabels"
+
+type requestLabels struct {
+	format   string
+	model    string
+	provider string
+}
+
+// Middleware records the count and latency of each request it wraps. Handlers fill in the
+// model, provider and inbound format with LabelRequest; until then the format is derived from
+// the route.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		labels := &requestLabels{}
+		c.Set(requestLabelsKey, labels)
+		started := time.Now()
+		c.Next()
+		format := labels.format
+		if format == "" {
+			format = formatFromPath(c.Request.URL.Path)
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requestsTotal.Inc(format, labels.model, labels.provider, status)
+		requestDuration.Observe(time.Since(started).Seconds(), format, labels.model, labels.provider, status)
+	}
+}
+
+// LabelRequest attaches the inbound format, model and serving provider to the request metrics of
+// the gin request carried by ctx. Empty values leave the current label unchanged.
+func LabelRequest(ctx context.Context, format, model, provider string) {
+	if ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	raw, ok := ginCtx.Get(requestLabelsKey)
+	if !ok {
+		return
+	}
+	labels, ok := raw.(*requestLabels)
+	if !ok || labels == nil {
+		return
+	}
+	if format != "" {
+		labels.format = format
+	}
+	if model != "" {
+		labels.model = model
+	}
+	if provider != "" {
+		labels.provider = provider
+	}
+}
+
+func formatFromPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/v1beta"):
+		return "gemini"
+	case strings.HasPrefix(path, "/v1internal"):
+		return "gemini-cli"
+	case strings.HasPrefix(path, "/v1/messages"):
+		return "claude"
+	case strings.HasPrefix(path, "/v1/responses"):
+		return "openai-response"
+	}
+	return "openai"
+}
+
+// RecordRetry counts a failed upstream attempt marked against a credential.
+func RecordRetry(provider string, status int) {
+	retriesTotal.Inc(provider, strconv.Itoa(status))
+}
+
+// RecordCooldown counts a credential cooldown started for reason.
+func RecordCooldown(provider, reason string) {
+	cooldownsTotal.Inc(provider, reason)
+}
+
+// RecordExecutorError counts an error returned by a provider executor.
+func RecordExecutorError(provider string, status int) {
+	executorErrorsTotal.Inc(provider, strconv.Itoa(status))
+}
+
+// CredentialState describes one credential for the credential status gauge.
+type CredentialState struct {
+	Provider string
+	ID       string
+	State    string
+}
+
+// SetCredentialSource installs the function listing credentials at scrape time.
+func SetCredentialSource(fn func() []CredentialState) {
+	if fn == nil {
+		credentialStatus.Set(nil)
+		return
+	}
+	credentialStatus.Set(func() []GaugeSample {
+		states := fn()
+		samples := make([]GaugeSample, 0, len(states)*len(credentialStates))
+		for _, cred := range states {
+			for _, state := range credentialStates {
+				value := 0.0
+				if cred.State == state {
+					value = 1
+				}
+				samples = append(samples, GaugeSample{Values: []string{cred.Provider, cred.ID, state}, Value: value})
+			}
+		}
+		return samples
+	})
+}
+
+// usagePlugin counts the tokens of usage records.
+type usagePlugin struct{}
+
+// HandleUsage implements coreusage.Plugin.
+func (usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	detail := record.Detail
+	for _, entry := range []struct {
+		kind  string
+		value int64
+	}{
+		{"input", detail.InputTokens},
+		{"output", detail.OutputTokens},
+		{"reasoning", detail.ReasoningTokens},
+		{"cached", detail.CachedTokens},
+	} {
+		if entry.value > 0 {
+			tokensTotal.Add(float64(entry.value), record.Provider, record.Model, entry.kind)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// Package metrics exposes proxy metrics in the Prometheus text exposition format. It keeps a
+// small in-process registry of counters, histograms and scrape-time gauges instead of pulling in
+// the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// labelSeparator joins label values into map keys; it cannot appear in valid UTF-8 text.
+const labelSeparator = "\xff"
+
+// collector writes one metric family.
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families in registration order.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// NewRegistry constructs an empty registry.
+func NewRegistry() *Registry { return &Registry{} }
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, c)
+	r.mu.Unlock()
+}
+
+// WriteTo writes every family in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	counting := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counting)
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	err := buffered.Flush()
+	return counting.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// CounterVec is a family of monotonically increasing counters partitioned by labels.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec registers a counter family.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Add increases the counter for the given label values. Negative deltas are ignored.
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if c == nil || delta < 0 || math.IsNaN(delta) {
+		return
+	}
+	key := strings.Join(values, labelSeparator)
+	c.mu.Lock()
+	c.values[key] += delta
+	c.mu.Unlock()
+}
+
+// Inc increases the counter for the given label values by one.
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+// Value returns the current counter for the given label values.
+func (c *CounterVec) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[strings.Join(values, labelSeparator)]
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	keys := sortedKeys(c.values)
+	snapshot := make([]float64, len(keys))
+	for i, key := range keys {
+		snapshot[i] = c.values[key]
+	}
+	c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for i, key := range keys {
+		writeSample(w, c.name, c.labels, splitKey(key, len(c.labels)), "", "", snapshot[i])
+	}
+}
+
+// HistogramVec is a family of histograms partitioned by labels.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram family with the given upper bucket bounds.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: sorted, values: make(map[string]*histogram)}
+	r.register(h)
+	return h
+}
+
+// Observe records a value for the given label values.
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	if h == nil || math.IsNaN(value) {
+		return
+	}
+	key := strings.Join(values, labelSeparator)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entry, ok := h.values[key]
+	if !ok {
+		entry = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = entry
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			entry.counts[i]++
+		}
+	}
+	entry.count++
+	entry.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	keys := sortedKeys(h.values)
+	snapshot := make([]histogram, len(keys))
+	for i, key := range keys {
+		entry := h.values[key]
+		snapshot[i] = histogram{counts: append([]uint64(nil), entry.counts...), count: entry.count, sum: entry.sum}
+	}
+	h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	for i, key := range keys {
+		values := splitKey(key, len(h.labels))
+		for j, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, values, "le", formatFloat(bound), float64(snapshot[i].counts[j]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, values, "le", "+Inf", float64(snapshot[i].count))
+		writeSample(w, h.name+"_sum", h.labels, values, "", "", snapshot[i].sum)
+		writeSample(w, h.name+"_count", h.labels, values, "", "", float64(snapshot[i].count))
+	}
+}
+
+// GaugeSample is one series reported by a GaugeFunc.
+type GaugeSample struct {
+	Values []string
+	Value  float64
+}
+
+// gaugeFunc is a gauge family computed at scrape time.
+type gaugeFunc struct {
+	name   string
+	help   string
+	labels []string
+	mu     sync.RWMutex
+	fn     func() []GaugeSample
+}
+
+// GaugeFunc is a handle to a scrape-time gauge family whose source can be replaced.
+type GaugeFunc struct{ g *gaugeFunc }
+
+// NewGaugeFunc registers a gauge family whose samples are produced by fn at scrape time.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() []GaugeSample, labels ...string) GaugeFunc {
+	g := &gaugeFunc{name: name, help: help, labels: labels, fn: fn}
+	r.register(g)
+	return GaugeFunc{g: g}
+}
+
+// Set replaces the function producing the family's samples. Nil reports no samples.
+func (g GaugeFunc) Set(fn func() []GaugeSample) {
+	g.g.mu.Lock()
+	g.g.fn = fn
+	g.g.mu.Unlock()
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.mu.RLock()
+	fn := g.fn
+	g.mu.RUnlock()
+	writeHeader(w, g.name, g.help, "gauge")
+	if fn == nil {
+		return
+	}
+	samples := fn()
+	sort.SliceStable(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Values, labelSeparator) < strings.Join(samples[j].Values, labelSeparator)
+	})
+	for _, sample := range samples {
+		writeSample(w, g.name, g.labels, sample.Values, "", "", sample.Value)
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		first := true
+		for i, label := range labels {
+			if !first {
+				w.WriteByte(',')
+			}
+			first = false
+			v := ""
+			if i < len(values) {
+				v = values[i]
+			}
+			w.WriteString(label)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(v))
+			w.WriteByte('"')
+		}
+		if extraName != "" {
+			if !first {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName)
+			w.WriteString(`="`)
+			w.WriteString(extraValue)
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string       { return helpReplacer.Replace(s) }
+func escapeLabelValue(s string) string { return labelReplacer.Replace(s) }
+
+func splitKey(key string, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return strings.SplitN(key, labelSeparator, n)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
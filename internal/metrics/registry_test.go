@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesTextExposition(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("test_requests_total", "Requests.\nPer route.", "route")
+	counter.Inc(`/v1/"chat"`)
+	counter.Add(2, "/v1/models")
+	histogram := registry.NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 0.5}, "route")
+	histogram.Observe(0.2, "/v1/models")
+	histogram.Observe(0.7, "/v1/models")
+	histogram.Observe(3, "/v1/models")
+	registry.NewGaugeFunc("test_up", "Up.", func() []GaugeSample {
+		return []GaugeSample{{Value: 1}}
+	})
+
+	var out strings.Builder
+	if _, err := registry.WriteTo(&out); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	want := `# HELP test_requests_total Requests.\nPer route.
+# TYPE test_requests_total counter
+test_requests_total{route="/v1/\"chat\""} 1
+test_requests_total{route="/v1/models"} 2
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/v1/models",le="0.5"} 1
+test_latency_seconds_bucket{route="/v1/models",le="1"} 2
+test_latency_seconds_bucket{route="/v1/models",le="+Inf"} 3
+test_latency_seconds_sum{route="/v1/models"} 3.9
+test_latency_seconds_count{route="/v1/models"} 3
+# HELP test_up Up.
+# TYPE test_up gauge
+test_up 1
+`
+	if out.String() != want {
+		t.Fatalf("unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
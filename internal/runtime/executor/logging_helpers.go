@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/radityprtama/proxygate/v6/internal/config"
 	"github.com/radityprtama/proxygate/v6/internal/util"
+	cliproxyauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
 )
 
 const (
@@ -94,7 +95,9 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 }
 
 // recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
+// It also feeds upstream rate-limit headers into the executing credential's quota snapshot.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
+	cliproxyauth.ObserveRateLimitHeaders(ctx, status, headers)
 	if cfg == nil || !cfg.RequestLog {
 		return
 	}
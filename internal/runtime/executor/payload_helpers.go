@@ -1,12 +1,16 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/gin-gonic/gin"
 	"github.com/radityprtama/proxygate/v6/internal/config"
 	"github.com/radityprtama/proxygate/v6/internal/util"
+	"github.com/radityprtama/proxygate/v6/internal/virtualkey"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -82,33 +86,41 @@ func ApplyReasoningEffortMetadata(payload []byte, metadata map[string]any, model
 	return payload
 }
 
-// applyPayloadConfig applies payload default and override rules from configuration
+// applyPayloadConfig applies payload default, override and delete rules from configuration
 // to the given JSON payload for the specified model.
 // Defaults only fill missing fields, while overrides always overwrite existing values.
-func applyPayloadConfig(cfg *config.Config, model string, payload []byte) []byte {
-	return applyPayloadConfigWithRoot(cfg, model, "", "", payload)
+// Format names the payload schema ("openai", "claude", "gemini" or "codex") for system prompt
+// rules; protocol constraints on rules are not applied.
+func applyPayloadConfig(ctx context.Context, cfg *config.Config, model, format string, payload []byte) []byte {
+	return applyPayloadRules(ctx, cfg, model, "", format, "", payload)
 }
 
 // applyPayloadConfigWithRoot behaves like applyPayloadConfig but treats all parameter
 // paths as relative to the provided root path (for example, "request" for Gemini CLI)
 // and restricts matches to the given protocol when supplied.
-func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload []byte) []byte {
+func applyPayloadConfigWithRoot(ctx context.Context, cfg *config.Config, model, protocol, root string, payload []byte) []byte {
+	return applyPayloadRules(ctx, cfg, model, protocol, protocol, root, payload)
+}
+
+func applyPayloadRules(ctx context.Context, cfg *config.Config, model, protocol, format, root string, payload []byte) []byte {
 	if cfg == nil || len(payload) == 0 {
 		return payload
 	}
 	rules := cfg.Payload
-	if len(rules.Default) == 0 && len(rules.Override) == 0 {
+	if len(rules.Default) == 0 && len(rules.Override) == 0 && len(rules.Delete) == 0 {
 		return payload
 	}
 	model = strings.TrimSpace(model)
 	if model == "" {
 		return payload
 	}
+	client := payloadClientFromContext(ctx, cfg)
+	format = payloadFormat(format)
 	out := payload
 	// Apply default rules: first write wins per field across all matching rules.
 	for i := range rules.Default {
 		rule := &rules.Default[i]
-		if !payloadRuleMatchesModel(rule, model, protocol) {
+		if !payloadRuleMatches(rule.Models, rule.ClientKeys, rule.Tags, model, protocol, client) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -125,11 +137,14 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 			}
 			out = updated
 		}
+		if rule.SystemPrompt != "" {
+			out = applySystemPrompt(out, format, root, rule.SystemPrompt, false)
+		}
 	}
 	// Apply override rules: last write wins per field across all matching rules.
 	for i := range rules.Override {
 		rule := &rules.Override[i]
-		if !payloadRuleMatchesModel(rule, model, protocol) {
+		if !payloadRuleMatches(rule.Models, rule.ClientKeys, rule.Tags, model, protocol, client) {
 			continue
 		}
 		for path, value := range rule.Params {
@@ -143,18 +158,99 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 			}
 			out = updated
 		}
+		if rule.SystemPrompt != "" {
+			out = applySystemPrompt(out, format, root, rule.SystemPrompt, true)
+		}
+	}
+	// Apply delete rules last so stripped parameters stay removed.
+	for i := range rules.Delete {
+		rule := &rules.Delete[i]
+		if !payloadRuleMatches(rule.Models, rule.ClientKeys, rule.Tags, model, protocol, client) {
+			continue
+		}
+		for _, path := range rule.Params {
+			fullPath := buildPayloadPath(root, path)
+			if fullPath == "" || !gjson.GetBytes(out, fullPath).Exists() {
+				continue
+			}
+			if updated, errDel := sjson.DeleteBytes(out, fullPath); errDel == nil {
+				out = updated
+			}
+		}
 	}
 	return out
 }
 
-func payloadRuleMatchesModel(rule *config.PayloadRule, model, protocol string) bool {
-	if rule == nil {
-		return false
+// payloadClient identifies the client key a request was authenticated with.
+type payloadClient struct {
+	names []string
+	tags  []string
+}
+
+func payloadClientFromContext(ctx context.Context, cfg *config.Config) payloadClient {
+	var client payloadClient
+	if ctx == nil {
+		return client
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return client
+	}
+	if key := apiKeyFromContext(ctx); key != "" {
+		client.names = append(client.names, key)
+		if settings := cfg.ClientKeySettings(key); settings != nil {
+			client.tags = append(client.tags, settings.Tags...)
+		}
 	}
-	if len(rule.Models) == 0 {
-		return false
+	if raw, exists := ginCtx.Get("accessMetadata"); exists {
+		if metadata, okMeta := raw.(map[string]string); okMeta {
+			if name := strings.TrimSpace(metadata[virtualkey.MetadataKeyName]); name != "" {
+				client.names = append(client.names, name)
+			}
+			for _, tag := range strings.Split(metadata[sdkaccess.MetadataTags], ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					client.tags = append(client.tags, tag)
+				}
+			}
+		}
 	}
-	for _, entry := range rule.Models {
+	return client
+}
+
+// payloadRuleMatches reports whether a rule applies to the request. Rules naming client keys
+// or tags only apply to those clients and, without model entries, to every model.
+func payloadRuleMatches(models []config.PayloadModelRule, clientKeys, tags []string, model, protocol string, client payloadClient) bool {
+	if len(clientKeys) > 0 || len(tags) > 0 {
+		if !client.matches(clientKeys, tags) {
+			return false
+		}
+		if len(models) == 0 {
+			return true
+		}
+	}
+	return payloadModelsMatch(models, model, protocol)
+}
+
+func (c payloadClient) matches(clientKeys, tags []string) bool {
+	for _, pattern := range clientKeys {
+		for _, name := range c.names {
+			if util.MatchModelPattern(pattern, name) {
+				return true
+			}
+		}
+	}
+	for _, want := range tags {
+		for _, tag := range c.tags {
+			if strings.EqualFold(strings.TrimSpace(want), tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func payloadModelsMatch(models []config.PayloadModelRule, model, protocol string) bool {
+	for _, entry := range models {
 		name := strings.TrimSpace(entry.Name)
 		if name == "" {
 			continue
@@ -162,13 +258,105 @@ func payloadRuleMatchesModel(rule *config.PayloadRule, model, protocol string) b
 		if ep := strings.TrimSpace(entry.Protocol); ep != "" && protocol != "" && !strings.EqualFold(ep, protocol) {
 			continue
 		}
-		if matchModelPattern(name, model) {
+		if util.MatchModelPattern(name, model) {
 			return true
 		}
 	}
 	return false
 }
 
+// payloadFormat maps a translator protocol to the payload schema it produces.
+func payloadFormat(protocol string) string {
+	switch strings.ToLower(strings.TrimSpace(protocol)) {
+	case "claude":
+		return "claude"
+	case "gemini", "gemini-cli", "antigravity":
+		return "gemini"
+	case "codex", "openai-response":
+		return "codex"
+	}
+	return "openai"
+}
+
+// applySystemPrompt writes prompt as the system prompt of a payload in the given format. When
+// prepend is false the prompt is only added to requests without a system prompt; otherwise it
+// is placed ahead of the existing one.
+func applySystemPrompt(payload []byte, format, root, prompt string, prepend bool) []byte {
+	switch format {
+	case "claude":
+		path := buildPayloadPath(root, "system")
+		existing := gjson.GetBytes(payload, path)
+		block, _ := sjson.Set(`{"type":"text"}`, "text", prompt)
+		switch {
+		case !existing.Exists() || (existing.Type == gjson.String && existing.String() == "") || (existing.IsArray() && len(existing.Array()) == 0):
+			return setPayloadValue(payload, path, prompt)
+		case !prepend:
+			return payload
+		case existing.IsArray():
+			return prependPayloadArray(payload, path, block)
+		}
+		return setPayloadValue(payload, path, prompt+"\n\n"+existing.String())
+	case "gemini":
+		path := buildPayloadPath(root, "systemInstruction")
+		if gjson.GetBytes(payload, buildPayloadPath(root, "system_instruction")).Exists() {
+			path = buildPayloadPath(root, "system_instruction")
+		}
+		part, _ := sjson.Set(`{}`, "text", prompt)
+		if !gjson.GetBytes(payload, path+".parts").IsArray() {
+			return setPayloadRaw(payload, path, `{"parts":[`+part+`]}`)
+		}
+		if !prepend {
+			return payload
+		}
+		return prependPayloadArray(payload, path+".parts", part)
+	case "codex":
+		path := buildPayloadPath(root, "instructions")
+		existing := strings.TrimSpace(gjson.GetBytes(payload, path).String())
+		if existing == "" {
+			return setPayloadValue(payload, path, prompt)
+		}
+		if !prepend {
+			return payload
+		}
+		return setPayloadValue(payload, path, prompt+"\n\n"+existing)
+	}
+	path := buildPayloadPath(root, "messages")
+	if !prepend {
+		for _, message := range gjson.GetBytes(payload, path).Array() {
+			if role := message.Get("role").String(); role == "system" || role == "developer" {
+				return payload
+			}
+		}
+	}
+	message, _ := sjson.Set(`{"role":"system"}`, "content", prompt)
+	return prependPayloadArray(payload, path, message)
+}
+
+func setPayloadValue(payload []byte, path string, value any) []byte {
+	if updated, err := sjson.SetBytes(payload, path, value); err == nil {
+		return updated
+	}
+	return payload
+}
+
+func setPayloadRaw(payload []byte, path, raw string) []byte {
+	if updated, err := sjson.SetRawBytes(payload, path, []byte(raw)); err == nil {
+		return updated
+	}
+	return payload
+}
+
+// prependPayloadArray inserts a raw JSON item at the start of the array at path, creating the
+// array when missing.
+func prependPayloadArray(payload []byte, path, item string) []byte {
+	existing := gjson.GetBytes(payload, path)
+	items := []string{item}
+	for _, element := range existing.Array() {
+		items = append(items, element.Raw)
+	}
+	return setPayloadRaw(payload, path, "["+strings.Join(items, ",")+"]")
+}
+
 // buildPayloadPath combines an optional root path with a relative parameter path.
 // When root is empty, the parameter path is used as-is. When root is non-empty,
 // the parameter path is treated as relative to root.
@@ -187,51 +375,6 @@ func buildPayloadPath(root, path string) string {
 	return r + "." + p
 }
 
-// matchModelPattern performs simple wildcard matching where '*' matches zero or more characters.
-// Examples:
-//
-//	"*-5" matches "gpt-5"
-//	"gpt-*" matches "gpt-5" and "gpt-4"
-//	"gemini-*-pro" matches "gemini-2.5-pro" and "gemini-3-pro".
-func matchModelPattern(pattern, model string) bool {
-	pattern = strings.TrimSpace(pattern)
-	model = strings.TrimSpace(model)
-	if pattern == "" {
-		return false
-	}
-	if pattern == "*" {
-		return true
-	}
-	// Iterative glob-style matcher supporting only '*' wildcard.
-	pi, si := 0, 0
-	starIdx := -1
-	matchIdx := 0
-	for si < len(model) {
-		if pi < len(pattern) && (pattern[pi] == model[si]) {
-			pi++
-			si++
-			continue
-		}
-		if pi < len(pattern) && pattern[pi] == '*' {
-			starIdx = pi
-			matchIdx = si
-			pi++
-			continue
-		}
-		if starIdx != -1 {
-			pi = starIdx + 1
-			matchIdx++
-			si = matchIdx
-			continue
-		}
-		return false
-	}
-	for pi < len(pattern) && pattern[pi] == '*' {
-		pi++
-	}
-	return pi == len(pattern)
-}
-
 // NormalizeThinkingConfig normalizes thinking-related fields in the payload
 // based on model capabilities. For models without thinking support, it strips
 // reasoning fields. For models with level-based thinking, it validates and
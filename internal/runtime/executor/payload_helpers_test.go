@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/config"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	"github.com/tidwall/gjson"
+)
+
+func clientContext(apiKey string, metadata map[string]string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", apiKey)
+	if metadata != nil {
+		c.Set("accessMetadata", metadata)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestApplyPayloadConfig_ClientScopedRules(t *testing.T) {
+	cfg := &config.Config{Payload: config.PayloadConfig{
+		Default: []config.PayloadRule{{
+			Tags:         []string{"research"},
+			SystemPrompt: "Team prompt.",
+			Params:       map[string]any{"max_tokens": 4096},
+		}},
+		Override: []config.PayloadRule{{
+			Models:     []config.PayloadModelRule{{Name: "o*"}},
+			ClientKeys: []string{"team-a-*"},
+			Params:     map[string]any{"reasoning_effort": "low"},
+		}},
+		Delete: []config.PayloadDeleteRule{{
+			Models: []config.PayloadModelRule{{Name: "o*"}},
+			Tags:   []string{"research"},
+			Params: []string{"temperature"},
+		}},
+	}}
+	cfg.ClientKeys = []config.ClientKey{{Key: "team-a-1", Tags: []string{"research"}}}
+	payload := []byte(`{"model":"o3","temperature":0.2,"messages":[{"role":"user","content":"hi"}]}`)
+
+	out := applyPayloadConfig(clientContext("team-a-1", nil), cfg, "o3", "openai", payload)
+	if got := gjson.GetBytes(out, "messages.0.content").String(); got != "Team prompt." || gjson.GetBytes(out, "messages.#").Int() != 2 {
+		t.Fatalf("expected system message prepended, got %s", out)
+	}
+	if gjson.GetBytes(out, "max_tokens").Int() != 4096 || gjson.GetBytes(out, "reasoning_effort").String() != "low" {
+		t.Fatalf("expected default and override params, got %s", out)
+	}
+	if gjson.GetBytes(out, "temperature").Exists() {
+		t.Fatalf("expected temperature deleted, got %s", out)
+	}
+
+	untouched := applyPayloadConfig(clientContext("team-b-1", nil), cfg, "o3", "openai", payload)
+	if string(untouched) != string(payload) {
+		t.Fatalf("expected rules to skip other clients, got %s", untouched)
+	}
+
+	virtual := clientContext("vk-123", map[string]string{sdkaccess.MetadataTags: "ops,research"})
+	claude := applyPayloadConfig(virtual, cfg, "claude-sonnet-4-5", "claude", []byte(`{"system":"Existing.","messages":[]}`))
+	if got := gjson.GetBytes(claude, "system").String(); got != "Existing." {
+		t.Fatalf("expected default system prompt to keep the existing one, got %q", got)
+	}
+	gemini := applyPayloadConfig(virtual, cfg, "gemini-2.5-pro", "gemini", []byte(`{"contents":[]}`))
+	if got := gjson.GetBytes(gemini, "systemInstruction.parts.0.text").String(); got != "Team prompt." {
+		t.Fatalf("expected gemini system instruction, got %s", gemini)
+	}
+}
+
+func TestApplySystemPrompt_PrependsPerFormat(t *testing.T) {
+	claude := applySystemPrompt([]byte(`{"system":[{"type":"text","text":"B"}]}`), "claude", "", "A", true)
+	if gjson.GetBytes(claude, "system.0.text").String() != "A" || gjson.GetBytes(claude, "system.1.text").String() != "B" {
+		t.Fatalf("unexpected claude system %s", claude)
+	}
+	codex := applySystemPrompt([]byte(`{"instructions":"B"}`), "codex", "", "A", true)
+	if gjson.GetBytes(codex, "instructions").String() != "A\n\nB" {
+		t.Fatalf("unexpected codex instructions %s", codex)
+	}
+	cli := applySystemPrompt([]byte(`{"request":{"systemInstruction":{"parts":[{"text":"B"}]}}}`), "gemini", "request", "A", true)
+	if gjson.GetBytes(cli, "request.systemInstruction.parts.0.text").String() != "A" || gjson.GetBytes(cli, "request.systemInstruction.parts.#").Int() != 2 {
+		t.Fatalf("unexpected gemini cli system instruction %s", cli)
+	}
+}
@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/radityprtama/proxygate/v6/internal/config"
 	cliproxyauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
+	"github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
 )
@@ -49,7 +51,7 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		transport := buildProxyTransport(proxyURL)
 		if transport != nil {
 			httpClient.Transport = transport
-			return httpClient
+			return withUpstreamTiming(ctx, httpClient)
 		}
 		// If proxy setup failed, log and fall through to context RoundTripper
 		log.Debugf("failed to setup proxy from URL: %s, falling back to context transport", proxyURL)
@@ -60,9 +62,57 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 		httpClient.Transport = rt
 	}
 
+	return withUpstreamTiming(ctx, httpClient)
+}
+
+// withUpstreamTiming wraps the client transport to report the upstream time to first byte to
+// the request timing carried by ctx, if any.
+func withUpstreamTiming(ctx context.Context, httpClient *http.Client) *http.Client {
+	timing := usage.TimingFromContext(ctx)
+	if timing == nil {
+		return httpClient
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = &timedRoundTripper{base: base, timing: timing}
 	return httpClient
 }
 
+// timedRoundTripper measures the time from sending each request to the first byte of its
+// response body.
+type timedRoundTripper struct {
+	base   http.RoundTripper
+	timing *usage.Timing
+}
+
+func (t *timedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = &firstByteBody{ReadCloser: resp.Body, onFirstByte: func() {
+		t.timing.ObserveUpstreamFirstByte(time.Since(started))
+	}}
+	return resp, nil
+}
+
+type firstByteBody struct {
+	io.ReadCloser
+	onFirstByte func()
+}
+
+func (b *firstByteBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.onFirstByte != nil {
+		b.onFirstByte()
+		b.onFirstByte = nil
+	}
+	return n, err
+}
+
 // buildProxyTransport creates an HTTP transport configured for the given proxy URL.
 // It supports SOCKS5, HTTP, and HTTPS proxy protocols.
 //
@@ -16,24 +16,28 @@ import (
 )
 
 type usageReporter struct {
-	provider    string
-	model       string
-	authID      string
-	authIndex   uint64
-	apiKey      string
-	source      string
-	requestedAt time.Time
-	once        sync.Once
+	provider       string
+	model          string
+	requestedModel string
+	authID         string
+	authIndex      uint64
+	apiKey         string
+	source         string
+	requestedAt    time.Time
+	queue          cliproxyauth.QueueStats
+	once           sync.Once
 }
 
 func newUsageReporter(ctx context.Context, provider, model string, auth *cliproxyauth.Auth) *usageReporter {
 	apiKey := apiKeyFromContext(ctx)
 	reporter := &usageReporter{
-		provider:    provider,
-		model:       model,
-		requestedAt: time.Now(),
-		apiKey:      apiKey,
-		source:      resolveUsageSource(auth, apiKey),
+		provider:       provider,
+		model:          model,
+		requestedModel: cliproxyauth.RequestedModelFromContext(ctx),
+		requestedAt:    time.Now(),
+		queue:          cliproxyauth.QueueStatsFromContext(ctx),
+		apiKey:         apiKey,
+		source:         resolveUsageSource(auth, apiKey),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -73,20 +77,30 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			RequestedAt: r.requestedAt,
-			Failed:      failed,
-			Detail:      detail,
-		})
+		usage.PublishRecord(ctx, r.record(detail, failed))
 	})
 }
 
+// record builds the usage record for this request. Duration covers the executor call; when the
+// request carries a usage.Timing, publishing replaces it and fills in the other timings.
+func (r *usageReporter) record(detail usage.Detail, failed bool) usage.Record {
+	return usage.Record{
+		Provider:       r.provider,
+		Model:          r.model,
+		RequestedModel: r.requestedModel,
+		Source:         r.source,
+		APIKey:         r.apiKey,
+		AuthID:         r.authID,
+		AuthIndex:      r.authIndex,
+		RequestedAt:    r.requestedAt,
+		Failed:         failed,
+		Detail:         detail,
+		QueueDepth:     r.queue.Depth,
+		QueueWait:      r.queue.Wait,
+		Duration:       time.Since(r.requestedAt),
+	}
+}
+
 // ensurePublished guarantees that a usage record is emitted exactly once.
 // It is safe to call multiple times; only the first call wins due to once.Do.
 // This is used to ensure request counting even when upstream responses do not
@@ -96,17 +110,7 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
-			Provider:    r.provider,
-			Model:       r.model,
-			Source:      r.source,
-			APIKey:      r.apiKey,
-			AuthID:      r.authID,
-			AuthIndex:   r.authIndex,
-			RequestedAt: r.requestedAt,
-			Failed:      false,
-			Detail:      usage.Detail{},
-		})
+		usage.PublishRecord(ctx, r.record(usage.Detail{}, false))
 	})
 }
 
@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/go-git/go-git/v6/plumbing/transport"
 	"github.com/go-git/go-git/v6/plumbing/transport/http"
 	cliproxyauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // GitTokenStore persists token records and auth metadata using git as the backing storage.
@@ -31,8 +33,18 @@ type GitTokenStore struct {
 	remote    string
 	username  string
 	password  string
+
+	// statePending holds state documents written to the working tree but not yet pushed;
+	// statePushedAt and stateTimer pace their pushes to one per gitStatePushInterval.
+	statePending  map[string]struct{}
+	statePushedAt time.Time
+	stateTimer    *time.Timer
 }
 
+// gitStatePushInterval spaces out commits of state documents, which are rewritten every few
+// seconds while the proxy serves traffic. Writes in between only touch the working tree.
+const gitStatePushInterval = 15 * time.Minute
+
 // NewGitTokenStore creates a token store that saves credentials to disk through the
 // TokenStorage implementation embedded in the token record.
 func NewGitTokenStore(remote, username, password string) *GitTokenStore {
@@ -652,6 +664,120 @@ func (s *GitTokenStore) rewriteHeadAsSingleCommit(repo *git.Repository, branch p
 	return nil
 }
 
+// gitStatePath returns the repository-relative location of a named state document.
+func gitStatePath(name string) string {
+	return "state/" + name + ".json"
+}
+
+// LoadState reads a named state document from the repository.
+func (s *GitTokenStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	repoDir := s.repoDirSnapshot()
+	if repoDir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(repoDir, filepath.FromSlash(gitStatePath(name))))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git token store: read %s: %w", name, err)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// SaveState writes a named state document to the working tree. It is committed and pushed
+// right away when no state was pushed within gitStatePushInterval, and otherwise once the
+// interval has passed or on FlushState.
+func (s *GitTokenStore) SaveState(_ context.Context, name string, data []byte) error {
+	if err := s.EnsureRepository(); err != nil {
+		return err
+	}
+	repoDir := s.repoDirSnapshot()
+	if repoDir == "" {
+		return fmt.Errorf("git token store: repository path not configured")
+	}
+	relPath := gitStatePath(name)
+	path := filepath.Join(repoDir, filepath.FromSlash(relPath))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, errRead := os.ReadFile(path); errRead == nil && jsonEqual(existing, data) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("git token store: create state dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("git token store: write %s: %w", name, err)
+	}
+	if s.statePending == nil {
+		s.statePending = make(map[string]struct{})
+	}
+	s.statePending[relPath] = struct{}{}
+	if wait := gitStatePushInterval - time.Since(s.statePushedAt); wait > 0 {
+		if s.stateTimer == nil {
+			s.stateTimer = time.AfterFunc(wait, s.pushPendingState)
+		}
+		return nil
+	}
+	return s.pushStateLocked()
+}
+
+// FlushState commits and pushes the state documents still waiting for their push.
+func (s *GitTokenStore) FlushState(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pushStateLocked()
+}
+
+func (s *GitTokenStore) pushPendingState() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stateTimer = nil
+	if err := s.pushStateLocked(); err != nil {
+		log.Warnf("git token store: push state: %v", err)
+	}
+}
+
+// pushStateLocked commits and pushes the pending state documents. Failed pushes stay pending.
+func (s *GitTokenStore) pushStateLocked() error {
+	if len(s.statePending) == 0 {
+		return nil
+	}
+	if s.stateTimer != nil {
+		s.stateTimer.Stop()
+		s.stateTimer = nil
+	}
+	paths := make([]string, 0, len(s.statePending))
+	for relPath := range s.statePending {
+		paths = append(paths, relPath)
+	}
+	sort.Strings(paths)
+	s.statePushedAt = time.Now()
+	if err := s.commitAndPushLocked("Update state", paths...); err != nil {
+		return err
+	}
+	s.statePending = nil
+	return nil
+}
+
+// LoadRuntimeStates reads persisted cooldowns and model states from the repository.
+func (s *GitTokenStore) LoadRuntimeStates(ctx context.Context) (map[string]*cliproxyauth.RuntimeState, error) {
+	return cliproxyauth.LoadRuntimeStatesFrom(ctx, s)
+}
+
+// SaveRuntimeStates writes cooldowns and model states to the repository; see SaveState.
+func (s *GitTokenStore) SaveRuntimeStates(ctx context.Context, states map[string]*cliproxyauth.RuntimeState) error {
+	return cliproxyauth.SaveRuntimeStatesTo(ctx, s, states)
+}
+
 // PersistConfig commits and pushes configuration changes to git.
 func (s *GitTokenStore) PersistConfig(_ context.Context) error {
 	if err := s.EnsureRepository(); err != nil {
@@ -23,8 +23,9 @@ import (
 )
 
 const (
-	objectStoreConfigKey  = "config/config.yaml"
-	objectStoreAuthPrefix = "auths"
+	objectStoreConfigKey   = "config/config.yaml"
+	objectStoreAuthPrefix  = "auths"
+	objectStoreStatePrefix = "state"
 )
 
 // ObjectStoreConfig captures configuration for the object storage-backed token store.
@@ -324,6 +325,45 @@ func (s *ObjectTokenStore) PersistConfig(ctx context.Context) error {
 	return s.putObject(ctx, objectStoreConfigKey, data, "application/x-yaml")
 }
 
+// LoadState downloads a named state document from the bucket.
+func (s *ObjectTokenStore) LoadState(ctx context.Context, name string) ([]byte, error) {
+	key := s.prefixedKey(objectStoreStatePrefix + "/" + name + ".json")
+	if _, err := s.client.StatObject(ctx, s.cfg.Bucket, key, minio.StatObjectOptions{}); err != nil {
+		if isObjectNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("object store: stat %s: %w", name, err)
+	}
+	object, err := s.client.GetObject(ctx, s.cfg.Bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("object store: fetch %s: %w", name, err)
+	}
+	defer object.Close()
+	data, err := io.ReadAll(object)
+	if err != nil {
+		return nil, fmt.Errorf("object store: read %s: %w", name, err)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// SaveState uploads a named state document to the bucket.
+func (s *ObjectTokenStore) SaveState(ctx context.Context, name string, data []byte) error {
+	return s.putObject(ctx, objectStoreStatePrefix+"/"+name+".json", data, "application/json")
+}
+
+// LoadRuntimeStates downloads persisted cooldowns and model states from the bucket.
+func (s *ObjectTokenStore) LoadRuntimeStates(ctx context.Context) (map[string]*cliproxyauth.RuntimeState, error) {
+	return cliproxyauth.LoadRuntimeStatesFrom(ctx, s)
+}
+
+// SaveRuntimeStates uploads cooldowns and model states to the bucket.
+func (s *ObjectTokenStore) SaveRuntimeStates(ctx context.Context, states map[string]*cliproxyauth.RuntimeState) error {
+	return cliproxyauth.SaveRuntimeStatesTo(ctx, s, states)
+}
+
 func (s *ObjectTokenStore) ensureBucket(ctx context.Context) error {
 	exists, err := s.client.BucketExists(ctx, s.cfg.Bucket)
 	if err != nil {
@@ -0,0 +1,397 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/radityprtama/proxygate/v6/internal/usage"
+)
+
+const defaultUsageTable = "usage"
+
+// PostgresUsageSink persists usage records to PostgreSQL. Raw rows go to <table>_events and
+// are folded into <table>_hourly and <table>_daily in the same transaction.
+type PostgresUsageSink struct {
+	db  *sql.DB
+	cfg PostgresStoreConfig
+}
+
+// NewPostgresUsageSink connects to PostgreSQL and prepares the usage tables. Only DSN, Schema
+// and UsageTable of cfg are used.
+func NewPostgresUsageSink(ctx context.Context, cfg PostgresStoreConfig) (*PostgresUsageSink, error) {
+	cfg.DSN = strings.TrimSpace(cfg.DSN)
+	if cfg.DSN == "" {
+		return nil, fmt.Errorf("postgres usage store: DSN is required")
+	}
+	if strings.TrimSpace(cfg.UsageTable) == "" {
+		cfg.UsageTable = defaultUsageTable
+	}
+	db, err := sql.Open("pgx", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("postgres usage store: open database connection: %w", err)
+	}
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("postgres usage store: ping database: %w", err)
+	}
+	sink := &PostgresUsageSink{db: db, cfg: cfg}
+	if err = sink.ensureSchema(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *PostgresUsageSink) table(suffix string) string {
+	name := s.cfg.UsageTable + "_" + suffix
+	if strings.TrimSpace(s.cfg.Schema) == "" {
+		return quoteIdentifier(name)
+	}
+	return quoteIdentifier(s.cfg.Schema) + "." + quoteIdentifier(name)
+}
+
+func (s *PostgresUsageSink) ensureSchema(ctx context.Context) error {
+	if schema := strings.TrimSpace(s.cfg.Schema); schema != "" {
+		query := fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", quoteIdentifier(schema))
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("postgres usage store: create schema: %w", err)
+		}
+	}
+	events := s.table("events")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			requested_at TIMESTAMPTZ NOT NULL,
+			api_key TEXT NOT NULL,
+			model TEXT NOT NULL,
+			requested_model TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			auth_id TEXT NOT NULL DEFAULT '',
+			auth_index BIGINT NOT NULL DEFAULT 0,
+			source TEXT NOT NULL DEFAULT '',
+			failed BOOLEAN NOT NULL DEFAULT FALSE,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0,
+			cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+			queue_depth INTEGER NOT NULL DEFAULT 0,
+			queue_wait_ms BIGINT NOT NULL DEFAULT 0,
+			upstream_first_byte_ms BIGINT NOT NULL DEFAULT 0,
+			client_first_chunk_ms BIGINT NOT NULL DEFAULT 0,
+			duration_ms BIGINT NOT NULL DEFAULT 0,
+			retries INTEGER NOT NULL DEFAULT 0,
+			credentials_tried INTEGER NOT NULL DEFAULT 0
+		)
+	`, events)); err != nil {
+		return fmt.Errorf("postgres usage store: create events table: %w", err)
+	}
+	index := quoteIdentifier(s.cfg.UsageTable + "_events_requested_at_idx")
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (requested_at)", index, events)); err != nil {
+		return fmt.Errorf("postgres usage store: create events index: %w", err)
+	}
+	for _, suffix := range []string{"hourly", "daily"} {
+		if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				bucket_start TIMESTAMPTZ NOT NULL,
+				api_key TEXT NOT NULL,
+				model TEXT NOT NULL,
+				provider TEXT NOT NULL,
+				auth_id TEXT NOT NULL,
+				requests BIGINT NOT NULL DEFAULT 0,
+				failures BIGINT NOT NULL DEFAULT 0,
+				input_tokens BIGINT NOT NULL DEFAULT 0,
+				output_tokens BIGINT NOT NULL DEFAULT 0,
+				reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+				cached_tokens BIGINT NOT NULL DEFAULT 0,
+				total_tokens BIGINT NOT NULL DEFAULT 0,
+				cost DOUBLE PRECISION NOT NULL DEFAULT 0,
+				PRIMARY KEY (bucket_start, api_key, model, provider, auth_id)
+			)
+		`, s.table(suffix))); err != nil {
+			return fmt.Errorf("postgres usage store: create %s table: %w", suffix, err)
+		}
+	}
+	// Tables created before costs were recorded lack the cost column.
+	for _, suffix := range []string{"events", "hourly", "daily"} {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS cost DOUBLE PRECISION NOT NULL DEFAULT 0", s.table(suffix))
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("postgres usage store: add cost column to %s: %w", suffix, err)
+		}
+	}
+	// Likewise for the request timings.
+	for _, column := range []string{
+		"upstream_first_byte_ms BIGINT", "client_first_chunk_ms BIGINT", "duration_ms BIGINT",
+		"retries INTEGER", "credentials_tried INTEGER",
+	} {
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s NOT NULL DEFAULT 0", events, column)
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("postgres usage store: add timing column to events: %w", err)
+		}
+	}
+	return nil
+}
+
+// Write implements usage.Sink.
+func (s *PostgresUsageSink) Write(ctx context.Context, rows []usage.Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("postgres usage store: begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	insert := fmt.Sprintf(`
+		INSERT INTO %s (requested_at, api_key, model, requested_model, provider, auth_id, auth_index, source, failed,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, cost, queue_depth, queue_wait_ms,
+			upstream_first_byte_ms, client_first_chunk_ms, duration_ms, retries, credentials_tried)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+	`, s.table("events"))
+	for _, row := range rows {
+		if _, err = tx.ExecContext(ctx, insert,
+			row.Timestamp.UTC(), row.APIKey, row.Model, row.RequestedModel, row.Provider, row.AuthID,
+			int64(row.AuthIndex), row.Source, row.Failed,
+			row.Tokens.InputTokens, row.Tokens.OutputTokens, row.Tokens.ReasoningTokens,
+			row.Tokens.CachedTokens, row.Tokens.TotalTokens, row.Cost, row.QueueDepth, row.QueueWaitMs,
+			row.UpstreamFirstByteMs, row.ClientFirstChunkMs, row.DurationMs, row.Retries, row.CredentialsTried,
+		); err != nil {
+			return fmt.Errorf("postgres usage store: insert event: %w", err)
+		}
+	}
+	for suffix, granularity := range map[string]string{"hourly": usage.GranularityHour, "daily": usage.GranularityDay} {
+		upsert := fmt.Sprintf(`
+			INSERT INTO %[1]s AS r (bucket_start, api_key, model, provider, auth_id, requests, failures,
+				input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, cost)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ON CONFLICT (bucket_start, api_key, model, provider, auth_id)
+			DO UPDATE SET
+				requests = r.requests + EXCLUDED.requests,
+				failures = r.failures + EXCLUDED.failures,
+				input_tokens = r.input_tokens + EXCLUDED.input_tokens,
+				output_tokens = r.output_tokens + EXCLUDED.output_tokens,
+				reasoning_tokens = r.reasoning_tokens + EXCLUDED.reasoning_tokens,
+				cached_tokens = r.cached_tokens + EXCLUDED.cached_tokens,
+				total_tokens = r.total_tokens + EXCLUDED.total_tokens,
+				cost = r.cost + EXCLUDED.cost
+		`, s.table(suffix))
+		for _, bucket := range usage.RollupRows(rows, granularity) {
+			if _, err = tx.ExecContext(ctx, upsert,
+				bucket.Start, bucket.APIKey, bucket.Model, bucket.Provider, bucket.AuthID, bucket.Requests, bucket.Failures,
+				bucket.Tokens.InputTokens, bucket.Tokens.OutputTokens, bucket.Tokens.ReasoningTokens,
+				bucket.Tokens.CachedTokens, bucket.Tokens.TotalTokens, bucket.Cost,
+			); err != nil {
+				return fmt.Errorf("postgres usage store: upsert %s rollup: %w", suffix, err)
+			}
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("postgres usage store: commit: %w", err)
+	}
+	return nil
+}
+
+// Query implements usage.Sink.
+func (s *PostgresUsageSink) Query(ctx context.Context, q usage.Query) (usage.QueryResult, error) {
+	suffix := "hourly"
+	if q.Granularity == usage.GranularityDay {
+		suffix = "daily"
+	}
+	where, args := usageRangeClause("bucket_start", q.From, q.To)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT bucket_start, api_key, model, provider, auth_id, requests, failures,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, cost
+		FROM %s%s
+		ORDER BY bucket_start, api_key, model, provider, auth_id
+	`, s.table(suffix), where), args...)
+	if err != nil {
+		return usage.QueryResult{}, fmt.Errorf("postgres usage store: query rollups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var result usage.QueryResult
+	for rows.Next() {
+		var bucket usage.Bucket
+		if err = rows.Scan(&bucket.Start, &bucket.APIKey, &bucket.Model, &bucket.Provider, &bucket.AuthID,
+			&bucket.Requests, &bucket.Failures, &bucket.Tokens.InputTokens, &bucket.Tokens.OutputTokens,
+			&bucket.Tokens.ReasoningTokens, &bucket.Tokens.CachedTokens, &bucket.Tokens.TotalTokens, &bucket.Cost); err != nil {
+			return usage.QueryResult{}, fmt.Errorf("postgres usage store: scan rollup: %w", err)
+		}
+		bucket.Start = bucket.Start.UTC()
+		result.Buckets = append(result.Buckets, bucket)
+	}
+	if err = rows.Err(); err != nil {
+		return usage.QueryResult{}, fmt.Errorf("postgres usage store: iterate rollups: %w", err)
+	}
+	if q.DetailLimit <= 0 {
+		return result, nil
+	}
+	details, err := s.queryEvents(ctx, q)
+	if err != nil {
+		return usage.QueryResult{}, err
+	}
+	result.Details = details
+	return result, nil
+}
+
+// queryEvents returns the most recent raw rows in range, oldest first.
+func (s *PostgresUsageSink) queryEvents(ctx context.Context, q usage.Query) ([]usage.Row, error) {
+	where, args := usageRangeClause("requested_at", q.From, q.To)
+	args = append(args, q.DetailLimit)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT requested_at, api_key, model, requested_model, provider, auth_id, auth_index, source, failed,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, cost, queue_depth, queue_wait_ms,
+			upstream_first_byte_ms, client_first_chunk_ms, duration_ms, retries, credentials_tried
+		FROM %s%s
+		ORDER BY requested_at DESC, id DESC
+		LIMIT $%d
+	`, s.table("events"), where, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres usage store: query events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []usage.Row
+	for rows.Next() {
+		var row usage.Row
+		var authIndex int64
+		if err = rows.Scan(&row.Timestamp, &row.APIKey, &row.Model, &row.RequestedModel, &row.Provider, &row.AuthID,
+			&authIndex, &row.Source, &row.Failed, &row.Tokens.InputTokens, &row.Tokens.OutputTokens,
+			&row.Tokens.ReasoningTokens, &row.Tokens.CachedTokens, &row.Tokens.TotalTokens, &row.Cost,
+			&row.QueueDepth, &row.QueueWaitMs, &row.UpstreamFirstByteMs, &row.ClientFirstChunkMs, &row.DurationMs,
+			&row.Retries, &row.CredentialsTried); err != nil {
+			return nil, fmt.Errorf("postgres usage store: scan event: %w", err)
+		}
+		row.Timestamp = row.Timestamp.UTC()
+		row.AuthIndex = uint64(authIndex)
+		out = append(out, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres usage store: iterate events: %w", err)
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out, nil
+}
+
+// usageGroupColumns maps export dimensions to rollup columns.
+var usageGroupColumns = map[string]string{
+	usage.GroupByKey:      "api_key",
+	usage.GroupByModel:    "model",
+	usage.GroupByProvider: "provider",
+	usage.GroupByAuth:     "auth_id",
+}
+
+// Aggregate implements usage.Aggregator, grouping the rollups in the database and streaming
+// the groups to fn as they are read.
+func (s *PostgresUsageSink) Aggregate(ctx context.Context, q usage.ExportQuery, fn func(usage.Bucket) error) error {
+	suffix := "hourly"
+	if q.Granularity == usage.GranularityDay {
+		suffix = "daily"
+	}
+	columns := make([]string, 0, len(q.GroupBy))
+	for _, dim := range q.GroupBy {
+		column, ok := usageGroupColumns[dim]
+		if !ok {
+			return fmt.Errorf("postgres usage store: unknown group dimension %q", dim)
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("postgres usage store: no group dimensions")
+	}
+	grouped := strings.Join(columns, ", ")
+	where, args := usageRangeClause("bucket_start", q.From, q.To)
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s, SUM(requests), SUM(failures), SUM(input_tokens), SUM(output_tokens),
+			SUM(reasoning_tokens), SUM(cached_tokens), SUM(total_tokens), SUM(cost)
+		FROM %s%s
+		GROUP BY %s
+		ORDER BY %s
+	`, grouped, s.table(suffix), where, grouped, grouped), args...)
+	if err != nil {
+		return fmt.Errorf("postgres usage store: aggregate rollups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var bucket usage.Bucket
+		dims := make([]string, len(columns))
+		dest := make([]any, 0, len(columns)+8)
+		for i := range dims {
+			dest = append(dest, &dims[i])
+		}
+		dest = append(dest, &bucket.Requests, &bucket.Failures, &bucket.Tokens.InputTokens, &bucket.Tokens.OutputTokens,
+			&bucket.Tokens.ReasoningTokens, &bucket.Tokens.CachedTokens, &bucket.Tokens.TotalTokens, &bucket.Cost)
+		if err = rows.Scan(dest...); err != nil {
+			return fmt.Errorf("postgres usage store: scan aggregate: %w", err)
+		}
+		for i, dim := range q.GroupBy {
+			switch dim {
+			case usage.GroupByKey:
+				bucket.APIKey = dims[i]
+			case usage.GroupByModel:
+				bucket.Model = dims[i]
+			case usage.GroupByProvider:
+				bucket.Provider = dims[i]
+			case usage.GroupByAuth:
+				bucket.AuthID = dims[i]
+			}
+		}
+		if err = fn(bucket); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("postgres usage store: iterate aggregate: %w", err)
+	}
+	return nil
+}
+
+// Prune implements usage.Sink.
+func (s *PostgresUsageSink) Prune(ctx context.Context, rawBefore, hourlyBefore time.Time) error {
+	if !rawBefore.IsZero() {
+		query := fmt.Sprintf("DELETE FROM %s WHERE requested_at < $1", s.table("events"))
+		if _, err := s.db.ExecContext(ctx, query, rawBefore.UTC()); err != nil {
+			return fmt.Errorf("postgres usage store: prune events: %w", err)
+		}
+	}
+	if !hourlyBefore.IsZero() {
+		query := fmt.Sprintf("DELETE FROM %s WHERE bucket_start < $1", s.table("hourly"))
+		if _, err := s.db.ExecContext(ctx, query, hourlyBefore.UTC()); err != nil {
+			return fmt.Errorf("postgres usage store: prune hourly rollups: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close implements usage.Sink.
+func (s *PostgresUsageSink) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func usageRangeClause(column string, from, to time.Time) (string, []any) {
+	var conditions []string
+	var args []any
+	if !from.IsZero() {
+		args = append(args, from.UTC())
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", column, len(args)))
+	}
+	if !to.IsZero() {
+		args = append(args, to.UTC())
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", column, len(args)))
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
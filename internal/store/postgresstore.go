@@ -32,6 +32,8 @@ type PostgresStoreConfig struct {
 	ConfigTable string
 	AuthTable   string
 	SpoolDir    string
+	// UsageTable prefixes the usage statistics tables (default "usage").
+	UsageTable string
 }
 
 // PostgresStore persists configuration and authentication metadata using PostgreSQL as backend
@@ -391,6 +393,43 @@ func (s *PostgresStore) PersistConfig(ctx context.Context) error {
 	return s.persistConfig(ctx, data)
 }
 
+// LoadState reads a named state document stored as a row of the config table.
+func (s *PostgresStore) LoadState(ctx context.Context, name string) ([]byte, error) {
+	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
+	var content string
+	if err := s.db.QueryRowContext(ctx, query, name).Scan(&content); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("postgres store: load %s: %w", name, err)
+	}
+	return []byte(content), nil
+}
+
+// SaveState stores a named state document as a row of the config table.
+func (s *PostgresStore) SaveState(ctx context.Context, name string, data []byte) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, content, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (id)
+		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+	`, s.fullTableName(s.cfg.ConfigTable))
+	if _, err := s.db.ExecContext(ctx, query, name, string(data)); err != nil {
+		return fmt.Errorf("postgres store: upsert %s: %w", name, err)
+	}
+	return nil
+}
+
+// LoadRuntimeStates reads persisted cooldowns and model states from the config table.
+func (s *PostgresStore) LoadRuntimeStates(ctx context.Context) (map[string]*cliproxyauth.RuntimeState, error) {
+	return cliproxyauth.LoadRuntimeStatesFrom(ctx, s)
+}
+
+// SaveRuntimeStates stores cooldowns and model states as a row of the config table.
+func (s *PostgresStore) SaveRuntimeStates(ctx context.Context, states map[string]*cliproxyauth.RuntimeState) error {
+	return cliproxyauth.SaveRuntimeStatesTo(ctx, s, states)
+}
+
 // syncConfigFromDatabase writes the database-stored config to disk or seeds the database from template.
 func (s *PostgresStore) syncConfigFromDatabase(ctx context.Context, exampleConfigPath string) error {
 	query := fmt.Sprintf("SELECT content FROM %s WHERE id = $1", s.fullTableName(s.cfg.ConfigTable))
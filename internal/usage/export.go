@@ -0,0 +1,181 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Dimensions usage can be grouped by when exported.
+const (
+	GroupByKey      = "key"
+	GroupByModel    = "model"
+	GroupByProvider = "provider"
+	GroupByAuth     = "auth"
+)
+
+// GroupBy lists the dimensions usage is aggregated by, in output order.
+type GroupBy []string
+
+// ParseGroupBy parses a comma-separated list of dimensions. An empty list groups by client key.
+func ParseGroupBy(raw string) (GroupBy, error) {
+	var out GroupBy
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		dim := strings.ToLower(strings.TrimSpace(part))
+		if dim == "" || seen[dim] {
+			continue
+		}
+		switch dim {
+		case GroupByKey, GroupByModel, GroupByProvider, GroupByAuth:
+		default:
+			return nil, fmt.Errorf("unknown dimension %q: must be key, model, provider or auth", dim)
+		}
+		seen[dim] = true
+		out = append(out, dim)
+	}
+	if len(out) == 0 {
+		out = GroupBy{GroupByKey}
+	}
+	return out, nil
+}
+
+// Has reports whether usage is grouped by dim.
+func (g GroupBy) Has(dim string) bool {
+	for _, d := range g {
+		if d == dim {
+			return true
+		}
+	}
+	return false
+}
+
+// Group returns the identity of the group b falls into: b with its start time and the
+// dimensions outside g cleared, and its counters zeroed.
+func (g GroupBy) Group(b Bucket) Bucket {
+	out := Bucket{}
+	if g.Has(GroupByKey) {
+		out.APIKey = b.APIKey
+	}
+	if g.Has(GroupByModel) {
+		out.Model = b.Model
+	}
+	if g.Has(GroupByProvider) {
+		out.Provider = b.Provider
+	}
+	if g.Has(GroupByAuth) {
+		out.AuthID = b.AuthID
+	}
+	return out
+}
+
+// Dimension returns the value of dim in b.
+func Dimension(b Bucket, dim string) string {
+	switch dim {
+	case GroupByKey:
+		return b.APIKey
+	case GroupByModel:
+		return b.Model
+	case GroupByProvider:
+		return b.Provider
+	case GroupByAuth:
+		return b.AuthID
+	}
+	return ""
+}
+
+// sort orders groups by the dimensions of g, in order.
+func (g GroupBy) sort(groups []Bucket) {
+	sort.Slice(groups, func(i, j int) bool {
+		for _, dim := range g {
+			a, b := Dimension(groups[i], dim), Dimension(groups[j], dim)
+			if a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+}
+
+// ExportQuery selects usage in the half-open range [From, To) aggregated by GroupBy. Persisted
+// usage is read from the rollups of Granularity, which are selected by their start time.
+type ExportQuery struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+	GroupBy     GroupBy
+}
+
+// Aggregator is implemented by sinks that can group persisted usage themselves, streaming the
+// groups instead of returning every rollup in range.
+type Aggregator interface {
+	// Aggregate calls fn with each group, ordered by the group dimensions. It stops at the first
+	// error returned by fn.
+	Aggregate(ctx context.Context, q ExportQuery, fn func(Bucket) error) error
+}
+
+// AggregateSink calls fn with each group of the usage persisted in sink, ordered by the group
+// dimensions. Sinks that do not implement Aggregator are grouped from their queried rollups.
+func AggregateSink(ctx context.Context, sink Sink, q ExportQuery, fn func(Bucket) error) error {
+	if aggregator, ok := sink.(Aggregator); ok {
+		return aggregator.Aggregate(ctx, q, fn)
+	}
+	result, err := sink.Query(ctx, Query{From: q.From, To: q.To, Granularity: q.Granularity})
+	if err != nil {
+		return err
+	}
+	groups := make(map[BucketKey]*Bucket)
+	for _, bucket := range result.Buckets {
+		group := q.GroupBy.Group(bucket)
+		existing, ok := groups[group.Key()]
+		if !ok {
+			existing = &group
+			groups[group.Key()] = existing
+		}
+		existing.Merge(bucket)
+	}
+	return emitGroups(groups, q.GroupBy, fn)
+}
+
+// Aggregate calls fn with each group of the in-memory requests in [q.From, q.To), ordered by
+// the group dimensions. q.Granularity is ignored; requests are filtered by their timestamp.
+func (s *RequestStatistics) Aggregate(q ExportQuery, fn func(Bucket) error) error {
+	groups := make(map[BucketKey]*Bucket)
+	if s != nil {
+		s.mu.RLock()
+		for apiName, stats := range s.apis {
+			for modelName, modelStatsValue := range stats.Models {
+				for _, detail := range modelStatsValue.Details {
+					if !inRange(detail.Timestamp, q.From, q.To) {
+						continue
+					}
+					group := q.GroupBy.Group(Bucket{APIKey: apiName, Model: modelName, Provider: detail.Provider, AuthID: detail.AuthID})
+					existing, ok := groups[group.Key()]
+					if !ok {
+						existing = &group
+						groups[group.Key()] = existing
+					}
+					existing.Add(Row{Failed: detail.Failed, Tokens: detail.Tokens, Cost: detail.Cost})
+				}
+			}
+		}
+		s.mu.RUnlock()
+	}
+	return emitGroups(groups, q.GroupBy, fn)
+}
+
+func emitGroups(groups map[BucketKey]*Bucket, groupBy GroupBy, fn func(Bucket) error) error {
+	ordered := make([]Bucket, 0, len(groups))
+	for _, group := range groups {
+		ordered = append(ordered, *group)
+	}
+	groupBy.sort(ordered)
+	for _, group := range ordered {
+		if err := fn(group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
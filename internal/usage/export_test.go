@@ -0,0 +1,74 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAggregateSink_GroupsRollupsByRequestedDimensions(t *testing.T) {
+	ctx := context.Background()
+	sink, err := NewFileSink(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	day := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	rows := []Row{
+		{Timestamp: day, APIKey: "team-a", Model: "gpt-4o", Provider: "openai", AuthID: "a1", Tokens: TokenStats{InputTokens: 10, TotalTokens: 12}, Cost: 0.5},
+		{Timestamp: day.Add(3 * time.Hour), APIKey: "team-a", Model: "claude", Provider: "claude", AuthID: "c1", Failed: true, Tokens: TokenStats{InputTokens: 4, TotalTokens: 4}},
+		{Timestamp: day.Add(time.Hour), APIKey: "team-b", Model: "gpt-4o", Provider: "openai", AuthID: "a2", Tokens: TokenStats{OutputTokens: 7, TotalTokens: 7}, Cost: 0.25},
+		{Timestamp: day.AddDate(0, 1, 0), APIKey: "team-a", Model: "gpt-4o", Provider: "openai", AuthID: "a1", Tokens: TokenStats{TotalTokens: 100}},
+	}
+	if err = sink.Write(ctx, rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	groupBy, err := ParseGroupBy("key, provider")
+	if err != nil {
+		t.Fatalf("ParseGroupBy: %v", err)
+	}
+	query := ExportQuery{
+		From:        time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		To:          time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		Granularity: GranularityDay,
+		GroupBy:     groupBy,
+	}
+	var groups []Bucket
+	if err = AggregateSink(ctx, sink, query, func(b Bucket) error {
+		groups = append(groups, b)
+		return nil
+	}); err != nil {
+		t.Fatalf("AggregateSink: %v", err)
+	}
+	if len(groups) != 3 {
+		t.Fatalf("groups = %+v, want 3", groups)
+	}
+	if g := groups[0]; g.APIKey != "team-a" || g.Provider != "claude" || g.Model != "" || g.Requests != 1 || g.Failures != 1 {
+		t.Fatalf("first group = %+v", g)
+	}
+	if g := groups[1]; g.APIKey != "team-a" || g.Provider != "openai" || g.Tokens.InputTokens != 10 || g.Cost != 0.5 {
+		t.Fatalf("second group = %+v", g)
+	}
+
+	stats := NewRequestStatistics()
+	stats.apis["team-b"] = &apiStats{Models: map[string]*modelStats{
+		"gpt-4o": {Details: []RequestDetail{
+			{Timestamp: day, Provider: "openai", AuthID: "a2", Tokens: TokenStats{OutputTokens: 7, TotalTokens: 7}},
+			{Timestamp: day.AddDate(0, 1, 0), Provider: "openai", AuthID: "a2", Tokens: TokenStats{TotalTokens: 9}},
+		}},
+	}}
+	groups = nil
+	if err = stats.Aggregate(query, func(b Bucket) error {
+		groups = append(groups, b)
+		return nil
+	}); err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Requests != 1 || groups[0].Tokens.OutputTokens != 7 {
+		t.Fatalf("in-memory groups = %+v", groups)
+	}
+
+	if _, err = ParseGroupBy("key,region"); err == nil {
+		t.Fatal("unknown dimension should be rejected")
+	}
+}
@@ -0,0 +1,365 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fileSinkRawDir      = "raw"
+	fileSinkRollupsFile = "rollups.json"
+	fileSinkDayLayout   = "2006-01-02"
+)
+
+// FileSink persists usage to a local directory. Raw rows are appended to one JSON-lines file
+// per UTC day under raw/, and the hourly and daily rollups are kept in memory and rewritten
+// atomically to rollups.json after every batch.
+type FileSink struct {
+	mu     sync.Mutex
+	dir    string
+	hourly map[BucketKey]*Bucket
+	daily  map[BucketKey]*Bucket
+}
+
+type fileSinkRollups struct {
+	Hourly []Bucket `json:"hourly"`
+	Daily  []Bucket `json:"daily"`
+}
+
+// NewFileSink opens or creates a file sink rooted at dir.
+func NewFileSink(dir string) (*FileSink, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("usage file store: directory is required")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, fileSinkRawDir), 0o700); err != nil {
+		return nil, fmt.Errorf("usage file store: create directory: %w", err)
+	}
+	sink := &FileSink{
+		dir:    dir,
+		hourly: make(map[BucketKey]*Bucket),
+		daily:  make(map[BucketKey]*Bucket),
+	}
+	data, err := os.ReadFile(filepath.Join(dir, fileSinkRollupsFile))
+	switch {
+	case err == nil:
+		var rollups fileSinkRollups
+		if errUnmarshal := json.Unmarshal(data, &rollups); errUnmarshal != nil {
+			return nil, fmt.Errorf("usage file store: decode rollups: %w", errUnmarshal)
+		}
+		for i := range rollups.Hourly {
+			bucket := rollups.Hourly[i]
+			sink.hourly[bucket.Key()] = &bucket
+		}
+		for i := range rollups.Daily {
+			bucket := rollups.Daily[i]
+			sink.daily[bucket.Key()] = &bucket
+		}
+	case errors.Is(err, fs.ErrNotExist):
+	default:
+		return nil, fmt.Errorf("usage file store: read rollups: %w", err)
+	}
+	return sink, nil
+}
+
+// Write implements Sink. A batch is all-or-nothing: if any raw append or the rollup rewrite
+// fails, the raw files are truncated back and the in-memory rollups are left untouched, so the
+// caller can retry the whole batch without double counting.
+func (s *FileSink) Write(_ context.Context, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byDay := make(map[string][]Row)
+	for _, row := range rows {
+		day := row.Timestamp.UTC().Format(fileSinkDayLayout)
+		byDay[day] = append(byDay[day], row)
+	}
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	appended := make(map[string]int64, len(days))
+	rollback := func(err error) error {
+		for day, size := range appended {
+			if errTruncate := s.truncateRaw(day, size); errTruncate != nil {
+				err = errors.Join(err, errTruncate)
+			}
+		}
+		return err
+	}
+	for _, day := range days {
+		size, err := s.appendRaw(day, byDay[day])
+		if err != nil {
+			return rollback(err)
+		}
+		appended[day] = size
+	}
+	hourly := maps.Clone(s.hourly)
+	daily := maps.Clone(s.daily)
+	mergeBuckets(hourly, RollupRows(rows, GranularityHour))
+	mergeBuckets(daily, RollupRows(rows, GranularityDay))
+	if err := s.saveRollups(hourly, daily); err != nil {
+		return rollback(err)
+	}
+	s.hourly, s.daily = hourly, daily
+	return nil
+}
+
+// Query implements Sink.
+func (s *FileSink) Query(_ context.Context, q Query) (QueryResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source := s.hourly
+	if q.Granularity == GranularityDay {
+		source = s.daily
+	}
+	var result QueryResult
+	for _, bucket := range source {
+		if inRange(bucket.Start, q.From, q.To) {
+			result.Buckets = append(result.Buckets, *bucket)
+		}
+	}
+	SortBuckets(result.Buckets)
+	if q.DetailLimit <= 0 {
+		return result, nil
+	}
+	details, err := s.readRaw(q.From, q.To, q.DetailLimit)
+	if err != nil {
+		return QueryResult{}, err
+	}
+	result.Details = details
+	return result, nil
+}
+
+// Prune implements Sink. Raw files are removed a whole day at a time, once the entire day is
+// older than rawBefore.
+func (s *FileSink) Prune(_ context.Context, rawBefore, hourlyBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !rawBefore.IsZero() {
+		days, err := s.rawDays()
+		if err != nil {
+			return err
+		}
+		for _, day := range days {
+			start, errParse := time.Parse(fileSinkDayLayout, day)
+			if errParse != nil || !start.AddDate(0, 0, 1).Before(rawBefore) {
+				continue
+			}
+			if errRemove := os.Remove(s.rawPath(day)); errRemove != nil && !errors.Is(errRemove, fs.ErrNotExist) {
+				return fmt.Errorf("usage file store: remove raw file: %w", errRemove)
+			}
+		}
+	}
+	if hourlyBefore.IsZero() {
+		return nil
+	}
+	removed := false
+	for key := range s.hourly {
+		if key.Start.Before(hourlyBefore) {
+			delete(s.hourly, key)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return s.saveRollups(s.hourly, s.daily)
+}
+
+// Close implements Sink.
+func (s *FileSink) Close() error { return nil }
+
+func (s *FileSink) rawPath(day string) string {
+	return filepath.Join(s.dir, fileSinkRawDir, day+".jsonl")
+}
+
+// appendRaw appends rows to the day's raw file and returns the file's size before the append.
+// A failed append truncates the file back to that size.
+func (s *FileSink) appendRaw(day string, rows []Row) (int64, error) {
+	file, err := os.OpenFile(s.rawPath(day), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("usage file store: open raw file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return 0, fmt.Errorf("usage file store: stat raw file: %w", err)
+	}
+	size := info.Size()
+	fail := func(err error) (int64, error) {
+		_ = file.Close()
+		if errTruncate := s.truncateRaw(day, size); errTruncate != nil {
+			err = errors.Join(err, errTruncate)
+		}
+		return 0, err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, row := range rows {
+		if err = encoder.Encode(row); err != nil {
+			return fail(fmt.Errorf("usage file store: encode row: %w", err))
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		return fail(fmt.Errorf("usage file store: write raw file: %w", err))
+	}
+	if err = file.Close(); err != nil {
+		return fail(fmt.Errorf("usage file store: close raw file: %w", err))
+	}
+	return size, nil
+}
+
+func (s *FileSink) truncateRaw(day string, size int64) error {
+	if err := os.Truncate(s.rawPath(day), size); err != nil {
+		return fmt.Errorf("usage file store: roll back raw file: %w", err)
+	}
+	return nil
+}
+
+// readRaw returns the most recent limit rows in [from, to), oldest first.
+func (s *FileSink) readRaw(from, to time.Time, limit int) ([]Row, error) {
+	days, err := s.rawDays()
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	// Walk days newest first so only the files needed to satisfy limit are read.
+	for i := len(days) - 1; i >= 0 && len(rows) < limit; i-- {
+		start, errParse := time.Parse(fileSinkDayLayout, days[i])
+		if errParse != nil {
+			continue
+		}
+		if (!to.IsZero() && !start.Before(to)) || (!from.IsZero() && start.AddDate(0, 0, 1).Before(from)) {
+			continue
+		}
+		dayRows, errRead := readRawFile(s.rawPath(days[i]))
+		if errRead != nil {
+			return nil, errRead
+		}
+		for j := len(dayRows) - 1; j >= 0 && len(rows) < limit; j-- {
+			if inRange(dayRows[j].Timestamp, from, to) {
+				rows = append(rows, dayRows[j])
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Timestamp.Before(rows[j].Timestamp) })
+	return rows, nil
+}
+
+func readRawFile(path string) ([]Row, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage file store: open raw file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	var rows []Row
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var row Row
+		// A torn final line from a crash mid-append is skipped rather than failing the query.
+		if json.Unmarshal(line, &row) != nil {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("usage file store: read raw file: %w", err)
+	}
+	return rows, nil
+}
+
+// rawDays lists the days with raw files, oldest first.
+func (s *FileSink) rawDays() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, fileSinkRawDir))
+	if err != nil {
+		return nil, fmt.Errorf("usage file store: list raw files: %w", err)
+	}
+	days := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		days = append(days, strings.TrimSuffix(name, ".jsonl"))
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func (s *FileSink) saveRollups(hourly, daily map[BucketKey]*Bucket) error {
+	rollups := fileSinkRollups{
+		Hourly: make([]Bucket, 0, len(hourly)),
+		Daily:  make([]Bucket, 0, len(daily)),
+	}
+	for _, bucket := range hourly {
+		rollups.Hourly = append(rollups.Hourly, *bucket)
+	}
+	for _, bucket := range daily {
+		rollups.Daily = append(rollups.Daily, *bucket)
+	}
+	SortBuckets(rollups.Hourly)
+	SortBuckets(rollups.Daily)
+	data, err := json.Marshal(rollups)
+	if err != nil {
+		return fmt.Errorf("usage file store: encode rollups: %w", err)
+	}
+	path := filepath.Join(s.dir, fileSinkRollupsFile)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("usage file store: write rollups: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("usage file store: rename rollups: %w", err)
+	}
+	return nil
+}
+
+// mergeBuckets merges src into dst. Existing buckets are replaced by merged copies rather than
+// mutated, so dst may be a shallow clone of a map that must stay unchanged.
+func mergeBuckets(dst, src map[BucketKey]*Bucket) {
+	for key, bucket := range src {
+		if existing, ok := dst[key]; ok {
+			merged := *existing
+			merged.Merge(*bucket)
+			dst[key] = &merged
+			continue
+		}
+		dst[key] = bucket
+	}
+}
+
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && !t.Before(to) {
+		return false
+	}
+	return true
+}
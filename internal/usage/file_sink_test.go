@@ -0,0 +1,128 @@
+package usage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSink_RollupsSurviveReopenAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+
+	day1 := time.Date(2026, 3, 1, 10, 15, 0, 0, time.UTC)
+	day2 := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	rows := []Row{
+		{Timestamp: day1, APIKey: "team-a", Model: "gpt-4o", Provider: "openai", Tokens: TokenStats{TotalTokens: 10}},
+		{Timestamp: day1.Add(20 * time.Minute), APIKey: "team-a", Model: "gpt-4o", Provider: "openai", Failed: true, Tokens: TokenStats{TotalTokens: 5}},
+		{Timestamp: day1.Add(2 * time.Hour), APIKey: "team-a", Model: "gpt-4o", Provider: "openai", Tokens: TokenStats{TotalTokens: 7}},
+		{Timestamp: day2, APIKey: "team-b", Model: "claude", Provider: "claude", Tokens: TokenStats{TotalTokens: 3}},
+	}
+	if err = sink.Write(ctx, rows); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	sink, err = NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	result, err := sink.Query(ctx, Query{Granularity: GranularityHour, DetailLimit: 2})
+	if err != nil {
+		t.Fatalf("Query hourly: %v", err)
+	}
+	if len(result.Buckets) != 3 {
+		t.Fatalf("hourly buckets = %d, want 3", len(result.Buckets))
+	}
+	first := result.Buckets[0]
+	if first.Requests != 2 || first.Failures != 1 || first.Tokens.TotalTokens != 15 {
+		t.Fatalf("first hourly bucket = %+v", first)
+	}
+	if len(result.Details) != 2 || !result.Details[1].Timestamp.Equal(day2) {
+		t.Fatalf("details = %+v, want the two most recent rows", result.Details)
+	}
+
+	result, err = sink.Query(ctx, Query{From: BucketStart(day1, GranularityDay), To: BucketStart(day2, GranularityDay), Granularity: GranularityDay})
+	if err != nil {
+		t.Fatalf("Query daily: %v", err)
+	}
+	if len(result.Buckets) != 1 || result.Buckets[0].Requests != 3 || result.Buckets[0].Tokens.TotalTokens != 22 {
+		t.Fatalf("daily buckets = %+v", result.Buckets)
+	}
+
+	if err = sink.Prune(ctx, day2.Add(48*time.Hour), day2); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	result, err = sink.Query(ctx, Query{Granularity: GranularityHour, DetailLimit: 10})
+	if err != nil {
+		t.Fatalf("Query after prune: %v", err)
+	}
+	if len(result.Buckets) != 1 || len(result.Details) != 0 {
+		t.Fatalf("after prune buckets = %d details = %d, want 1 and 0", len(result.Buckets), len(result.Details))
+	}
+	result, err = sink.Query(ctx, Query{Granularity: GranularityDay})
+	if err != nil {
+		t.Fatalf("Query daily after prune: %v", err)
+	}
+	if len(result.Buckets) != 2 {
+		t.Fatalf("daily buckets after prune = %d, want 2", len(result.Buckets))
+	}
+
+	snapshot := SnapshotFromQuery(result, GranularityDay)
+	if snapshot.TotalRequests != 4 || snapshot.FailureCount != 1 || snapshot.APIs["team-a"].Models["gpt-4o"].TotalTokens != 22 {
+		t.Fatalf("snapshot = %+v", snapshot)
+	}
+}
+
+func TestFileSink_FailedWriteRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	sink, err := NewFileSink(dir)
+	if err != nil {
+		t.Fatalf("NewFileSink: %v", err)
+	}
+	ts := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	first := []Row{{Timestamp: ts, APIKey: "team-a", Model: "gpt-4o", Tokens: TokenStats{TotalTokens: 10}}}
+	if err = sink.Write(ctx, first); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	// A non-empty directory in place of rollups.json makes the rollup rename fail.
+	rollups := filepath.Join(dir, fileSinkRollupsFile)
+	if err = os.Remove(rollups); err != nil {
+		t.Fatalf("remove rollups: %v", err)
+	}
+	if err = os.MkdirAll(filepath.Join(rollups, "blocker"), 0o700); err != nil {
+		t.Fatalf("block rollups: %v", err)
+	}
+	batch := []Row{
+		{Timestamp: ts.Add(time.Minute), APIKey: "team-a", Model: "gpt-4o", Tokens: TokenStats{TotalTokens: 5}},
+		{Timestamp: ts.AddDate(0, 0, 1), APIKey: "team-b", Model: "claude", Tokens: TokenStats{TotalTokens: 3}},
+	}
+	if err = sink.Write(ctx, batch); err == nil {
+		t.Fatalf("expected the write to fail while rollups.json is blocked")
+	}
+	if err = os.RemoveAll(rollups); err != nil {
+		t.Fatalf("unblock rollups: %v", err)
+	}
+	// The caller retries the whole batch; nothing may be counted twice.
+	if err = sink.Write(ctx, batch); err != nil {
+		t.Fatalf("retry Write: %v", err)
+	}
+
+	result, err := sink.Query(ctx, Query{Granularity: GranularityHour, DetailLimit: 10})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(result.Details) != 3 {
+		t.Fatalf("raw rows = %d, want 3", len(result.Details))
+	}
+	if len(result.Buckets) != 2 || result.Buckets[0].Requests != 2 || result.Buckets[0].Tokens.TotalTokens != 15 {
+		t.Fatalf("hourly buckets = %+v", result.Buckets)
+	}
+}
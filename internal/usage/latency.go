@@ -0,0 +1,115 @@
+package usage
+
+import (
+	"math"
+	"sort"
+)
+
+// Percentiles holds latency percentiles in milliseconds.
+type Percentiles struct {
+	P50 int64 `json:"p50"`
+	P95 int64 `json:"p95"`
+	P99 int64 `json:"p99"`
+}
+
+// LatencySummary describes the timings of the requests that reported them. Each percentile set
+// only covers the requests where that timing was measured.
+type LatencySummary struct {
+	Requests          int64       `json:"requests"`
+	Retries           int64       `json:"retries"`
+	UpstreamFirstByte Percentiles `json:"upstream_first_byte_ms"`
+	ClientFirstChunk  Percentiles `json:"client_first_chunk_ms"`
+	Duration          Percentiles `json:"duration_ms"`
+}
+
+type latencySamples struct {
+	requests int64
+	retries  int64
+	upstream []int64
+	client   []int64
+	duration []int64
+}
+
+func (s *latencySamples) add(detail RequestDetail) {
+	s.requests++
+	s.retries += int64(detail.Retries)
+	if detail.UpstreamFirstByteMs > 0 {
+		s.upstream = append(s.upstream, detail.UpstreamFirstByteMs)
+	}
+	if detail.ClientFirstChunkMs > 0 {
+		s.client = append(s.client, detail.ClientFirstChunkMs)
+	}
+	s.duration = append(s.duration, detail.DurationMs)
+}
+
+func (s *latencySamples) summary() LatencySummary {
+	return LatencySummary{
+		Requests:          s.requests,
+		Retries:           s.retries,
+		UpstreamFirstByte: percentilesOf(s.upstream),
+		ClientFirstChunk:  percentilesOf(s.client),
+		Duration:          percentilesOf(s.duration),
+	}
+}
+
+// latencyAggregator collects request timings per model and per provider.
+type latencyAggregator struct {
+	byModel    map[string]*latencySamples
+	byProvider map[string]*latencySamples
+}
+
+func newLatencyAggregator() *latencyAggregator {
+	return &latencyAggregator{
+		byModel:    make(map[string]*latencySamples),
+		byProvider: make(map[string]*latencySamples),
+	}
+}
+
+// add folds in a request of model. Requests recorded without timings are skipped.
+func (a *latencyAggregator) add(model string, detail RequestDetail) {
+	if detail.DurationMs <= 0 {
+		return
+	}
+	provider := detail.Provider
+	if provider == "" {
+		provider = "unknown"
+	}
+	samplesFor(a.byModel, model).add(detail)
+	samplesFor(a.byProvider, provider).add(detail)
+}
+
+func samplesFor(groups map[string]*latencySamples, key string) *latencySamples {
+	samples, ok := groups[key]
+	if !ok {
+		samples = &latencySamples{}
+		groups[key] = samples
+	}
+	return samples
+}
+
+func (a *latencyAggregator) apply(snapshot *StatisticsSnapshot) {
+	snapshot.LatencyByModel = make(map[string]LatencySummary, len(a.byModel))
+	for model, samples := range a.byModel {
+		snapshot.LatencyByModel[model] = samples.summary()
+	}
+	snapshot.LatencyByProvider = make(map[string]LatencySummary, len(a.byProvider))
+	for provider, samples := range a.byProvider {
+		snapshot.LatencyByProvider[provider] = samples.summary()
+	}
+}
+
+// percentilesOf returns the nearest-rank percentiles of values, sorting them in place.
+func percentilesOf(values []int64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	rank := func(p float64) int64 {
+		idx := int(math.Ceil(p*float64(len(values)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return values[idx]
+	}
+	return Percentiles{P50: rank(0.50), P95: rank(0.95), P99: rank(0.99)}
+}
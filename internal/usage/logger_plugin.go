@@ -46,6 +46,10 @@ func (p *LoggerPlugin) HandleUsage(ctx context.Context, record coreusage.Record)
 	if p == nil || p.stats == nil {
 		return
 	}
+	// A persistent sink supersedes the in-memory history.
+	if PersistentSink() != nil {
+		return
+	}
 	p.stats.Record(ctx, record)
 }
 
@@ -55,6 +59,25 @@ func SetStatisticsEnabled(enabled bool) { statisticsEnabled.Store(enabled) }
 // StatisticsEnabled reports the current recording state.
 func StatisticsEnabled() bool { return statisticsEnabled.Load() }
 
+var apiKeyLabeler atomic.Pointer[func(string) string]
+
+// SetAPIKeyLabeler installs a function mapping client principals to the label statistics are
+// grouped under, such as the name of a virtual key. Nil restores the raw principal.
+func SetAPIKeyLabeler(labeler func(string) string) {
+	if labeler == nil {
+		apiKeyLabeler.Store(nil)
+		return
+	}
+	apiKeyLabeler.Store(&labeler)
+}
+
+func labelAPIKey(key string) string {
+	if labeler := apiKeyLabeler.Load(); labeler != nil && key != "" {
+		return (*labeler)(key)
+	}
+	return key
+}
+
 // RequestStatistics maintains aggregated request metrics in memory.
 type RequestStatistics struct {
 	mu sync.RWMutex
@@ -63,9 +86,13 @@ type RequestStatistics struct {
 	successCount  int64
 	failureCount  int64
 	totalTokens   int64
+	totalCost     float64
 
 	apis map[string]*apiStats
 
+	costByModel      map[string]float64
+	costByCredential map[string]float64
+
 	requestsByDay  map[string]int64
 	requestsByHour map[int]int64
 	tokensByDay    map[string]int64
@@ -76,6 +103,7 @@ type RequestStatistics struct {
 type apiStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Models        map[string]*modelStats
 }
 
@@ -83,16 +111,30 @@ type apiStats struct {
 type modelStats struct {
 	TotalRequests int64
 	TotalTokens   int64
+	TotalCost     float64
 	Details       []RequestDetail
 }
 
 // RequestDetail stores the timestamp and token usage for a single request.
 type RequestDetail struct {
-	Timestamp time.Time  `json:"timestamp"`
-	Source    string     `json:"source"`
-	AuthIndex uint64     `json:"auth_index"`
-	Tokens    TokenStats `json:"tokens"`
-	Failed    bool       `json:"failed"`
+	Timestamp      time.Time  `json:"timestamp"`
+	Source         string     `json:"source"`
+	AuthIndex      uint64     `json:"auth_index"`
+	AuthID         string     `json:"auth_id,omitempty"`
+	Provider       string     `json:"provider,omitempty"`
+	RequestedModel string     `json:"requested_model,omitempty"`
+	Tokens         TokenStats `json:"tokens"`
+	// Cost is the price of the request under the pricing table in force when it was recorded.
+	Cost        float64 `json:"cost,omitempty"`
+	Failed      bool    `json:"failed"`
+	QueueDepth  int     `json:"queue_depth,omitempty"`
+	QueueWaitMs int64   `json:"queue_wait_ms,omitempty"`
+	// Timings in milliseconds; see coreusage.Record. Zero when not measured.
+	UpstreamFirstByteMs int64 `json:"upstream_first_byte_ms,omitempty"`
+	ClientFirstChunkMs  int64 `json:"client_first_chunk_ms,omitempty"`
+	DurationMs          int64 `json:"duration_ms,omitempty"`
+	Retries             int   `json:"retries,omitempty"`
+	CredentialsTried    int   `json:"credentials_tried,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -110,9 +152,17 @@ type StatisticsSnapshot struct {
 	SuccessCount  int64 `json:"success_count"`
 	FailureCount  int64 `json:"failure_count"`
 	TotalTokens   int64 `json:"total_tokens"`
+	// TotalCost is the priced cost of all requests; see the pricing config.
+	TotalCost float64 `json:"total_cost"`
 
 	APIs map[string]APISnapshot `json:"apis"`
 
+	CostByModel      map[string]float64 `json:"cost_by_model"`
+	CostByCredential map[string]float64 `json:"cost_by_credential"`
+
+	LatencyByModel    map[string]LatencySummary `json:"latency_by_model"`
+	LatencyByProvider map[string]LatencySummary `json:"latency_by_provider"`
+
 	RequestsByDay  map[string]int64 `json:"requests_by_day"`
 	RequestsByHour map[string]int64 `json:"requests_by_hour"`
 	TokensByDay    map[string]int64 `json:"tokens_by_day"`
@@ -123,6 +173,7 @@ type StatisticsSnapshot struct {
 type APISnapshot struct {
 	TotalRequests int64                    `json:"total_requests"`
 	TotalTokens   int64                    `json:"total_tokens"`
+	TotalCost     float64                  `json:"total_cost"`
 	Models        map[string]ModelSnapshot `json:"models"`
 }
 
@@ -130,6 +181,7 @@ type APISnapshot struct {
 type ModelSnapshot struct {
 	TotalRequests int64           `json:"total_requests"`
 	TotalTokens   int64           `json:"total_tokens"`
+	TotalCost     float64         `json:"total_cost"`
 	Details       []RequestDetail `json:"details"`
 }
 
@@ -141,11 +193,13 @@ func GetRequestStatistics() *RequestStatistics { return defaultRequestStatistics
 // NewRequestStatistics constructs an empty statistics store.
 func NewRequestStatistics() *RequestStatistics {
 	return &RequestStatistics{
-		apis:           make(map[string]*apiStats),
-		requestsByDay:  make(map[string]int64),
-		requestsByHour: make(map[int]int64),
-		tokensByDay:    make(map[string]int64),
-		tokensByHour:   make(map[int]int64),
+		apis:             make(map[string]*apiStats),
+		costByModel:      make(map[string]float64),
+		costByCredential: make(map[string]float64),
+		requestsByDay:    make(map[string]int64),
+		requestsByHour:   make(map[int]int64),
+		tokensByDay:      make(map[string]int64),
+		tokensByHour:     make(map[int]int64),
 	}
 }
 
@@ -157,25 +211,12 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	if !statisticsEnabled.Load() {
 		return
 	}
-	timestamp := record.RequestedAt
-	if timestamp.IsZero() {
-		timestamp = time.Now()
-	}
-	detail := normaliseDetail(record.Detail)
-	totalTokens := detail.TotalTokens
-	statsKey := record.APIKey
-	if statsKey == "" {
-		statsKey = resolveAPIIdentifier(ctx, record)
-	}
-	failed := record.Failed
-	if !failed {
-		failed = !resolveSuccess(ctx)
-	}
-	success := !failed
-	modelName := record.Model
-	if modelName == "" {
-		modelName = "unknown"
-	}
+	detail := requestDetailFromRecord(ctx, record)
+	timestamp := detail.Timestamp
+	totalTokens := detail.Tokens.TotalTokens
+	statsKey := statsKeyForRecord(ctx, record)
+	success := !detail.Failed
+	modelName := modelForRecord(record)
 	dayKey := timestamp.Format("2006-01-02")
 	hourKey := timestamp.Hour()
 
@@ -189,19 +230,20 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.failureCount++
 	}
 	s.totalTokens += totalTokens
+	s.totalCost += detail.Cost
+	if detail.Cost != 0 {
+		s.costByModel[modelName] += detail.Cost
+		if detail.AuthID != "" {
+			s.costByCredential[detail.AuthID] += detail.Cost
+		}
+	}
 
 	stats, ok := s.apis[statsKey]
 	if !ok {
 		stats = &apiStats{Models: make(map[string]*modelStats)}
 		s.apis[statsKey] = stats
 	}
-	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp: timestamp,
-		Source:    record.Source,
-		AuthIndex: record.AuthIndex,
-		Tokens:    detail,
-		Failed:    failed,
-	})
+	s.updateAPIStats(stats, modelName, detail)
 
 	s.requestsByDay[dayKey]++
 	s.requestsByHour[hourKey]++
@@ -212,6 +254,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail RequestDetail) {
 	stats.TotalRequests++
 	stats.TotalTokens += detail.Tokens.TotalTokens
+	stats.TotalCost += detail.Cost
 	modelStatsValue, ok := stats.Models[model]
 	if !ok {
 		modelStatsValue = &modelStats{}
@@ -219,6 +262,7 @@ func (s *RequestStatistics) updateAPIStats(stats *apiStats, model string, detail
 	}
 	modelStatsValue.TotalRequests++
 	modelStatsValue.TotalTokens += detail.Tokens.TotalTokens
+	modelStatsValue.TotalCost += detail.Cost
 	modelStatsValue.Details = append(modelStatsValue.Details, detail)
 }
 
@@ -236,26 +280,44 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	result.SuccessCount = s.successCount
 	result.FailureCount = s.failureCount
 	result.TotalTokens = s.totalTokens
+	result.TotalCost = s.totalCost
 
+	latency := newLatencyAggregator()
 	result.APIs = make(map[string]APISnapshot, len(s.apis))
 	for apiName, stats := range s.apis {
 		apiSnapshot := APISnapshot{
 			TotalRequests: stats.TotalRequests,
 			TotalTokens:   stats.TotalTokens,
+			TotalCost:     stats.TotalCost,
 			Models:        make(map[string]ModelSnapshot, len(stats.Models)),
 		}
 		for modelName, modelStatsValue := range stats.Models {
 			requestDetails := make([]RequestDetail, len(modelStatsValue.Details))
 			copy(requestDetails, modelStatsValue.Details)
+			for _, detail := range requestDetails {
+				latency.add(modelName, detail)
+			}
 			apiSnapshot.Models[modelName] = ModelSnapshot{
 				TotalRequests: modelStatsValue.TotalRequests,
 				TotalTokens:   modelStatsValue.TotalTokens,
+				TotalCost:     modelStatsValue.TotalCost,
 				Details:       requestDetails,
 			}
 		}
 		result.APIs[apiName] = apiSnapshot
 	}
 
+	latency.apply(&result)
+
+	result.CostByModel = make(map[string]float64, len(s.costByModel))
+	for k, v := range s.costByModel {
+		result.CostByModel[k] = v
+	}
+	result.CostByCredential = make(map[string]float64, len(s.costByCredential))
+	for k, v := range s.costByCredential {
+		result.CostByCredential[k] = v
+	}
+
 	result.RequestsByDay = make(map[string]int64, len(s.requestsByDay))
 	for k, v := range s.requestsByDay {
 		result.RequestsByDay[k] = v
@@ -281,7 +343,58 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	return result
 }
 
+// requestDetailFromRecord resolves the timestamp, tokens and outcome of a usage record.
+func requestDetailFromRecord(ctx context.Context, record coreusage.Record) RequestDetail {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	failed := record.Failed
+	if !failed {
+		failed = !resolveSuccess(ctx)
+	}
+	tokens := normaliseDetail(record.Detail)
+	return RequestDetail{
+		Timestamp:      timestamp,
+		Source:         record.Source,
+		AuthIndex:      record.AuthIndex,
+		AuthID:         record.AuthID,
+		Provider:       record.Provider,
+		RequestedModel: record.RequestedModel,
+		Tokens:         tokens,
+		Cost:           costForRecord(record.Provider, record.Model, tokens),
+		Failed:         failed,
+		QueueDepth:     record.QueueDepth,
+		QueueWaitMs:    record.QueueWait.Milliseconds(),
+
+		UpstreamFirstByteMs: record.UpstreamFirstByte.Milliseconds(),
+		ClientFirstChunkMs:  record.ClientFirstChunk.Milliseconds(),
+		DurationMs:          record.Duration.Milliseconds(),
+		Retries:             record.Retries,
+		CredentialsTried:    record.CredentialsTried,
+	}
+}
+
+// statsKeyForRecord returns the label a record is grouped under: the labelled client key, or
+// the route when the request carried none.
+func statsKeyForRecord(ctx context.Context, record coreusage.Record) string {
+	if key := labelAPIKey(record.APIKey); key != "" {
+		return key
+	}
+	return resolveAPIIdentifier(ctx, record)
+}
+
+func modelForRecord(record coreusage.Record) string {
+	if record.Model == "" {
+		return "unknown"
+	}
+	return record.Model
+}
+
 func resolveAPIIdentifier(ctx context.Context, record coreusage.Record) string {
+	if record.Endpoint != "" {
+		return record.Endpoint
+	}
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
 			path := ginCtx.FullPath()
@@ -0,0 +1,515 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Rollup granularities kept by persistent sinks.
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+// Default retention applied by persistent sinks.
+const (
+	DefaultRawRetention    = 7 * 24 * time.Hour
+	DefaultHourlyRetention = 90 * 24 * time.Hour
+)
+
+const (
+	persistFlushInterval = 2 * time.Second
+	persistPruneInterval = time.Hour
+	persistMaxPending    = 10000
+)
+
+// Row is a single request as stored by a persistent sink.
+type Row struct {
+	Timestamp      time.Time  `json:"timestamp"`
+	APIKey         string     `json:"api_key"`
+	Model          string     `json:"model"`
+	RequestedModel string     `json:"requested_model,omitempty"`
+	Provider       string     `json:"provider,omitempty"`
+	AuthID         string     `json:"auth_id,omitempty"`
+	AuthIndex      uint64     `json:"auth_index"`
+	Source         string     `json:"source,omitempty"`
+	Failed         bool       `json:"failed"`
+	Tokens         TokenStats `json:"tokens"`
+	Cost           float64    `json:"cost,omitempty"`
+	QueueDepth     int        `json:"queue_depth,omitempty"`
+	QueueWaitMs    int64      `json:"queue_wait_ms,omitempty"`
+
+	UpstreamFirstByteMs int64 `json:"upstream_first_byte_ms,omitempty"`
+	ClientFirstChunkMs  int64 `json:"client_first_chunk_ms,omitempty"`
+	DurationMs          int64 `json:"duration_ms,omitempty"`
+	Retries             int   `json:"retries,omitempty"`
+	CredentialsTried    int   `json:"credentials_tried,omitempty"`
+}
+
+// Bucket aggregates the requests of one client key, model, provider and credential over an
+// hour or a day.
+type Bucket struct {
+	Start    time.Time  `json:"start"`
+	APIKey   string     `json:"api_key"`
+	Model    string     `json:"model"`
+	Provider string     `json:"provider,omitempty"`
+	AuthID   string     `json:"auth_id,omitempty"`
+	Requests int64      `json:"requests"`
+	Failures int64      `json:"failures"`
+	Tokens   TokenStats `json:"tokens"`
+	Cost     float64    `json:"cost"`
+}
+
+// BucketKey identifies the rollup bucket a row belongs to.
+type BucketKey struct {
+	Start    time.Time
+	APIKey   string
+	Model    string
+	Provider string
+	AuthID   string
+}
+
+// Key returns the bucket's identity.
+func (b Bucket) Key() BucketKey {
+	return BucketKey{Start: b.Start, APIKey: b.APIKey, Model: b.Model, Provider: b.Provider, AuthID: b.AuthID}
+}
+
+// Add folds a row into the bucket.
+func (b *Bucket) Add(row Row) {
+	b.Requests++
+	if row.Failed {
+		b.Failures++
+	}
+	b.Tokens.InputTokens += row.Tokens.InputTokens
+	b.Tokens.OutputTokens += row.Tokens.OutputTokens
+	b.Tokens.ReasoningTokens += row.Tokens.ReasoningTokens
+	b.Tokens.CachedTokens += row.Tokens.CachedTokens
+	b.Tokens.TotalTokens += row.Tokens.TotalTokens
+	b.Cost += row.Cost
+}
+
+// Merge adds the counters of other into the bucket.
+func (b *Bucket) Merge(other Bucket) {
+	b.Requests += other.Requests
+	b.Failures += other.Failures
+	b.Tokens.InputTokens += other.Tokens.InputTokens
+	b.Tokens.OutputTokens += other.Tokens.OutputTokens
+	b.Tokens.ReasoningTokens += other.Tokens.ReasoningTokens
+	b.Tokens.CachedTokens += other.Tokens.CachedTokens
+	b.Tokens.TotalTokens += other.Tokens.TotalTokens
+	b.Cost += other.Cost
+}
+
+// BucketStart truncates t to the start of its UTC hour or day.
+func BucketStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	if granularity == GranularityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// RollupRows aggregates rows into buckets of the given granularity, keyed by bucket identity.
+func RollupRows(rows []Row, granularity string) map[BucketKey]*Bucket {
+	out := make(map[BucketKey]*Bucket)
+	for _, row := range rows {
+		key := BucketKey{
+			Start:    BucketStart(row.Timestamp, granularity),
+			APIKey:   row.APIKey,
+			Model:    row.Model,
+			Provider: row.Provider,
+			AuthID:   row.AuthID,
+		}
+		bucket, ok := out[key]
+		if !ok {
+			bucket = &Bucket{Start: key.Start, APIKey: key.APIKey, Model: key.Model, Provider: key.Provider, AuthID: key.AuthID}
+			out[key] = bucket
+		}
+		bucket.Add(row)
+	}
+	return out
+}
+
+// SortBuckets orders buckets by start time, then key, model, provider and credential.
+func SortBuckets(buckets []Bucket) {
+	sort.Slice(buckets, func(i, j int) bool {
+		a, b := buckets[i], buckets[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.APIKey != b.APIKey {
+			return a.APIKey < b.APIKey
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.AuthID < b.AuthID
+	})
+}
+
+// Query selects persisted usage in the half-open range [From, To). Rollups are selected by
+// their start time.
+type Query struct {
+	From        time.Time
+	To          time.Time
+	Granularity string
+	// DetailLimit caps the raw rows returned, keeping the most recent. Zero returns none.
+	DetailLimit int
+}
+
+// QueryResult holds the rollups and raw rows matching a Query.
+type QueryResult struct {
+	Buckets []Bucket
+	Details []Row
+}
+
+// Sink persists usage rows and maintains their hourly and daily rollups.
+type Sink interface {
+	// Write appends rows and folds them into the rollups. A failed Write must leave no rows
+	// behind, since the caller retries the whole batch.
+	Write(ctx context.Context, rows []Row) error
+	// Query returns the rollups of the requested granularity and the raw rows in range.
+	Query(ctx context.Context, q Query) (QueryResult, error)
+	// Prune drops raw rows older than rawBefore and hourly rollups older than hourlyBefore.
+	// Zero times keep the corresponding data.
+	Prune(ctx context.Context, rawBefore, hourlyBefore time.Time) error
+	// Close releases the sink.
+	Close() error
+}
+
+// Retention bounds how long a persistent sink keeps raw rows and hourly rollups. Zero or
+// negative durations keep data forever; daily rollups are always kept.
+type Retention struct {
+	Raw    time.Duration
+	Hourly time.Duration
+}
+
+// persistentPlugin buffers usage records and writes them to the installed sink in batches.
+type persistentPlugin struct {
+	mu        sync.Mutex
+	sink      Sink
+	retention Retention
+	pending   []Row
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+var defaultPersistent = &persistentPlugin{}
+
+func init() {
+	coreusage.RegisterPlugin(defaultPersistent)
+}
+
+// SetSink installs the persistent sink usage records are written to and starts the background
+// writer. While a sink is installed the in-memory statistics are not recorded, so history no
+// longer grows in memory. Installing nil closes the current sink.
+func SetSink(sink Sink, retention Retention) {
+	defaultPersistent.install(sink, retention)
+}
+
+// PersistentSink returns the installed sink, or nil when statistics are kept in memory.
+func PersistentSink() Sink {
+	defaultPersistent.mu.Lock()
+	defer defaultPersistent.mu.Unlock()
+	return defaultPersistent.sink
+}
+
+// CloseSink flushes pending records and closes the installed sink.
+func CloseSink(ctx context.Context) error {
+	return defaultPersistent.close(ctx)
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p *persistentPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if !statisticsEnabled.Load() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sink == nil {
+		return
+	}
+	if len(p.pending) >= persistMaxPending {
+		log.Warnf("usage store: dropping usage record, %d records pending", len(p.pending))
+		return
+	}
+	p.pending = append(p.pending, rowFromRecord(ctx, record))
+}
+
+func (p *persistentPlugin) install(sink Sink, retention Retention) {
+	_ = p.close(context.Background())
+	if sink == nil {
+		return
+	}
+	p.mu.Lock()
+	p.sink = sink
+	p.retention = retention
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+	go p.run(sink, stop, done)
+}
+
+func (p *persistentPlugin) run(sink Sink, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	flush := time.NewTicker(persistFlushInterval)
+	defer flush.Stop()
+	prune := time.NewTicker(persistPruneInterval)
+	defer prune.Stop()
+	p.prune(sink)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-flush.C:
+			if err := p.flush(context.Background(), sink); err != nil {
+				log.Warnf("usage store: %v", err)
+			}
+		case <-prune.C:
+			p.prune(sink)
+		}
+	}
+}
+
+// flush writes pending rows. Rows are put back when the write fails so a transient outage does
+// not lose them.
+func (p *persistentPlugin) flush(ctx context.Context, sink Sink) error {
+	p.mu.Lock()
+	rows := p.pending
+	p.pending = nil
+	p.mu.Unlock()
+	if len(rows) == 0 {
+		return nil
+	}
+	if err := sink.Write(ctx, rows); err != nil {
+		p.mu.Lock()
+		if len(p.pending)+len(rows) <= persistMaxPending {
+			p.pending = append(rows, p.pending...)
+		}
+		p.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (p *persistentPlugin) prune(sink Sink) {
+	p.mu.Lock()
+	retention := p.retention
+	p.mu.Unlock()
+	now := time.Now()
+	var rawBefore, hourlyBefore time.Time
+	if retention.Raw > 0 {
+		rawBefore = now.Add(-retention.Raw)
+	}
+	if retention.Hourly > 0 {
+		hourlyBefore = now.Add(-retention.Hourly)
+	}
+	if rawBefore.IsZero() && hourlyBefore.IsZero() {
+		return
+	}
+	if err := sink.Prune(context.Background(), rawBefore, hourlyBefore); err != nil {
+		log.Warnf("usage store: prune: %v", err)
+	}
+}
+
+func (p *persistentPlugin) close(ctx context.Context) error {
+	p.mu.Lock()
+	sink, stop, done := p.sink, p.stop, p.done
+	p.sink, p.stop, p.done = nil, nil, nil
+	p.mu.Unlock()
+	if sink == nil {
+		return nil
+	}
+	close(stop)
+	<-done
+	errFlush := p.flush(ctx, sink)
+	p.mu.Lock()
+	p.pending = nil
+	p.mu.Unlock()
+	if errClose := sink.Close(); errFlush == nil {
+		errFlush = errClose
+	}
+	return errFlush
+}
+
+// rowFromRecord resolves a usage record into the row persisted for it, using the same client
+// key label, model and failure resolution as the in-memory statistics.
+func rowFromRecord(ctx context.Context, record coreusage.Record) Row {
+	detail := requestDetailFromRecord(ctx, record)
+	return Row{
+		Timestamp:      detail.Timestamp.UTC(),
+		APIKey:         statsKeyForRecord(ctx, record),
+		Model:          modelForRecord(record),
+		RequestedModel: record.RequestedModel,
+		Provider:       record.Provider,
+		AuthID:         record.AuthID,
+		AuthIndex:      record.AuthIndex,
+		Source:         record.Source,
+		Failed:         detail.Failed,
+		Tokens:         detail.Tokens,
+		Cost:           detail.Cost,
+		QueueDepth:     record.QueueDepth,
+		QueueWaitMs:    detail.QueueWaitMs,
+
+		UpstreamFirstByteMs: detail.UpstreamFirstByteMs,
+		ClientFirstChunkMs:  detail.ClientFirstChunkMs,
+		DurationMs:          detail.DurationMs,
+		Retries:             detail.Retries,
+		CredentialsTried:    detail.CredentialsTried,
+	}
+}
+
+// SnapshotFromQuery builds a statistics snapshot from persisted rollups and raw rows, so
+// persistent sinks serve the same shape as the in-memory statistics. Totals come from the
+// rollups; per-model details and latency percentiles only cover the returned raw rows.
+func SnapshotFromQuery(result QueryResult, granularity string) StatisticsSnapshot {
+	snapshot := newSnapshot()
+	for _, bucket := range result.Buckets {
+		snapshot.TotalRequests += bucket.Requests
+		snapshot.FailureCount += bucket.Failures
+		snapshot.SuccessCount += bucket.Requests - bucket.Failures
+		snapshot.TotalTokens += bucket.Tokens.TotalTokens
+		snapshot.addCost(bucket.Model, bucket.AuthID, bucket.Cost)
+
+		api := snapshot.APIs[bucket.APIKey]
+		if api.Models == nil {
+			api.Models = make(map[string]ModelSnapshot)
+		}
+		api.TotalRequests += bucket.Requests
+		api.TotalTokens += bucket.Tokens.TotalTokens
+		api.TotalCost += bucket.Cost
+		model := api.Models[bucket.Model]
+		model.TotalRequests += bucket.Requests
+		model.TotalTokens += bucket.Tokens.TotalTokens
+		model.TotalCost += bucket.Cost
+		api.Models[bucket.Model] = model
+		snapshot.APIs[bucket.APIKey] = api
+
+		dayKey := bucket.Start.UTC().Format("2006-01-02")
+		snapshot.RequestsByDay[dayKey] += bucket.Requests
+		snapshot.TokensByDay[dayKey] += bucket.Tokens.TotalTokens
+		if granularity != GranularityDay {
+			hourKey := formatHour(bucket.Start.UTC().Hour())
+			snapshot.RequestsByHour[hourKey] += bucket.Requests
+			snapshot.TokensByHour[hourKey] += bucket.Tokens.TotalTokens
+		}
+	}
+	latency := newLatencyAggregator()
+	for _, row := range result.Details {
+		api, ok := snapshot.APIs[row.APIKey]
+		if !ok {
+			continue
+		}
+		model := api.Models[row.Model]
+		detail := RequestDetail{
+			Timestamp:      row.Timestamp,
+			Source:         row.Source,
+			AuthIndex:      row.AuthIndex,
+			AuthID:         row.AuthID,
+			Provider:       row.Provider,
+			RequestedModel: row.RequestedModel,
+			Tokens:         row.Tokens,
+			Cost:           row.Cost,
+			Failed:         row.Failed,
+			QueueDepth:     row.QueueDepth,
+			QueueWaitMs:    row.QueueWaitMs,
+
+			UpstreamFirstByteMs: row.UpstreamFirstByteMs,
+			ClientFirstChunkMs:  row.ClientFirstChunkMs,
+			DurationMs:          row.DurationMs,
+			Retries:             row.Retries,
+			CredentialsTried:    row.CredentialsTried,
+		}
+		model.Details = append(model.Details, detail)
+		api.Models[row.Model] = model
+		latency.add(row.Model, detail)
+	}
+	latency.apply(&snapshot)
+	return snapshot
+}
+
+// FilterSnapshot restricts an in-memory snapshot to requests in [from, to), recomputing the
+// totals from the retained details. Zero bounds are open.
+func FilterSnapshot(snapshot StatisticsSnapshot, from, to time.Time) StatisticsSnapshot {
+	if from.IsZero() && to.IsZero() {
+		return snapshot
+	}
+	out := newSnapshot()
+	latency := newLatencyAggregator()
+	for apiName, api := range snapshot.APIs {
+		filteredAPI := APISnapshot{Models: make(map[string]ModelSnapshot)}
+		for modelName, model := range api.Models {
+			var filtered ModelSnapshot
+			for _, detail := range model.Details {
+				if (!from.IsZero() && detail.Timestamp.Before(from)) || (!to.IsZero() && !detail.Timestamp.Before(to)) {
+					continue
+				}
+				filtered.Details = append(filtered.Details, detail)
+				latency.add(modelName, detail)
+				filtered.TotalRequests++
+				filtered.TotalTokens += detail.Tokens.TotalTokens
+				filtered.TotalCost += detail.Cost
+				out.TotalRequests++
+				out.TotalTokens += detail.Tokens.TotalTokens
+				out.addCost(modelName, detail.AuthID, detail.Cost)
+				if detail.Failed {
+					out.FailureCount++
+				} else {
+					out.SuccessCount++
+				}
+				dayKey := detail.Timestamp.Format("2006-01-02")
+				hourKey := formatHour(detail.Timestamp.Hour())
+				out.RequestsByDay[dayKey]++
+				out.TokensByDay[dayKey] += detail.Tokens.TotalTokens
+				out.RequestsByHour[hourKey]++
+				out.TokensByHour[hourKey] += detail.Tokens.TotalTokens
+			}
+			if filtered.TotalRequests == 0 {
+				continue
+			}
+			filteredAPI.TotalRequests += filtered.TotalRequests
+			filteredAPI.TotalTokens += filtered.TotalTokens
+			filteredAPI.TotalCost += filtered.TotalCost
+			filteredAPI.Models[modelName] = filtered
+		}
+		if filteredAPI.TotalRequests > 0 {
+			out.APIs[apiName] = filteredAPI
+		}
+	}
+	latency.apply(&out)
+	return out
+}
+
+func newSnapshot() StatisticsSnapshot {
+	return StatisticsSnapshot{
+		APIs:              make(map[string]APISnapshot),
+		CostByModel:       make(map[string]float64),
+		CostByCredential:  make(map[string]float64),
+		LatencyByModel:    make(map[string]LatencySummary),
+		LatencyByProvider: make(map[string]LatencySummary),
+		RequestsByDay:     make(map[string]int64),
+		RequestsByHour:    make(map[string]int64),
+		TokensByDay:       make(map[string]int64),
+		TokensByHour:      make(map[string]int64),
+	}
+}
+
+func (s *StatisticsSnapshot) addCost(model, authID string, cost float64) {
+	if cost == 0 {
+		return
+	}
+	s.TotalCost += cost
+	s.CostByModel[model] += cost
+	if authID != "" {
+		s.CostByCredential[authID] += cost
+	}
+}
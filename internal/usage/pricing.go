@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/radityprtama/proxygate/v6/internal/util"
+)
+
+// tokensPerPriceUnit is the number of tokens a price applies to.
+const tokensPerPriceUnit = 1_000_000
+
+// Price holds per-million-token prices for a model.
+type Price struct {
+	Input  float64
+	Output float64
+	// CachedInput prices cache-read input tokens.
+	CachedInput float64
+	// Reasoning prices reasoning tokens.
+	Reasoning float64
+}
+
+// PriceRule prices the models matching Model, a '*' wildcard pattern, optionally restricted to
+// one provider.
+type PriceRule struct {
+	Model    string
+	Provider string
+	Price    Price
+}
+
+// Pricing resolves model prices from an ordered set of rules. A rule restricted to the
+// request's provider beats an unrestricted one; among those, an exact model name beats a
+// pattern, and a longer pattern beats a shorter one. Remaining ties go to the earlier rule.
+type Pricing struct {
+	rules []PriceRule
+}
+
+// NewPricing compiles a pricing table. Rules without a model pattern are ignored.
+func NewPricing(rules []PriceRule) *Pricing {
+	compiled := make([]PriceRule, 0, len(rules))
+	for _, rule := range rules {
+		rule.Model = strings.TrimSpace(rule.Model)
+		rule.Provider = strings.ToLower(strings.TrimSpace(rule.Provider))
+		if rule.Model == "" {
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+	return &Pricing{rules: compiled}
+}
+
+// Lookup returns the price of model when served by provider.
+func (p *Pricing) Lookup(provider, model string) (Price, bool) {
+	if p == nil || model == "" {
+		return Price{}, false
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	best := -1
+	bestScore := -1
+	for i, rule := range p.rules {
+		if rule.Provider != "" && rule.Provider != provider {
+			continue
+		}
+		if !util.MatchModelPattern(rule.Model, model) {
+			continue
+		}
+		score := len(rule.Model)
+		if !strings.Contains(rule.Model, "*") {
+			score += 1 << 16
+		}
+		if rule.Provider != "" {
+			score += 1 << 20
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return Price{}, false
+	}
+	return p.rules[best].Price, true
+}
+
+// Cost prices the tokens of one request. Providers report tokens differently: Claude reports
+// cache reads separately from input tokens, Gemini reports reasoning separately from output
+// tokens, and OpenAI-style providers include both in the input and output counts. Cost
+// normalises these so cached and reasoning tokens are charged once at their own price.
+func (p *Pricing) Cost(provider, model string, tokens TokenStats) (float64, bool) {
+	price, ok := p.Lookup(provider, model)
+	if !ok {
+		return 0, false
+	}
+	uncachedInput := tokens.InputTokens
+	if !cachedReportedSeparately(provider, tokens) {
+		uncachedInput -= tokens.CachedTokens
+	}
+	visibleOutput := tokens.OutputTokens
+	if !ReasoningReportedSeparately(provider) {
+		visibleOutput -= tokens.ReasoningTokens
+	}
+	if uncachedInput < 0 {
+		uncachedInput = 0
+	}
+	if visibleOutput < 0 {
+		visibleOutput = 0
+	}
+	cost := float64(uncachedInput)*price.Input +
+		float64(tokens.CachedTokens)*price.CachedInput +
+		float64(visibleOutput)*price.Output +
+		float64(tokens.ReasoningTokens)*price.Reasoning
+	return cost / tokensPerPriceUnit, true
+}
+
+func cachedReportedSeparately(provider string, tokens TokenStats) bool {
+	return strings.EqualFold(provider, "claude") || tokens.CachedTokens > tokens.InputTokens
+}
+
+// ReasoningReportedSeparately reports whether the provider counts reasoning tokens outside
+// OutputTokens. Gemini-family providers do; OpenAI-style providers include them in it.
+func ReasoningReportedSeparately(provider string) bool {
+	switch strings.ToLower(provider) {
+	case "gemini", "gemini-cli", "vertex", "aistudio", "antigravity":
+		return true
+	}
+	return false
+}
+
+var pricing atomic.Pointer[Pricing]
+
+// SetPricing installs the pricing table applied to new usage records. Nil disables costing.
+func SetPricing(table *Pricing) { pricing.Store(table) }
+
+// CurrentPricing returns the installed pricing table, or nil.
+func CurrentPricing() *Pricing { return pricing.Load() }
+
+// costForRecord prices a request with the installed table, returning 0 when the model has no
+// price.
+func costForRecord(provider, model string, tokens TokenStats) float64 {
+	cost, _ := pricing.Load().Cost(provider, model, tokens)
+	return cost
+}
@@ -0,0 +1,36 @@
+package usage
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPricing_LookupPrecedenceAndCachedCost(t *testing.T) {
+	pricing := NewPricing([]PriceRule{
+		{Model: "claude-*", Price: Price{Input: 1, Output: 1}},
+		{Model: "claude-sonnet-*", Price: Price{Input: 3, Output: 15, CachedInput: 0.3, Reasoning: 15}},
+		{Model: "gpt-4o", Price: Price{Input: 2.5, Output: 10, CachedInput: 1.25, Reasoning: 10}},
+		{Model: "gpt-*", Provider: "codex", Price: Price{Input: 0, Output: 0}},
+	})
+
+	if price, ok := pricing.Lookup("claude", "claude-sonnet-4"); !ok || price.Input != 3 {
+		t.Fatalf("longer pattern should win, got %+v ok=%v", price, ok)
+	}
+	if price, ok := pricing.Lookup("codex", "gpt-4o"); !ok || price.Input != 0 {
+		t.Fatalf("provider rule should win over exact name, got %+v ok=%v", price, ok)
+	}
+	if _, ok := pricing.Lookup("openai", "o3"); ok {
+		t.Fatal("unpriced model should not match")
+	}
+
+	// Claude reports cache reads separately from input tokens.
+	claude, _ := pricing.Cost("claude", "claude-sonnet-4", TokenStats{InputTokens: 1000, CachedTokens: 10000, OutputTokens: 500})
+	if want := (1000*3 + 10000*0.3 + 500*15) / 1e6; math.Abs(claude-want) > 1e-12 {
+		t.Fatalf("claude cost = %v, want %v", claude, want)
+	}
+	// OpenAI counts cached tokens in input and reasoning tokens in output.
+	openai, _ := pricing.Cost("openai", "gpt-4o", TokenStats{InputTokens: 1000, CachedTokens: 400, OutputTokens: 300, ReasoningTokens: 100})
+	if want := (600*2.5 + 400*1.25 + 200*10 + 100*10) / 1e6; math.Abs(openai-want) > 1e-12 {
+		t.Fatalf("openai cost = %v, want %v", openai, want)
+	}
+}
@@ -0,0 +1,100 @@
+package virtualkey
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+)
+
+// ProviderName identifies the virtual key access provider.
+const ProviderName = "virtual-keys"
+
+// Result.Metadata keys describing the authenticated virtual key.
+const (
+	MetadataKeyName = "key_name"
+	MetadataTags    = sdkaccess.MetadataTags
+)
+
+// provider authenticates requests against the virtual keys of a registry. It stays inactive
+// while the registry is empty so that it does not close an otherwise open proxy.
+type provider struct {
+	registry *Registry
+}
+
+var defaultProvider = &provider{registry: defaultRegistry}
+
+// AccessProvider returns the access provider backed by the default registry.
+func AccessProvider() sdkaccess.Provider {
+	return defaultProvider
+}
+
+func (p *provider) Identifier() string { return ProviderName }
+
+// Active implements sdkaccess.ConditionalProvider.
+func (p *provider) Active() bool {
+	return p.registry.Count() > 0
+}
+
+func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, error) {
+	candidates := []struct {
+		value  string
+		source string
+	}{
+		{bearerToken(r.Header.Get("Authorization")), "authorization"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+	}
+	if r.URL != nil {
+		query := r.URL.Query()
+		candidates = append(candidates,
+			struct{ value, source string }{query.Get("key"), "query-key"},
+			struct{ value, source string }{query.Get("auth_token"), "query-auth-token"},
+		)
+	}
+	supplied := false
+	for _, candidate := range candidates {
+		if candidate.value == "" {
+			continue
+		}
+		supplied = true
+		if !IsVirtualKey(candidate.value) {
+			continue
+		}
+		key, ok := p.registry.Authenticate(candidate.value)
+		if !ok {
+			return nil, sdkaccess.ErrInvalidCredential
+		}
+		metadata := map[string]string{
+			"source":        candidate.source,
+			MetadataKeyName: key.Name,
+		}
+		if len(key.Tags) > 0 {
+			metadata[MetadataTags] = strings.Join(key.Tags, ",")
+		}
+		if !key.Policy.Empty() {
+			metadata[sdkaccess.MetadataPolicy] = key.Policy.Encode()
+		}
+		return &sdkaccess.Result{
+			Provider:  ProviderName,
+			Principal: key.ID,
+			Metadata:  metadata,
+		}, nil
+	}
+	if !supplied {
+		return nil, sdkaccess.ErrNoCredentials
+	}
+	return nil, sdkaccess.ErrNotHandled
+}
+
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return header
+	}
+	if !strings.EqualFold(scheme, "bearer") {
+		return header
+	}
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,456 @@
+// Package virtualkey manages runtime-created client API keys. Each key carries a name, owner,
+// tags and an optional policy and token budget. Only a salted hash of the secret is kept; the
+// plaintext is returned once when the key is created. Keys are persisted as a state document
+// of the active auth store.
+package virtualkey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/radityprtama/proxygate/v6/internal/budget"
+	"github.com/radityprtama/proxygate/v6/internal/usage"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	coreauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// StateDocument is the StateStore document name holding virtual keys.
+const StateDocument = "virtual-keys"
+
+// keyPrefix starts every virtual key secret; the key ID follows so lookups need a single hash check.
+const keyPrefix = "sk-"
+
+// idPrefix starts every virtual key ID.
+const idPrefix = "vk-"
+
+// lastUsedFlushInterval throttles how often last-used timestamps are written to the store.
+const lastUsedFlushInterval = time.Minute
+
+// saveTimeout bounds a single background write.
+const saveTimeout = 30 * time.Second
+
+var (
+	// ErrNotFound is returned for unknown key IDs.
+	ErrNotFound = errors.New("virtual key not found")
+	// ErrInvalid is returned when a create or update request is malformed.
+	ErrInvalid = errors.New("invalid virtual key")
+)
+
+func init() {
+	usage.SetAPIKeyLabeler(defaultRegistry.Label)
+}
+
+// Key describes a virtual key. The secret itself is never part of it.
+type Key struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Owner      string              `json:"owner,omitempty"`
+	Tags       []string            `json:"tags,omitempty"`
+	Hint       string              `json:"hint"`
+	CreatedAt  time.Time           `json:"created_at"`
+	LastUsedAt time.Time           `json:"last_used_at,omitempty"`
+	Policy     sdkaccess.KeyPolicy `json:"policy,omitempty"`
+	Budget     *budget.Budget      `json:"budget,omitempty"`
+}
+
+// Spec holds the fields supplied when creating or updating a key. Nil fields are left unchanged
+// on update.
+type Spec struct {
+	Name   *string              `json:"name"`
+	Owner  *string              `json:"owner"`
+	Tags   *[]string            `json:"tags"`
+	Policy *sdkaccess.KeyPolicy `json:"policy"`
+	Budget *budget.Budget       `json:"budget"`
+}
+
+// record is the persisted form of a key.
+type record struct {
+	Key
+	Hash string `json:"hash"`
+}
+
+// Registry holds the virtual keys of the process.
+type Registry struct {
+	mu        sync.RWMutex
+	keys      map[string]*record
+	store     coreauth.StateStore
+	dirty     bool
+	timer     *time.Timer
+	listeners []func()
+	// saveMu serializes snapshot-and-write so an older snapshot never lands after a newer one.
+	saveMu sync.Mutex
+}
+
+// New returns an empty registry.
+func New() *Registry {
+	return &Registry{keys: make(map[string]*record)}
+}
+
+var defaultRegistry = New()
+
+// Default returns the process-wide registry.
+func Default() *Registry { return defaultRegistry }
+
+// SetStore sets where keys are persisted. A nil store keeps keys in memory only.
+func (r *Registry) SetStore(store coreauth.StateStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// OnChange registers fn to run after keys are loaded, created, updated or deleted.
+func (r *Registry) OnChange(fn func()) {
+	if fn == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+func (r *Registry) notify() {
+	r.mu.RLock()
+	listeners := append([]func(){}, r.listeners...)
+	r.mu.RUnlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// Load replaces the in-memory keys with those saved in the store.
+func (r *Registry) Load(ctx context.Context) error {
+	r.mu.RLock()
+	store := r.store
+	r.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	data, err := store.LoadState(ctx, StateDocument)
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]*record)
+	if len(data) > 0 {
+		var records []*record
+		if err = json.Unmarshal(data, &records); err != nil {
+			return fmt.Errorf("virtual keys: unmarshal state: %w", err)
+		}
+		for _, rec := range records {
+			if rec != nil && rec.ID != "" {
+				loaded[rec.ID] = rec
+			}
+		}
+	}
+	r.mu.Lock()
+	r.keys = loaded
+	r.mu.Unlock()
+	r.notify()
+	return nil
+}
+
+// Count returns the number of keys.
+func (r *Registry) Count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.keys)
+}
+
+// List returns every key ordered by creation time.
+func (r *Registry) List() []Key {
+	r.mu.RLock()
+	out := make([]Key, 0, len(r.keys))
+	for _, rec := range r.keys {
+		out = append(out, cloneKey(rec.Key))
+	}
+	r.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out
+}
+
+// Get returns the key with the given ID.
+func (r *Registry) Get(id string) (Key, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.keys[id]
+	if !ok {
+		return Key{}, false
+	}
+	return cloneKey(rec.Key), true
+}
+
+// Label returns the name of the virtual key identified by principal, or principal itself when it
+// is not a virtual key.
+func (r *Registry) Label(principal string) string {
+	if !strings.HasPrefix(principal, idPrefix) {
+		return principal
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if rec, ok := r.keys[principal]; ok && rec.Name != "" {
+		return rec.Name
+	}
+	return principal
+}
+
+// Create adds a key and returns it along with its plaintext secret, which is not stored.
+func (r *Registry) Create(ctx context.Context, spec Spec) (Key, string, error) {
+	if spec.Name == nil || strings.TrimSpace(*spec.Name) == "" {
+		return Key{}, "", fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	id, secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", err
+	}
+	hashed, err := sdkaccess.HashClientKey(secret, sdkaccess.KeyHashSHA256)
+	if err != nil {
+		return Key{}, "", err
+	}
+	rec := &record{
+		Key: Key{
+			ID:        id,
+			Hint:      secret[:len(keyPrefix)+len(id)] + "..." + secret[len(secret)-4:],
+			CreatedAt: time.Now().UTC(),
+		},
+		Hash: hashed,
+	}
+	applySpec(&rec.Key, spec)
+
+	r.mu.Lock()
+	r.keys[id] = rec
+	r.mu.Unlock()
+	if err = r.save(ctx); err != nil {
+		r.mu.Lock()
+		delete(r.keys, id)
+		r.mu.Unlock()
+		return Key{}, "", err
+	}
+	r.notify()
+	return cloneKey(rec.Key), secret, nil
+}
+
+// Update applies the non-nil fields of spec to a key.
+func (r *Registry) Update(ctx context.Context, id string, spec Spec) (Key, error) {
+	if spec.Name != nil && strings.TrimSpace(*spec.Name) == "" {
+		return Key{}, fmt.Errorf("%w: name cannot be empty", ErrInvalid)
+	}
+	r.mu.Lock()
+	rec, ok := r.keys[id]
+	if !ok {
+		r.mu.Unlock()
+		return Key{}, ErrNotFound
+	}
+	previous := cloneKey(rec.Key)
+	applySpec(&rec.Key, spec)
+	updated := cloneKey(rec.Key)
+	r.mu.Unlock()
+	if err := r.save(ctx); err != nil {
+		r.mu.Lock()
+		rec.Key = previous
+		r.mu.Unlock()
+		return Key{}, err
+	}
+	r.notify()
+	return updated, nil
+}
+
+// Delete removes a key.
+func (r *Registry) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	rec, ok := r.keys[id]
+	if !ok {
+		r.mu.Unlock()
+		return ErrNotFound
+	}
+	delete(r.keys, id)
+	r.mu.Unlock()
+	if err := r.save(ctx); err != nil {
+		r.mu.Lock()
+		r.keys[id] = rec
+		r.mu.Unlock()
+		return err
+	}
+	r.notify()
+	return nil
+}
+
+// Authenticate returns the key matching secret and records its use.
+func (r *Registry) Authenticate(secret string) (Key, bool) {
+	id, ok := idFromSecret(secret)
+	if !ok {
+		return Key{}, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rec, ok := r.keys[id]
+	if !ok || !sdkaccess.VerifyClientKey(rec.Hash, secret) {
+		return Key{}, false
+	}
+	rec.LastUsedAt = time.Now().UTC()
+	r.markDirtyLocked()
+	return cloneKey(rec.Key), true
+}
+
+// IsVirtualKey reports whether secret has the shape of a virtual key.
+func IsVirtualKey(secret string) bool {
+	_, ok := idFromSecret(secret)
+	return ok
+}
+
+// Flush writes pending last-used updates to the store.
+func (r *Registry) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	dirty := r.dirty
+	r.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	return r.save(ctx)
+}
+
+func (r *Registry) markDirtyLocked() {
+	r.dirty = true
+	if r.store == nil || r.timer != nil {
+		return
+	}
+	r.timer = time.AfterFunc(lastUsedFlushInterval, func() {
+		r.mu.Lock()
+		r.timer = nil
+		r.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), saveTimeout)
+		defer cancel()
+		if err := r.save(ctx); err != nil {
+			log.Warnf("virtual keys: failed to persist last-used times: %v", err)
+		}
+	})
+}
+
+// save writes every key to the store.
+func (r *Registry) save(ctx context.Context) error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+	r.mu.Lock()
+	store := r.store
+	records := make([]*record, 0, len(r.keys))
+	for _, rec := range r.keys {
+		copied := *rec
+		copied.Key = cloneKey(rec.Key)
+		records = append(records, &copied)
+	}
+	r.dirty = false
+	r.mu.Unlock()
+	if store == nil {
+		return nil
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("virtual keys: marshal state: %w", err)
+	}
+	if err = store.SaveState(ctx, StateDocument, data); err != nil {
+		r.mu.Lock()
+		r.dirty = true
+		r.mu.Unlock()
+		return fmt.Errorf("virtual keys: save state: %w", err)
+	}
+	return nil
+}
+
+func applySpec(key *Key, spec Spec) {
+	if spec.Name != nil {
+		key.Name = strings.TrimSpace(*spec.Name)
+	}
+	if spec.Owner != nil {
+		key.Owner = strings.TrimSpace(*spec.Owner)
+	}
+	if spec.Tags != nil {
+		key.Tags = normalizeTags(*spec.Tags)
+	}
+	if spec.Policy != nil {
+		key.Policy = *spec.Policy
+	}
+	if spec.Budget != nil {
+		if *spec.Budget == (budget.Budget{}) {
+			key.Budget = nil
+		} else {
+			b := *spec.Budget
+			key.Budget = &b
+		}
+	}
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func cloneKey(key Key) Key {
+	key.Tags = append([]string(nil), key.Tags...)
+	key.Policy.AllowedModels = append([]string(nil), key.Policy.AllowedModels...)
+	key.Policy.AllowedProviders = append([]string(nil), key.Policy.AllowedProviders...)
+	key.Policy.AllowedPrefixes = append([]string(nil), key.Policy.AllowedPrefixes...)
+	if key.Budget != nil {
+		b := *key.Budget
+		key.Budget = &b
+	}
+	return key
+}
+
+// newSecret returns a new key ID and the secret embedding it: "sk-vk-<id>.<random>".
+func newSecret() (string, string, error) {
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("virtual keys: generate id: %w", err)
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("virtual keys: generate key: %w", err)
+	}
+	id := idPrefix + hex.EncodeToString(idBytes)
+	return id, keyPrefix + id + "." + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func idFromSecret(secret string) (string, bool) {
+	rest, ok := strings.CutPrefix(secret, keyPrefix+idPrefix)
+	if !ok {
+		return "", false
+	}
+	id, random, ok := strings.Cut(rest, ".")
+	if !ok || id == "" || random == "" {
+		return "", false
+	}
+	return idPrefix + id, true
+}
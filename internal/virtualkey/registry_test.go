@@ -0,0 +1,102 @@
+package virtualkey
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+)
+
+type memoryStateStore struct {
+	docs map[string][]byte
+}
+
+func (s *memoryStateStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	return s.docs[name], nil
+}
+
+func (s *memoryStateStore) SaveState(_ context.Context, name string, data []byte) error {
+	s.docs[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestRegistry_CreateAuthenticatePersist(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStateStore{docs: make(map[string][]byte)}
+	registry := New()
+	registry.SetStore(store)
+
+	key, secret, err := registry.Create(ctx, Spec{
+		Name:   strPtr("ci-bot"),
+		Owner:  strPtr("platform"),
+		Tags:   &[]string{"ci", "ci", " batch "},
+		Policy: &sdkaccess.KeyPolicy{AllowedModels: []string{"gpt-*"}},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(secret, "sk-"+key.ID+".") || len(key.Tags) != 2 {
+		t.Fatalf("unexpected key %+v / %q", key, secret)
+	}
+	if strings.Contains(string(store.docs[StateDocument]), secret) {
+		t.Fatalf("expected only the hash to be persisted")
+	}
+	if got, ok := registry.Authenticate(secret); !ok || got.ID != key.ID || got.LastUsedAt.IsZero() {
+		t.Fatalf("expected secret to authenticate, got %+v", got)
+	}
+	if _, ok := registry.Authenticate(secret + "x"); ok {
+		t.Fatalf("expected tampered secret to be rejected")
+	}
+	if label := registry.Label(key.ID); label != "ci-bot" {
+		t.Fatalf("expected label ci-bot, got %q", label)
+	}
+
+	restored := New()
+	restored.SetStore(store)
+	if err = restored.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, ok := restored.Authenticate(secret); !ok {
+		t.Fatalf("expected restored registry to authenticate the secret")
+	}
+	if _, err = restored.Update(ctx, key.ID, Spec{Name: strPtr("renamed")}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got, _ := restored.Get(key.ID); got.Name != "renamed" || got.Owner != "platform" {
+		t.Fatalf("expected partial update, got %+v", got)
+	}
+	if err = restored.Delete(ctx, key.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err = restored.Delete(ctx, key.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestProvider_ActiveOnlyWithKeys(t *testing.T) {
+	registry := New()
+	p := &provider{registry: registry}
+	if p.Active() {
+		t.Fatalf("expected empty registry to be inactive")
+	}
+	key, secret, err := registry.Create(context.Background(), Spec{Name: strPtr("svc"), Tags: &[]string{"batch"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("X-Api-Key", secret)
+	res, err := p.Authenticate(context.Background(), req)
+	if err != nil || res.Principal != key.ID || res.Metadata[MetadataKeyName] != "svc" || res.Metadata[MetadataTags] != "batch" {
+		t.Fatalf("unexpected result %+v, %v", res, err)
+	}
+	req.Header.Set("X-Api-Key", "sk-static")
+	if _, err = p.Authenticate(context.Background(), req); !errors.Is(err, sdkaccess.ErrNotHandled) {
+		t.Fatalf("expected other keys to be left to other providers, got %v", err)
+	}
+}
@@ -21,6 +21,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Port != newCfg.Port {
 		changes = append(changes, fmt.Sprintf("port: %d -> %d", oldCfg.Port, newCfg.Port))
 	}
+	if oldCfg.TLS.ClientAuth != newCfg.TLS.ClientAuth {
+		changes = append(changes, fmt.Sprintf("tls.client-auth: %s -> %s (restart required)", oldCfg.TLS.ClientAuth, newCfg.TLS.ClientAuth))
+	}
+	if oldCfg.TLS.ClientCA != newCfg.TLS.ClientCA {
+		changes = append(changes, "tls.client-ca: updated (restart required)")
+	}
 	if oldCfg.AuthDir != newCfg.AuthDir {
 		changes = append(changes, fmt.Sprintf("auth-dir: %s -> %s", oldCfg.AuthDir, newCfg.AuthDir))
 	}
@@ -33,6 +39,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.UsageStatisticsEnabled != newCfg.UsageStatisticsEnabled {
 		changes = append(changes, fmt.Sprintf("usage-statistics-enabled: %t -> %t", oldCfg.UsageStatisticsEnabled, newCfg.UsageStatisticsEnabled))
 	}
+	if oldCfg.UsageStore != newCfg.UsageStore {
+		changes = append(changes, fmt.Sprintf("usage-store: %s -> %s", usageStoreSummary(oldCfg.UsageStore), usageStoreSummary(newCfg.UsageStore)))
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing, newCfg.Pricing) {
+		changes = append(changes, fmt.Sprintf("pricing: %d -> %d entries", len(oldCfg.Pricing), len(newCfg.Pricing)))
+	}
+	if oldCfg.Metrics.Enable != newCfg.Metrics.Enable {
+		changes = append(changes, fmt.Sprintf("metrics.enable: %t -> %t", oldCfg.Metrics.Enable, newCfg.Metrics.Enable))
+	}
+	if oldCfg.Metrics.Token != newCfg.Metrics.Token {
+		changes = append(changes, "metrics.token: updated")
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -45,6 +63,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.MaxRetryInterval != newCfg.MaxRetryInterval {
 		changes = append(changes, fmt.Sprintf("max-retry-interval: %d -> %d", oldCfg.MaxRetryInterval, newCfg.MaxRetryInterval))
 	}
+	if oldCfg.MaxQueueWait != newCfg.MaxQueueWait {
+		changes = append(changes, fmt.Sprintf("max-queue-wait: %d -> %d", oldCfg.MaxQueueWait, newCfg.MaxQueueWait))
+	}
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
@@ -54,6 +75,27 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
+	if strings.TrimSpace(oldCfg.Routing.Strategy) != strings.TrimSpace(newCfg.Routing.Strategy) {
+		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", strings.TrimSpace(oldCfg.Routing.Strategy), strings.TrimSpace(newCfg.Routing.Strategy)))
+	}
+	if oldCfg.Routing.SessionAffinity != newCfg.Routing.SessionAffinity {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity: %t -> %t", oldCfg.Routing.SessionAffinity, newCfg.Routing.SessionAffinity))
+	}
+	if oldCfg.Routing.SessionAffinityTTL != newCfg.Routing.SessionAffinityTTL {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity-ttl: %d -> %d", oldCfg.Routing.SessionAffinityTTL, newCfg.Routing.SessionAffinityTTL))
+	}
+	if oldCfg.CircuitBreaker.Enabled != newCfg.CircuitBreaker.Enabled {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.enabled: %t -> %t", oldCfg.CircuitBreaker.Enabled, newCfg.CircuitBreaker.Enabled))
+	}
+	if oldCfg.CircuitBreaker.FailureThreshold != newCfg.CircuitBreaker.FailureThreshold {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.failure-threshold: %d -> %d", oldCfg.CircuitBreaker.FailureThreshold, newCfg.CircuitBreaker.FailureThreshold))
+	}
+	if oldCfg.CircuitBreaker.OpenSeconds != newCfg.CircuitBreaker.OpenSeconds {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.open-seconds: %d -> %d", oldCfg.CircuitBreaker.OpenSeconds, newCfg.CircuitBreaker.OpenSeconds))
+	}
+	if oldCfg.CircuitBreaker.HalfOpenProbes != newCfg.CircuitBreaker.HalfOpenProbes {
+		changes = append(changes, fmt.Sprintf("circuit-breaker.half-open-probes: %d -> %d", oldCfg.CircuitBreaker.HalfOpenProbes, newCfg.CircuitBreaker.HalfOpenProbes))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -69,6 +111,34 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
 	}
+	if len(oldCfg.ClientKeys) != len(newCfg.ClientKeys) {
+		changes = append(changes, fmt.Sprintf("client-keys count: %d -> %d", len(oldCfg.ClientKeys), len(newCfg.ClientKeys)))
+	} else if !reflect.DeepEqual(oldCfg.ClientKeys, newCfg.ClientKeys) {
+		changes = append(changes, "client-keys: settings updated (redacted)")
+	}
+	if oldCfg.ClientRateLimit.RPM != newCfg.ClientRateLimit.RPM {
+		changes = append(changes, fmt.Sprintf("client-rate-limit.rpm: %d -> %d", oldCfg.ClientRateLimit.RPM, newCfg.ClientRateLimit.RPM))
+	}
+	if oldCfg.ClientRateLimit.TPM != newCfg.ClientRateLimit.TPM {
+		changes = append(changes, fmt.Sprintf("client-rate-limit.tpm: %d -> %d", oldCfg.ClientRateLimit.TPM, newCfg.ClientRateLimit.TPM))
+	}
+	if oldCfg.ClientBudget != newCfg.ClientBudget {
+		changes = append(changes, "client-budget: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Payload, newCfg.Payload) {
+		changes = append(changes, fmt.Sprintf("payload rules: default %d -> %d, override %d -> %d, delete %d -> %d",
+			len(oldCfg.Payload.Default), len(newCfg.Payload.Default), len(oldCfg.Payload.Override), len(newCfg.Payload.Override),
+			len(oldCfg.Payload.Delete), len(newCfg.Payload.Delete)))
+	}
+	if !reflect.DeepEqual(oldCfg.IPFilter.Allow, newCfg.IPFilter.Allow) {
+		changes = append(changes, fmt.Sprintf("ip-filter.allow: %d -> %d entries", len(oldCfg.IPFilter.Allow), len(newCfg.IPFilter.Allow)))
+	}
+	if !reflect.DeepEqual(oldCfg.IPFilter.Deny, newCfg.IPFilter.Deny) {
+		changes = append(changes, fmt.Sprintf("ip-filter.deny: %d -> %d entries", len(oldCfg.IPFilter.Deny), len(newCfg.IPFilter.Deny)))
+	}
+	if !reflect.DeepEqual(oldCfg.IPFilter.TrustedProxies, newCfg.IPFilter.TrustedProxies) {
+		changes = append(changes, fmt.Sprintf("ip-filter.trusted-proxies: %d -> %d entries", len(oldCfg.IPFilter.TrustedProxies), len(newCfg.IPFilter.TrustedProxies)))
+	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
 	} else {
@@ -84,6 +154,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("gemini[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.Weight != n.Weight {
+				changes = append(changes, fmt.Sprintf("gemini[%d].weight: %d -> %d", i, o.Weight, n.Weight))
+			}
+			if o.MaxConcurrent != n.MaxConcurrent {
+				changes = append(changes, fmt.Sprintf("gemini[%d].max-concurrent: %d -> %d", i, o.MaxConcurrent, n.MaxConcurrent))
+			}
+			if strings.TrimSpace(o.MinInterval) != strings.TrimSpace(n.MinInterval) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].min-interval: %s -> %s", i, strings.TrimSpace(o.MinInterval), strings.TrimSpace(n.MinInterval)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].api-key: updated", i))
 			}
@@ -114,6 +196,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("claude[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("claude[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.Weight != n.Weight {
+				changes = append(changes, fmt.Sprintf("claude[%d].weight: %d -> %d", i, o.Weight, n.Weight))
+			}
+			if o.MaxConcurrent != n.MaxConcurrent {
+				changes = append(changes, fmt.Sprintf("claude[%d].max-concurrent: %d -> %d", i, o.MaxConcurrent, n.MaxConcurrent))
+			}
+			if strings.TrimSpace(o.MinInterval) != strings.TrimSpace(n.MinInterval) {
+				changes = append(changes, fmt.Sprintf("claude[%d].min-interval: %s -> %s", i, strings.TrimSpace(o.MinInterval), strings.TrimSpace(n.MinInterval)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("claude[%d].api-key: updated", i))
 			}
@@ -144,6 +238,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("codex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("codex[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.Weight != n.Weight {
+				changes = append(changes, fmt.Sprintf("codex[%d].weight: %d -> %d", i, o.Weight, n.Weight))
+			}
+			if o.MaxConcurrent != n.MaxConcurrent {
+				changes = append(changes, fmt.Sprintf("codex[%d].max-concurrent: %d -> %d", i, o.MaxConcurrent, n.MaxConcurrent))
+			}
+			if strings.TrimSpace(o.MinInterval) != strings.TrimSpace(n.MinInterval) {
+				changes = append(changes, fmt.Sprintf("codex[%d].min-interval: %s -> %s", i, strings.TrimSpace(o.MinInterval), strings.TrimSpace(n.MinInterval)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("codex[%d].api-key: updated", i))
 			}
@@ -186,6 +292,10 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		changes = append(changes, fmt.Sprintf("ampcode.force-model-mappings: %t -> %t", oldCfg.AmpCode.ForceModelMappings, newCfg.AmpCode.ForceModelMappings))
 	}
 
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks, newCfg.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks: updated (%d -> %d entries)", len(oldCfg.ModelFallbacks), len(newCfg.ModelFallbacks)))
+	}
+
 	if entries, _ := DiffOAuthExcludedModelChanges(oldCfg.OAuthExcludedModels, newCfg.OAuthExcludedModels); len(entries) > 0 {
 		changes = append(changes, entries...)
 	}
@@ -237,6 +347,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
+			if o.Priority != n.Priority {
+				changes = append(changes, fmt.Sprintf("vertex[%d].priority: %d -> %d", i, o.Priority, n.Priority))
+			}
+			if o.Weight != n.Weight {
+				changes = append(changes, fmt.Sprintf("vertex[%d].weight: %d -> %d", i, o.Weight, n.Weight))
+			}
+			if o.MaxConcurrent != n.MaxConcurrent {
+				changes = append(changes, fmt.Sprintf("vertex[%d].max-concurrent: %d -> %d", i, o.MaxConcurrent, n.MaxConcurrent))
+			}
+			if strings.TrimSpace(o.MinInterval) != strings.TrimSpace(n.MinInterval) {
+				changes = append(changes, fmt.Sprintf("vertex[%d].min-interval: %s -> %s", i, strings.TrimSpace(o.MinInterval), strings.TrimSpace(n.MinInterval)))
+			}
 			if strings.TrimSpace(o.APIKey) != strings.TrimSpace(n.APIKey) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].api-key: updated", i))
 			}
@@ -301,3 +423,11 @@ func formatProxyURL(raw string) string {
 	}
 	return scheme + "://" + host
 }
+
+func usageStoreSummary(cfg config.UsageStoreConfig) string {
+	storeType := strings.TrimSpace(cfg.Type)
+	if storeType == "" {
+		return "memory"
+	}
+	return fmt.Sprintf("%s (raw %dd, hourly %dd)", storeType, cfg.RawRetentionDays, cfg.HourlyRetentionDays)
+}
@@ -171,6 +171,11 @@ func SynthesizeGeminiVirtualAuths(primary *coreauth.Auth, metadata map[string]an
 		if proxy != "" {
 			metadataCopy["proxy_url"] = proxy
 		}
+		for _, key := range []string{"priority", "weight", "max_concurrent", "min_interval"} {
+			if value, ok := metadata[key]; ok {
+				metadataCopy[key] = value
+			}
+		}
 		virtual := &coreauth.Auth{
 			ID:         buildGeminiVirtualID(primary.ID, projectID),
 			Provider:   originalProvider,
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/radityprtama/proxygate/v6/internal/config"
@@ -108,3 +109,30 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addSelectionAttrs records the credential's priority tier and weight in auth attributes.
+// Zero values are omitted so the selector falls back to its defaults.
+func addSelectionAttrs(priority, weight int, attrs map[string]string) {
+	if attrs == nil {
+		return
+	}
+	if priority != 0 {
+		attrs["priority"] = strconv.Itoa(priority)
+	}
+	if weight > 0 {
+		attrs["weight"] = strconv.Itoa(weight)
+	}
+}
+
+// addThrottleAttrs records the credential's concurrency cap and request spacing in auth attributes.
+func addThrottleAttrs(maxConcurrent int, minInterval string, attrs map[string]string) {
+	if attrs == nil {
+		return
+	}
+	if maxConcurrent > 0 {
+		attrs["max_concurrent"] = strconv.Itoa(maxConcurrent)
+	}
+	if interval := strings.TrimSpace(minInterval); interval != "" {
+		attrs["min_interval"] = interval
+	}
+}
@@ -0,0 +1,101 @@
+package access
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ParseCIDRs parses CIDR blocks; bare addresses are treated as single-host prefixes.
+func ParseCIDRs(values []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q", value)
+			}
+			addr = addr.Unmap()
+			out = append(out, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), max(prefix.Bits()-96, 0))
+		}
+		out = append(out, prefix.Masked())
+	}
+	return out, nil
+}
+
+// AddrAllowed applies allow and deny lists to addr. Deny entries win; an empty allow list
+// admits every address not denied. Invalid addresses are only admitted when no list is set.
+func AddrAllowed(allow, deny []netip.Prefix, addr netip.Addr) bool {
+	if len(allow) == 0 && len(deny) == 0 {
+		return true
+	}
+	if !addr.IsValid() {
+		return false
+	}
+	addr = addr.Unmap()
+	if containsAddr(deny, addr) {
+		return false
+	}
+	return len(allow) == 0 || containsAddr(allow, addr)
+}
+
+// ClientAddr returns the address of the client behind r. X-Forwarded-For is only honoured when
+// the connection comes from a trusted proxy; the chain is then walked from the right, skipping
+// trusted hops, so clients cannot spoof their address by prepending entries.
+func ClientAddr(r *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	addr := remoteAddr(r.RemoteAddr)
+	if len(trustedProxies) == 0 || !containsAddr(trustedProxies, addr) {
+		return addr
+	}
+	hops := r.Header.Values("X-Forwarded-For")
+	var chain []string
+	for _, hop := range hops {
+		chain = append(chain, strings.Split(hop, ",")...)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(chain[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !containsAddr(trustedProxies, addr) {
+			break
+		}
+	}
+	return addr
+}
+
+func remoteAddr(raw string) netip.Addr {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(raw))
+	if err != nil {
+		host = strings.TrimSpace(raw)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package access
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestKeyPolicyCheckAddr(t *testing.T) {
+	policy := &KeyPolicy{AllowedCIDRs: []string{"10.20.0.0/16", "::ffff:192.168.1.5"}, DeniedCIDRs: []string{"10.20.99.0/24"}}
+	cases := map[string]bool{
+		"10.20.1.1":        true,
+		"10.20.99.7":       false,
+		"10.21.0.1":        false,
+		"192.168.1.5":      true,
+		"::ffff:10.20.1.1": true,
+	}
+	for raw, want := range cases {
+		if err := policy.CheckAddr(netip.MustParseAddr(raw)); (err == nil) != want {
+			t.Fatalf("%s: got %v, want allowed=%v", raw, err, want)
+		}
+	}
+	broken := &KeyPolicy{AllowedCIDRs: []string{"not-a-network"}}
+	if err := broken.CheckAddr(netip.MustParseAddr("10.0.0.1")); err == nil {
+		t.Fatalf("expected allow list without valid entries to fail closed")
+	}
+	if err := (&KeyPolicy{}).CheckAddr(netip.Addr{}); err != nil {
+		t.Fatalf("expected policy without networks to admit any address, got %v", err)
+	}
+}
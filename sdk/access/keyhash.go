@@ -0,0 +1,101 @@
+package access
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Prefixes marking hashed client keys in configuration.
+const (
+	// KeyHashSHA256 stores "sha256:<hex salt>:<hex sha256(salt || key)>". It is cheap to verify
+	// and suits high-entropy generated keys.
+	KeyHashSHA256 = "sha256"
+	// KeyHashBcrypt stores "bcrypt:<bcrypt hash>". It is deliberately slow; successful matches
+	// are cached by the config access provider.
+	KeyHashBcrypt = "bcrypt"
+)
+
+// generatedKeyPrefix marks keys produced by GenerateClientKey.
+const generatedKeyPrefix = "sk-pg-"
+
+// IsHashedKey reports whether a configured key is stored as a hash rather than in plaintext.
+func IsHashedKey(stored string) bool {
+	scheme, _, ok := strings.Cut(stored, ":")
+	return ok && (scheme == KeyHashSHA256 || scheme == KeyHashBcrypt)
+}
+
+// HashClientKey hashes a plaintext key with the given scheme (KeyHashSHA256 when empty).
+func HashClientKey(plaintext, scheme string) (string, error) {
+	if plaintext == "" {
+		return "", fmt.Errorf("access: empty key")
+	}
+	switch strings.ToLower(strings.TrimSpace(scheme)) {
+	case "", KeyHashSHA256:
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("access: generate salt: %w", err)
+		}
+		return KeyHashSHA256 + ":" + hex.EncodeToString(salt) + ":" + hex.EncodeToString(saltedSHA256(salt, plaintext)), nil
+	case KeyHashBcrypt:
+		hashed, err := bcrypt.GenerateFromPassword([]byte(plaintext), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("access: bcrypt key: %w", err)
+		}
+		return KeyHashBcrypt + ":" + string(hashed), nil
+	}
+	return "", fmt.Errorf("access: unsupported key hash scheme %q", scheme)
+}
+
+// VerifyClientKey reports whether provided matches a stored key. Hashed keys are verified in
+// constant time; plaintext keys are compared in constant time as well.
+func VerifyClientKey(stored, provided string) bool {
+	if stored == "" || provided == "" {
+		return false
+	}
+	scheme, rest, _ := strings.Cut(stored, ":")
+	switch scheme {
+	case KeyHashSHA256:
+		saltHex, sumHex, ok := strings.Cut(rest, ":")
+		if !ok {
+			return false
+		}
+		salt, errSalt := hex.DecodeString(saltHex)
+		sum, errSum := hex.DecodeString(sumHex)
+		if errSalt != nil || errSum != nil || len(sum) != sha256.Size {
+			return false
+		}
+		return subtle.ConstantTimeCompare(saltedSHA256(salt, provided), sum) == 1
+	case KeyHashBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(rest), []byte(provided)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(provided)) == 1
+}
+
+// GenerateClientKey returns a new random client key and its hash for the given scheme.
+// The plaintext should be shown once and only the hash stored.
+func GenerateClientKey(scheme string) (plaintext, hashed string, err error) {
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("access: generate key: %w", err)
+	}
+	plaintext = generatedKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	hashed, err = HashClientKey(plaintext, scheme)
+	if err != nil {
+		return "", "", err
+	}
+	return plaintext, hashed, nil
+}
+
+func saltedSHA256(salt []byte, key string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(key))
+	return h.Sum(nil)
+}
@@ -0,0 +1,42 @@
+package access
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyClientKey(t *testing.T) {
+	for _, scheme := range []string{KeyHashSHA256, KeyHashBcrypt} {
+		plaintext, hashed, err := GenerateClientKey(scheme)
+		if err != nil {
+			t.Fatalf("%s: generate: %v", scheme, err)
+		}
+		if !strings.HasPrefix(hashed, scheme+":") || !IsHashedKey(hashed) || strings.Contains(hashed, plaintext) {
+			t.Fatalf("%s: unexpected stored form %q", scheme, hashed)
+		}
+		if !VerifyClientKey(hashed, plaintext) {
+			t.Fatalf("%s: expected plaintext to verify", scheme)
+		}
+		if VerifyClientKey(hashed, plaintext+"x") {
+			t.Fatalf("%s: expected other key to be rejected", scheme)
+		}
+	}
+	if first, _ := HashClientKey("same", KeyHashSHA256); first == mustHash(t, "same") {
+		t.Fatalf("expected salted hashes to differ")
+	}
+	if !VerifyClientKey("plain-key", "plain-key") || VerifyClientKey("plain-key", "plain-kez") {
+		t.Fatalf("expected plaintext entries to compare exactly")
+	}
+	if VerifyClientKey("sha256:zz:00", "anything") {
+		t.Fatalf("expected malformed hash to be rejected")
+	}
+}
+
+func mustHash(t *testing.T, key string) string {
+	t.Helper()
+	hashed, err := HashClientKey(key, "")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	return hashed
+}
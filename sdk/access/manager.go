@@ -57,10 +57,15 @@ func (m *Manager) Authenticate(ctx context.Context, r *http.Request) (*Result, e
 		invalid bool
 	)
 
+	active := 0
 	for _, provider := range providers {
 		if provider == nil {
 			continue
 		}
+		if conditional, ok := provider.(ConditionalProvider); ok && !conditional.Active() {
+			continue
+		}
+		active++
 		res, err := provider.Authenticate(ctx, r)
 		if err == nil {
 			return res, nil
@@ -79,6 +84,9 @@ func (m *Manager) Authenticate(ctx context.Context, r *http.Request) (*Result, e
 		return nil, err
 	}
 
+	if active == 0 {
+		return nil, nil
+	}
 	if invalid {
 		return nil, ErrInvalidCredential
 	}
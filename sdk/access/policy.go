@@ -0,0 +1,293 @@
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/radityprtama/proxygate/v6/sdk/config"
+)
+
+// MetadataPolicy is the Result.Metadata key carrying a JSON-encoded KeyPolicy.
+const MetadataPolicy = "policy"
+
+// KeyPolicy restricts what a client key may reach.
+type KeyPolicy struct {
+	// AllowedModels lists model globs ('*' wildcard) the key may request. Empty allows all.
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// AllowedProviders lists provider keys the key may be routed to. Empty allows all.
+	AllowedProviders []string `json:"allowed_providers,omitempty"`
+	// AllowedPrefixes lists credential prefixes the key must target. Empty allows all.
+	AllowedPrefixes []string `json:"allowed_prefixes,omitempty"`
+	// DefaultPrefix is prepended to models requested without one of the key's prefixes. It is
+	// implicitly allowed.
+	DefaultPrefix string `json:"default_prefix,omitempty"`
+	// AllowedCIDRs lists source networks the key may be used from. Empty allows all.
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
+	// DeniedCIDRs lists source networks the key is rejected from; they take precedence.
+	DeniedCIDRs []string `json:"denied_cidrs,omitempty"`
+	// ExpiresAt rejects the key from this instant on when set.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// Disabled rejects every request made with the key.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// nets caches the parsed CIDR lists when the policy is built or decoded.
+	nets *policyNets
+}
+
+type policyNets struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// PolicyFromClientKey returns the policy configured on a client key entry.
+func PolicyFromClientKey(clientKey *config.ClientKey) KeyPolicy {
+	if clientKey == nil {
+		return KeyPolicy{}
+	}
+	return KeyPolicy{
+		AllowedModels:    clientKey.AllowedModels,
+		AllowedProviders: clientKey.AllowedProviders,
+		AllowedPrefixes:  clientKey.AllowedPrefixes,
+		DefaultPrefix:    clientKey.DefaultPrefix,
+		AllowedCIDRs:     clientKey.AllowedCIDRs,
+		DeniedCIDRs:      clientKey.DeniedCIDRs,
+		ExpiresAt:        clientKey.ExpiresAt,
+		Disabled:         clientKey.Disabled,
+		nets:             parsePolicyNets(clientKey.AllowedCIDRs, clientKey.DeniedCIDRs),
+	}
+}
+
+// ApplyClientKeySettings attaches the admin flag and policy configured under client-keys for
+// principal to metadata. Providers authenticating identities rather than keys use it so that
+// per-key settings apply to those identities too.
+func ApplyClientKeySettings(root *config.SDKConfig, principal string, metadata map[string]string) {
+	clientKey := root.ClientKeySettings(principal)
+	if clientKey == nil || metadata == nil {
+		return
+	}
+	if clientKey.Admin {
+		metadata[MetadataAdmin] = "true"
+	}
+	if len(clientKey.Tags) > 0 {
+		metadata[MetadataTags] = strings.Join(clientKey.Tags, ",")
+	}
+	if policy := PolicyFromClientKey(clientKey); !policy.Empty() {
+		metadata[MetadataPolicy] = policy.Encode()
+	}
+}
+
+// Empty reports whether the policy imposes no restriction.
+func (p KeyPolicy) Empty() bool {
+	return len(p.AllowedModels) == 0 && len(p.AllowedProviders) == 0 && len(p.AllowedPrefixes) == 0 && p.DefaultPrefix == "" &&
+		len(p.AllowedCIDRs) == 0 && len(p.DeniedCIDRs) == 0 && p.ExpiresAt.IsZero() && !p.Disabled
+}
+
+// Encode serialises the policy for Result.Metadata.
+func (p KeyPolicy) Encode() string {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// PolicyFromMetadata decodes the policy attached to an authentication result, if any.
+func PolicyFromMetadata(metadata map[string]string) (*KeyPolicy, bool) {
+	raw := strings.TrimSpace(metadata[MetadataPolicy])
+	if raw == "" {
+		return nil, false
+	}
+	var policy KeyPolicy
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return nil, false
+	}
+	policy.nets = parsePolicyNets(policy.AllowedCIDRs, policy.DeniedCIDRs)
+	return &policy, true
+}
+
+// CheckActive returns an error when the key is disabled or expired at now.
+func (p *KeyPolicy) CheckActive(now time.Time) error {
+	if p == nil {
+		return nil
+	}
+	if p.Disabled {
+		return fmt.Errorf("client key is disabled")
+	}
+	if !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt) {
+		return fmt.Errorf("client key expired at %s", p.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CheckAddr returns an error when the key may not be used from addr. Unparseable entries are
+// skipped; an allow list without any valid entry rejects every address.
+func (p *KeyPolicy) CheckAddr(addr netip.Addr) error {
+	if p == nil || (len(p.AllowedCIDRs) == 0 && len(p.DeniedCIDRs) == 0) {
+		return nil
+	}
+	nets := p.nets
+	if nets == nil {
+		nets = parsePolicyNets(p.AllowedCIDRs, p.DeniedCIDRs)
+	}
+	if len(p.AllowedCIDRs) > 0 && len(nets.allow) == 0 {
+		return fmt.Errorf("client key has no valid allowed networks")
+	}
+	if !AddrAllowed(nets.allow, nets.deny, addr) {
+		return fmt.Errorf("client key is not allowed from %s", addr)
+	}
+	return nil
+}
+
+// parsePolicyNets parses the allow and deny lists, skipping unparseable entries.
+func parsePolicyNets(allowed, denied []string) *policyNets {
+	return &policyNets{allow: parseValidCIDRs(allowed), deny: parseValidCIDRs(denied)}
+}
+
+func parseValidCIDRs(values []string) []netip.Prefix {
+	out := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if prefixes, err := ParseCIDRs([]string{value}); err == nil {
+			out = append(out, prefixes...)
+		}
+	}
+	return out
+}
+
+// CheckModel returns an error when the key may not request model. Each candidate name (for
+// example the raw and the normalized model) is tried against the allowed globs.
+func (p *KeyPolicy) CheckModel(names ...string) error {
+	if p == nil {
+		return nil
+	}
+	model := ""
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			model = name
+			break
+		}
+	}
+	if len(p.AllowedModels) > 0 && !p.modelAllowed(names) {
+		return fmt.Errorf("client key is not allowed to use model %s", model)
+	}
+	return p.CheckPrefix(model)
+}
+
+// CheckPrefix returns an error when model does not address one of the key's credential prefixes.
+func (p *KeyPolicy) CheckPrefix(model string) error {
+	if p == nil {
+		return nil
+	}
+	if prefixes := p.Prefixes(); len(prefixes) > 0 && !p.prefixAllowed(strings.TrimSpace(model)) {
+		return fmt.Errorf("client key must target a credential prefix: %s", strings.Join(prefixes, ", "))
+	}
+	return nil
+}
+
+// Prefixes returns the credential prefixes the key is confined to, including the default
+// prefix. An empty result leaves the key unconfined.
+func (p *KeyPolicy) Prefixes() []string {
+	if p == nil {
+		return nil
+	}
+	out := make([]string, 0, len(p.AllowedPrefixes)+1)
+	seen := make(map[string]struct{}, len(p.AllowedPrefixes)+1)
+	for _, prefix := range append(append([]string(nil), p.AllowedPrefixes...), p.DefaultPrefix) {
+		prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+		if prefix == "" {
+			continue
+		}
+		if _, dup := seen[strings.ToLower(prefix)]; dup {
+			continue
+		}
+		seen[strings.ToLower(prefix)] = struct{}{}
+		out = append(out, prefix)
+	}
+	return out
+}
+
+// ApplyDefaultPrefix prepends the default prefix to model unless it already addresses one of
+// the key's prefixes.
+func (p *KeyPolicy) ApplyDefaultPrefix(model string) string {
+	if p == nil || model == "" {
+		return model
+	}
+	prefix := strings.Trim(strings.TrimSpace(p.DefaultPrefix), "/")
+	if prefix == "" || p.prefixAllowed(model) {
+		return model
+	}
+	return prefix + "/" + model
+}
+
+func (p *KeyPolicy) modelAllowed(names []string) bool {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		for _, pattern := range p.AllowedModels {
+			if matchPolicyPattern(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *KeyPolicy) prefixAllowed(model string) bool {
+	prefix, _, ok := strings.Cut(model, "/")
+	if !ok {
+		return false
+	}
+	for _, allowed := range p.Prefixes() {
+		if strings.EqualFold(allowed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterProviders returns the providers the key may be routed to, preserving order.
+func (p *KeyPolicy) FilterProviders(providers []string) []string {
+	if p == nil || len(p.AllowedProviders) == 0 {
+		return providers
+	}
+	out := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		for _, allowed := range p.AllowedProviders {
+			if strings.EqualFold(strings.TrimSpace(allowed), provider) {
+				out = append(out, provider)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// matchPolicyPattern performs case-insensitive matching where '*' matches any substring.
+func matchPolicyPattern(pattern, value string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	value = strings.ToLower(value)
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, segment := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return strings.HasSuffix(value, last)
+}
@@ -0,0 +1,75 @@
+package access
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyPolicy_RoundTripAndChecks(t *testing.T) {
+	policy := KeyPolicy{
+		AllowedModels:    []string{"gpt-5*", "*sonnet*"},
+		AllowedProviders: []string{"codex"},
+		ExpiresAt:        time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	decoded, ok := PolicyFromMetadata(map[string]string{MetadataPolicy: policy.Encode()})
+	if !ok {
+		t.Fatalf("expected policy to decode")
+	}
+	if err := decoded.CheckActive(time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("expected active key, got %v", err)
+	}
+	if err := decoded.CheckActive(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Fatalf("expected expired key to be rejected")
+	}
+	if err := decoded.CheckModel("GPT-5-codex"); err != nil {
+		t.Fatalf("expected glob match, got %v", err)
+	}
+	if err := decoded.CheckModel("claude-sonnet-4-5(high)", "claude-sonnet-4-5"); err != nil {
+		t.Fatalf("expected infix glob match, got %v", err)
+	}
+	if err := decoded.CheckModel("gemini-2.5-pro"); err == nil {
+		t.Fatalf("expected unlisted model to be rejected")
+	}
+	if got := decoded.FilterProviders([]string{"openai", "codex"}); len(got) != 1 || got[0] != "codex" {
+		t.Fatalf("unexpected provider filter result %v", got)
+	}
+
+	prefixed := &KeyPolicy{AllowedPrefixes: []string{"teamA"}}
+	if err := prefixed.CheckModel("teama/gpt-5"); err != nil {
+		t.Fatalf("expected prefixed model to pass, got %v", err)
+	}
+	if err := prefixed.CheckModel("gpt-5"); err == nil {
+		t.Fatalf("expected unprefixed model to be rejected")
+	}
+	if err := (&KeyPolicy{Disabled: true}).CheckActive(time.Now()); err == nil {
+		t.Fatalf("expected disabled key to be rejected")
+	}
+}
+
+func TestKeyPolicy_DefaultPrefix(t *testing.T) {
+	policy := &KeyPolicy{AllowedPrefixes: []string{"teamA", "shared"}, DefaultPrefix: "/teamA/"}
+	if got := policy.Prefixes(); len(got) != 2 || got[0] != "teamA" || got[1] != "shared" {
+		t.Fatalf("unexpected prefixes %v", got)
+	}
+	cases := map[string]string{
+		"gpt-5":             "teamA/gpt-5",
+		"shared/gpt-5":      "shared/gpt-5",
+		"teamB/gpt-5":       "teamA/teamB/gpt-5",
+		"meta-llama/llama3": "teamA/meta-llama/llama3",
+	}
+	for in, want := range cases {
+		if got := policy.ApplyDefaultPrefix(in); got != want {
+			t.Fatalf("ApplyDefaultPrefix(%q) = %q, want %q", in, got, want)
+		}
+	}
+	onlyDefault := &KeyPolicy{DefaultPrefix: "teamA"}
+	if onlyDefault.Empty() {
+		t.Fatalf("expected a default prefix to count as a restriction")
+	}
+	if err := onlyDefault.CheckPrefix("teamB/gpt-5"); err == nil {
+		t.Fatalf("expected the default prefix to confine the key")
+	}
+	if err := onlyDefault.CheckPrefix(onlyDefault.ApplyDefaultPrefix("gpt-5")); err != nil {
+		t.Fatalf("expected defaulted model to pass, got %v", err)
+	}
+}
@@ -15,6 +15,14 @@ type Provider interface {
 	Authenticate(ctx context.Context, r *http.Request) (*Result, error)
 }
 
+// ConditionalProvider is implemented by providers that only take part in authentication while
+// they have credentials to check, such as runtime-managed keys. While every provider is
+// inactive, requests are let through as if no provider was configured.
+type ConditionalProvider interface {
+	Provider
+	Active() bool
+}
+
 // Result conveys authentication outcome.
 type Result struct {
 	Provider  string
@@ -22,6 +30,13 @@ type Result struct {
 	Metadata  map[string]string
 }
 
+// MetadataAdmin is the Result.Metadata key set to "true" for principals allowed to use
+// privileged request features such as routing hints.
+const MetadataAdmin = "admin"
+
+// MetadataTags is the Result.Metadata key listing the principal's tags, comma-separated.
+const MetadataTags = "tags"
+
 // ProviderFactory builds a provider from configuration data.
 type ProviderFactory func(cfg *config.AccessProvider, root *config.SDKConfig) (Provider, error)
 
@@ -74,7 +89,7 @@ func BuildProviders(root *config.SDKConfig) ([]Provider, error) {
 		}
 		providers = append(providers, provider)
 	}
-	if len(providers) == 0 {
+	if !root.HasConfigAPIKeyProvider() {
 		if inline := config.MakeInlineAPIKeyProvider(root.APIKeys); inline != nil {
 			provider, err := BuildProvider(inline, root)
 			if err != nil {
@@ -77,6 +77,11 @@ func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 		return
 	}
 
+	if errMsg := h.CheckClientPolicy(c, gjson.GetBytes(rawJSON, "model").String()); errMsg != nil {
+		h.writeClaudeError(c, errMsg)
+		return
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	if !streamResult.Exists() || streamResult.Type == gjson.False {
@@ -108,11 +113,15 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 
 	c.Header("Content-Type", "application/json")
 
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if errMsg := h.CheckClientPolicy(c, modelName); errMsg != nil {
+		h.writeClaudeError(c, errMsg)
+		return
+	}
+
 	alt := h.GetAlt(c)
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 
-	modelName := gjson.GetBytes(rawJSON, "model").String()
-
 	resp, errMsg := h.ExecuteCountWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
@@ -130,7 +139,7 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 //   - c: The Gin context for the request.
 func (h *ClaudeCodeAPIHandler) ClaudeModels(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"data": h.Models(),
+		"data": h.FilterModelsForClient(c, h.Models()),
 	})
 }
 
@@ -284,3 +293,26 @@ func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) claud
 		},
 	}
 }
+
+// writeClaudeError renders a request-level rejection in the Anthropic error format.
+func (h *ClaudeCodeAPIHandler) writeClaudeError(c *gin.Context, msg *interfaces.ErrorMessage) {
+	errType := "invalid_request_error"
+	switch msg.StatusCode {
+	case http.StatusForbidden:
+		errType = "permission_error"
+	case http.StatusTooManyRequests:
+		errType = "rate_limit_error"
+	}
+	for key, values := range msg.Addon {
+		for _, value := range values {
+			c.Writer.Header().Add(key, value)
+		}
+	}
+	c.JSON(msg.StatusCode, claudeErrorResponse{
+		Type: "error",
+		Error: claudeErrorDetail{
+			Type:    errType,
+			Message: msg.Error.Error(),
+		},
+	})
+}
@@ -62,6 +62,13 @@ func (h *GeminiCLIAPIHandler) CLIHandler(c *gin.Context) {
 	rawJSON, _ := c.GetRawData()
 	requestRawURI := c.Request.URL.Path
 
+	if requestRawURI == "/v1internal:generateContent" || requestRawURI == "/v1internal:streamGenerateContent" {
+		if errMsg := h.CheckClientPolicy(c, gjson.GetBytes(rawJSON, "model").String()); errMsg != nil {
+			writeGeminiError(c, errMsg)
+			return
+		}
+	}
+
 	if requestRawURI == "/v1internal:generateContent" {
 		h.handleInternalGenerateContent(c, rawJSON)
 	} else if requestRawURI == "/v1internal:streamGenerateContent" {
@@ -48,7 +48,7 @@ func (h *GeminiAPIHandler) Models() []map[string]any {
 // GeminiModels handles the Gemini models listing endpoint.
 // It returns a JSON response containing available Gemini models and their specifications.
 func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
-	rawModels := h.Models()
+	rawModels := h.FilterModelsForClient(c, h.Models())
 	normalizedModels := make([]map[string]any, 0, len(rawModels))
 	defaultMethods := []string{"generateContent"}
 	for _, model := range rawModels {
@@ -204,6 +204,11 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 	method := action[1]
 	rawJSON, _ := c.GetRawData()
 
+	if errMsg := h.CheckClientPolicy(c, action[0]); errMsg != nil {
+		writeGeminiError(c, errMsg)
+		return
+	}
+
 	switch method {
 	case "generateContent":
 		h.handleGenerateContent(c, action[0], rawJSON)
@@ -333,3 +338,26 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 		}
 	}
 }
+
+// writeGeminiError renders a request-level rejection in the Google API error format.
+func writeGeminiError(c *gin.Context, msg *interfaces.ErrorMessage) {
+	status := "INVALID_ARGUMENT"
+	switch msg.StatusCode {
+	case http.StatusForbidden:
+		status = "PERMISSION_DENIED"
+	case http.StatusTooManyRequests:
+		status = "RESOURCE_EXHAUSTED"
+	}
+	for key, values := range msg.Addon {
+		for _, value := range values {
+			c.Writer.Header().Add(key, value)
+		}
+	}
+	c.JSON(msg.StatusCode, gin.H{
+		"error": gin.H{
+			"code":    msg.StatusCode,
+			"message": msg.Error.Error(),
+			"status":  status,
+		},
+	})
+}
@@ -8,13 +8,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/budget"
+	"github.com/radityprtama/proxygate/v6/internal/clientlimit"
 	"github.com/radityprtama/proxygate/v6/internal/interfaces"
+	"github.com/radityprtama/proxygate/v6/internal/metrics"
 	"github.com/radityprtama/proxygate/v6/internal/util"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
 	coreauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
 	"github.com/radityprtama/proxygate/v6/sdk/config"
 	sdktranslator "github.com/radityprtama/proxygate/v6/sdk/translator"
 	"golang.org/x/net/context"
@@ -112,6 +119,7 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 	newCtx, cancel := context.WithCancel(ctx)
 	newCtx = context.WithValue(newCtx, "gin", c)
 	newCtx = context.WithValue(newCtx, "handler", handler)
+	newCtx = coreusage.WithTiming(newCtx, usageTimingFromGin(c))
 	return newCtx, func(params ...interface{}) {
 		if h.Cfg.RequestLog && len(params) == 1 {
 			if existing, exists := c.Get("API_RESPONSE"); exists {
@@ -178,7 +186,7 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
@@ -198,7 +206,10 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	if cloned := cloneMetadata(metadata); cloned != nil {
 		opts.Metadata = cloned
 	}
+	opts.Metadata = h.withSessionKey(ctx, rawJSON, opts.Metadata)
+	ctx, route := coreauth.WithRouteInfo(ctx)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	labelRequestMetrics(ctx, handlerType, normalizedModel, providers, route)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -214,13 +225,14 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	writeRouteHeaders(ctx, route)
 	return cloneBytes(resp.Payload), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		return nil, errMsg
 	}
@@ -240,7 +252,10 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if cloned := cloneMetadata(metadata); cloned != nil {
 		opts.Metadata = cloned
 	}
+	opts.Metadata = h.withSessionKey(ctx, rawJSON, opts.Metadata)
+	ctx, route := coreauth.WithRouteInfo(ctx)
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
+	labelRequestMetrics(ctx, handlerType, normalizedModel, providers, route)
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -256,13 +271,14 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		}
 		return nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	writeRouteHeaders(ctx, route)
 	return cloneBytes(resp.Payload), nil
 }
 
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(modelName)
+	providers, normalizedModel, metadata, errMsg := h.getRequestDetails(ctx, modelName)
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -285,7 +301,10 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	if cloned := cloneMetadata(metadata); cloned != nil {
 		opts.Metadata = cloned
 	}
+	opts.Metadata = h.withSessionKey(ctx, rawJSON, opts.Metadata)
+	ctx, route := coreauth.WithRouteInfo(ctx)
 	chunks, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	labelRequestMetrics(ctx, handlerType, normalizedModel, providers, route)
 	if err != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -304,6 +323,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, errChan
 	}
+	writeRouteHeaders(ctx, route)
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
@@ -334,9 +354,15 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	return dataChan, errChan
 }
 
-func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
-	// Resolve "auto" model to an actual available model first
-	resolvedModelName := util.ResolveAutoModel(modelName)
+func (h *BaseAPIHandler) getRequestDetails(ctx context.Context, modelName string) (providers []string, normalizedModel string, metadata map[string]any, err *interfaces.ErrorMessage) {
+	var policy *sdkaccess.KeyPolicy
+	if ginContext, ok := ctx.Value("gin").(*gin.Context); ok && ginContext != nil {
+		policy = clientPolicy(ginContext)
+	}
+
+	// Resolve "auto" model to an actual available model first, then route tenant-confined keys
+	// to their default prefix.
+	resolvedModelName := policy.ApplyDefaultPrefix(util.ResolveAutoModel(modelName))
 
 	// Normalize the model name to handle dynamic thinking suffixes before determining the provider.
 	normalizedModel, metadata = normalizeModelMetadata(resolvedModelName)
@@ -365,9 +391,269 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	// If it's a non-dynamic model, normalizedModel was set by normalizeModelMetadata.
 	// So, normalizedModel is already correctly set at this point.
 
+	hints, errHints := routingHintsFromContext(ctx)
+	if errHints != nil {
+		return nil, "", nil, errHints
+	}
+	if hints.Provider != "" {
+		if !containsProvider(providers, hints.Provider) {
+			return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("provider %s does not serve model %s", hints.Provider, modelName)}
+		}
+		providers = []string{hints.Provider}
+	}
+	if policy != nil {
+		if providers = policy.FilterProviders(providers); len(providers) == 0 {
+			return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("client key is not allowed to use the providers serving model %s", modelName)}
+		}
+		if errPrefix := policy.CheckPrefix(normalizedModel); errPrefix != nil {
+			return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: errPrefix}
+		}
+	}
+	metadata = hints.ApplyMetadata(metadata)
+	if prefixes := policy.Prefixes(); len(prefixes) > 0 {
+		if metadata == nil {
+			metadata = make(map[string]any, 1)
+		}
+		metadata[coreauth.AllowedPrefixesMetadataKey] = prefixes
+	}
+	if policy != nil {
+		if metadata == nil {
+			metadata = make(map[string]any, 1)
+		}
+		metadata[coreauth.ClientPolicyMetadataKey] = policy
+	}
+
 	return providers, normalizedModel, metadata, nil
 }
 
+// routingHintsFromContext reads the X-ProxyGate-* routing hint headers. Hints are only honoured
+// for admin client keys; other callers get a 403.
+func routingHintsFromContext(ctx context.Context) (coreauth.RoutingHints, *interfaces.ErrorMessage) {
+	ginContext, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginContext == nil || ginContext.Request == nil {
+		return coreauth.RoutingHints{}, nil
+	}
+	hints := coreauth.RoutingHintsFromRequest(ginContext.Request.Header)
+	if hints.Empty() {
+		return hints, nil
+	}
+	if !isAdminClient(ginContext) {
+		return coreauth.RoutingHints{}, &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: fmt.Errorf("routing hint headers require an admin client key")}
+	}
+	return hints, nil
+}
+
+// CheckClientPolicy verifies that the calling key's policy allows a request for modelName and
+// that the key is within its rate limits. It returns a 403 or 429 error message otherwise.
+// Handlers call it before executing so they can render the error in their own format.
+func (h *BaseAPIHandler) CheckClientPolicy(c *gin.Context, modelName string) *interfaces.ErrorMessage {
+	if errMsg := checkKeyPolicy(c, modelName); errMsg != nil {
+		return errMsg
+	}
+	if errMsg := checkClientBudget(c); errMsg != nil {
+		return errMsg
+	}
+	return checkClientRateLimit(c)
+}
+
+// checkClientBudget rejects the request once the calling key has used up a token budget.
+func checkClientBudget(c *gin.Context) *interfaces.ErrorMessage {
+	raw, _ := c.Get("apiKey")
+	key, _ := raw.(string)
+	now := time.Now()
+	decision, exceeded := budget.Default().Check(key, now)
+	if !exceeded {
+		return nil
+	}
+	headers := http.Header{}
+	headers.Set("Retry-After", strconv.FormatInt(max(ceilSeconds(decision.ResetAt.Sub(now)), 1), 10))
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      fmt.Errorf("client key %s token budget exhausted: %d of %d %s used, resets at %s", decision.Period, decision.Used, decision.Max, decision.Limit, decision.ResetAt.Format(time.RFC3339)),
+		Addon:      headers,
+	}
+}
+
+// checkClientRateLimit admits the request against the calling key's rate limits and reports
+// the remaining allowance in x-ratelimit-* headers.
+func checkClientRateLimit(c *gin.Context) *interfaces.ErrorMessage {
+	raw, _ := c.Get("apiKey")
+	key, _ := raw.(string)
+	decision, limited := clientlimit.Default().Allow(key, time.Now())
+	if !limited {
+		return nil
+	}
+	headers := http.Header{}
+	headers.Set("x-ratelimit-limit", strconv.Itoa(decision.Limit))
+	headers.Set("x-ratelimit-remaining", strconv.Itoa(decision.Remaining))
+	headers.Set("x-ratelimit-reset", strconv.FormatInt(ceilSeconds(decision.Reset), 10))
+	if decision.Allowed {
+		for name, values := range headers {
+			c.Header(name, values[0])
+		}
+		return nil
+	}
+	headers.Set("Retry-After", strconv.FormatInt(max(ceilSeconds(decision.RetryAfter), 1), 10))
+	unit := "requests"
+	if decision.Tokens {
+		unit = "tokens"
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      fmt.Errorf("client key rate limit exceeded: %d %s per minute", decision.Limit, unit),
+		Addon:      headers,
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+func checkKeyPolicy(c *gin.Context, modelName string) *interfaces.ErrorMessage {
+	policy := clientPolicy(c)
+	if policy == nil {
+		return nil
+	}
+	forbidden := func(err error) *interfaces.ErrorMessage {
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: err}
+	}
+	if err := policy.CheckActive(time.Now()); err != nil {
+		return forbidden(err)
+	}
+	resolved := policy.ApplyDefaultPrefix(util.ResolveAutoModel(modelName))
+	normalized, _ := normalizeModelMetadata(resolved)
+	if err := policy.CheckModel(resolved, normalized, modelName); err != nil {
+		return forbidden(err)
+	}
+	if len(policy.AllowedProviders) > 0 {
+		if providers := util.GetProviderName(normalized); len(providers) > 0 && len(policy.FilterProviders(providers)) == 0 {
+			return forbidden(fmt.Errorf("client key is not allowed to use the providers serving model %s", modelName))
+		}
+	}
+	return nil
+}
+
+// FilterModelsForClient drops the models the calling key cannot reach under its policy, so
+// model listings only advertise what the key may request. Entries are matched by "id", or by
+// "name" for Gemini listings.
+func (h *BaseAPIHandler) FilterModelsForClient(c *gin.Context, models []map[string]any) []map[string]any {
+	policy := clientPolicy(c)
+	if policy == nil {
+		return models
+	}
+	out := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		id, _ := model["id"].(string)
+		if id == "" {
+			name, _ := model["name"].(string)
+			id = strings.TrimPrefix(name, "models/")
+		}
+		if id == "" {
+			continue
+		}
+		bare := id
+		if _, rest, ok := strings.Cut(id, "/"); ok && policy.CheckPrefix(id) == nil {
+			bare = rest
+		}
+		if policy.CheckModel(id, bare) != nil {
+			continue
+		}
+		if len(policy.AllowedProviders) > 0 && len(policy.FilterProviders(util.GetProviderName(id))) == 0 {
+			continue
+		}
+		out = append(out, model)
+	}
+	return out
+}
+
+// clientPolicy returns the key policy the access provider attached to the caller, if any.
+func clientPolicy(c *gin.Context) *sdkaccess.KeyPolicy {
+	metadata := accessMetadata(c)
+	if metadata == nil {
+		return nil
+	}
+	policy, _ := sdkaccess.PolicyFromMetadata(metadata)
+	return policy
+}
+
+func accessMetadata(c *gin.Context) map[string]string {
+	raw, exists := c.Get("accessMetadata")
+	if !exists {
+		return nil
+	}
+	metadata, _ := raw.(map[string]string)
+	return metadata
+}
+
+// isAdminClient reports whether the access provider granted the caller admin privileges.
+func isAdminClient(c *gin.Context) bool {
+	return accessMetadata(c)[sdkaccess.MetadataAdmin] == "true"
+}
+
+func containsProvider(providers []string, provider string) bool {
+	for _, candidate := range providers {
+		if strings.EqualFold(candidate, provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// withSessionKey attaches the session affinity key derived from the inbound request
+// so the auth manager can keep a conversation on the same credential.
+func (h *BaseAPIHandler) withSessionKey(ctx context.Context, rawJSON []byte, meta map[string]any) map[string]any {
+	if !h.AuthManager.SessionAffinityEnabled() {
+		return meta
+	}
+	var headers http.Header
+	var clientKey string
+	if ginContext, ok := ctx.Value("gin").(*gin.Context); ok && ginContext != nil {
+		if ginContext.Request != nil {
+			headers = ginContext.Request.Header
+		}
+		clientKey = ginContext.GetString("apiKey")
+	}
+	key := coreauth.SessionKeyFromRequest(headers, rawJSON, clientKey)
+	if key == "" {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]any, 1)
+	}
+	meta[coreauth.SessionKeyMetadataKey] = key
+	return meta
+}
+
+// labelRequestMetrics tags the request metrics with the inbound format, the requested model and
+// the provider that served it, or the only candidate provider when routing never got that far.
+func labelRequestMetrics(ctx context.Context, handlerType, model string, providers []string, route *coreauth.RouteInfo) {
+	provider := route.Result().Provider
+	if provider == "" && len(providers) == 1 {
+		provider = providers[0]
+	}
+	metrics.LabelRequest(ctx, handlerType, model, provider)
+}
+
+// writeRouteHeaders echoes routing decisions, such as a model fallback, as response headers.
+func writeRouteHeaders(ctx context.Context, route *coreauth.RouteInfo) {
+	ginContext, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginContext == nil {
+		return
+	}
+	result := route.Result()
+	if result.Fallback() {
+		ginContext.Header(coreauth.FallbackModelHeader, result.Model)
+	}
+	if result.AuthID != "" && isAdminClient(ginContext) {
+		ginContext.Header(coreauth.ProviderHintHeader, result.Provider)
+		ginContext.Header(coreauth.AuthHintHeader, result.AuthID)
+		ginContext.Header(coreauth.AuthIndexHeader, strconv.FormatUint(result.AuthIndex, 10))
+	}
+}
+
 func cloneBytes(src []byte) []byte {
 	if len(src) == 0 {
 		return nil
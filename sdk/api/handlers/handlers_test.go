@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/radityprtama/proxygate/v6/internal/registry"
+	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
+	coreauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+	"github.com/radityprtama/proxygate/v6/sdk/config"
+)
+
+type stubExecutor struct {
+	provider string
+	calls    *int
+}
+
+func (e stubExecutor) Identifier() string { return e.provider }
+
+func (e stubExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	*e.calls++
+	return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (e stubExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (<-chan coreexecutor.StreamChunk, error) {
+	ch := make(chan coreexecutor.StreamChunk)
+	close(ch)
+	return ch, nil
+}
+
+func (e stubExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e stubExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func TestExecuteWithAuthManager_FallbackRespectsKeyPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("policy-primary", "claude", []*registry.ModelInfo{{ID: "policy-test-opus"}})
+	reg.RegisterClient("policy-secondary", "gemini", []*registry.ModelInfo{{ID: "policy-test-pro"}})
+	defer reg.UnregisterClient("policy-primary")
+	defer reg.UnregisterClient("policy-secondary")
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.SetModelFallbacks(map[string][]string{"policy-test-opus": {"policy-test-pro"}})
+	var primaryCalls, fallbackCalls int
+	manager.RegisterExecutor(stubExecutor{provider: "claude", calls: &primaryCalls})
+	manager.RegisterExecutor(stubExecutor{provider: "gemini", calls: &fallbackCalls})
+	cooling := &coreauth.Auth{ID: "policy-primary", Provider: "claude", ModelStates: map[string]*coreauth.ModelState{
+		"policy-test-opus": {
+			Unavailable:    true,
+			NextRetryAfter: time.Now().Add(time.Hour),
+			Quota:          coreauth.QuotaState{Exceeded: true},
+		},
+	}}
+	if _, err := manager.Register(ctx, cooling); err != nil {
+		t.Fatalf("register primary: %v", err)
+	}
+	if _, err := manager.Register(ctx, &coreauth.Auth{ID: "policy-secondary", Provider: "gemini"}); err != nil {
+		t.Fatalf("register secondary: %v", err)
+	}
+	h := NewBaseAPIHandlers(&config.SDKConfig{}, manager)
+
+	execute := func(policy *sdkaccess.KeyPolicy) *int {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if policy != nil {
+			c.Set("accessMetadata", map[string]string{sdkaccess.MetadataPolicy: policy.Encode()})
+		}
+		before := fallbackCalls
+		_, _ = h.ExecuteWithAuthManager(context.WithValue(ctx, "gin", c), "openai", "policy-test-opus", []byte(`{"model":"policy-test-opus"}`), "")
+		served := fallbackCalls - before
+		return &served
+	}
+
+	if served := execute(&sdkaccess.KeyPolicy{AllowedModels: []string{"policy-test-opus"}}); *served != 0 {
+		t.Fatalf("restricted key fell back to a model outside its policy")
+	}
+	if served := execute(&sdkaccess.KeyPolicy{AllowedModels: []string{"policy-test-*"}, AllowedProviders: []string{"claude"}}); *served != 0 {
+		t.Fatalf("restricted key fell back to a provider outside its policy")
+	}
+	if served := execute(nil); *served != 1 {
+		t.Fatalf("unrestricted key should fall back, served %d", *served)
+	}
+	if primaryCalls != 0 {
+		t.Fatalf("cooling credential was called %d times", primaryCalls)
+	}
+}
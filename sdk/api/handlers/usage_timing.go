@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+)
+
+// usageTimingKey stores the request's usage timing in the gin context.
+const usageTimingKey = "usageTiming"
+
+// UsageTimingMiddleware times each request it wraps for its usage records: it notes when the
+// first response byte reaches the client and, once the handler returns, publishes the usage
+// records held for the request with their final timings, route and response status.
+func UsageTimingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timing := coreusage.NewTiming(time.Now())
+		c.Set(usageTimingKey, timing)
+		c.Writer = &usageTimingWriter{ResponseWriter: c.Writer, timing: timing}
+		defer func() {
+			timing.SetOutcome(requestEndpoint(c), c.Writer.Status())
+			timing.Finish()
+		}()
+		c.Next()
+	}
+}
+
+// requestEndpoint names the route of c as "METHOD /path".
+func requestEndpoint(c *gin.Context) string {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	return c.Request.Method + " " + path
+}
+
+// usageTimingFromGin returns the timing installed by UsageTimingMiddleware, or nil.
+func usageTimingFromGin(c *gin.Context) *coreusage.Timing {
+	if c == nil {
+		return nil
+	}
+	raw, ok := c.Get(usageTimingKey)
+	if !ok {
+		return nil
+	}
+	timing, _ := raw.(*coreusage.Timing)
+	return timing
+}
+
+// usageTimingWriter marks the first chunk written to the client.
+type usageTimingWriter struct {
+	gin.ResponseWriter
+	timing *coreusage.Timing
+}
+
+func (w *usageTimingWriter) Write(data []byte) (int, error) {
+	if len(data) > 0 {
+		w.timing.MarkClientFirstChunk()
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *usageTimingWriter) WriteString(s string) (int, error) {
+	if len(s) > 0 {
+		w.timing.MarkClientFirstChunk()
+	}
+	return w.ResponseWriter.WriteString(s)
+}
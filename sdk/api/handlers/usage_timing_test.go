@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
+)
+
+type endpointRecorder struct {
+	mu      sync.Mutex
+	records map[string]coreusage.Record
+	started chan struct{}
+	done    chan struct{}
+}
+
+func (p *endpointRecorder) HandleUsage(ctx context.Context, record coreusage.Record) {
+	p.started <- struct{}{}
+	// Plugins run after the request, while gin may already serve the next one with the same
+	// pooled context; reading it here races with that request.
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		for deadline := time.Now().Add(20 * time.Millisecond); time.Now().Before(deadline); {
+			_ = ginCtx.Writer.Status()
+			_ = ginCtx.FullPath()
+		}
+	}
+	p.mu.Lock()
+	p.records[record.Endpoint] = record
+	p.mu.Unlock()
+	p.done <- struct{}{}
+}
+
+func TestUsageTimingMiddleware_StampsOutcomeBeforeReleasingContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreusage.NewManager(0)
+	plugin := &endpointRecorder{records: make(map[string]coreusage.Record), started: make(chan struct{}, 2), done: make(chan struct{}, 2)}
+	manager.Register(plugin)
+	defer manager.Stop()
+
+	publish := func(status int) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			ctx := coreusage.WithTiming(context.WithValue(c.Request.Context(), "gin", c), usageTimingFromGin(c))
+			manager.Publish(ctx, coreusage.Record{Model: "m"})
+			c.Status(status)
+		}
+	}
+	engine := gin.New()
+	engine.Use(UsageTimingMiddleware())
+	engine.GET("/ok", publish(http.StatusOK))
+	engine.GET("/fail", publish(http.StatusBadGateway))
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	select {
+	case <-plugin.started:
+	case <-time.After(time.Second):
+		t.Fatal("usage record for /ok not delivered")
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	for i := 0; i < 2; i++ {
+		select {
+		case <-plugin.done:
+		case <-time.After(time.Second):
+			t.Fatal("usage records not delivered")
+		}
+	}
+
+	plugin.mu.Lock()
+	defer plugin.mu.Unlock()
+	if record, ok := plugin.records["GET /ok"]; !ok || record.Failed {
+		t.Fatalf("unexpected record for /ok: %+v (found %v)", record, ok)
+	}
+	if record, ok := plugin.records["GET /fail"]; !ok || !record.Failed {
+		t.Fatalf("unexpected record for /fail: %+v (found %v)", record, ok)
+	}
+}
@@ -151,6 +151,63 @@ func (s *FileTokenStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// stateFileName returns the auth-directory file holding a named state document. The name lacks
+// a .json suffix so the watcher and List never mistake it for a credential.
+func stateFileName(name string) string {
+	return "." + name
+}
+
+// LoadState reads a named state document from the auth directory.
+func (s *FileTokenStore) LoadState(_ context.Context, name string) ([]byte, error) {
+	dir := s.baseDirSnapshot()
+	if dir == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName(name)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("auth filestore: read %s failed: %w", name, err)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// SaveState writes a named state document to the auth directory.
+func (s *FileTokenStore) SaveState(_ context.Context, name string, data []byte) error {
+	dir := s.baseDirSnapshot()
+	if dir == "" {
+		return fmt.Errorf("auth filestore: directory not configured")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("auth filestore: create dir failed: %w", err)
+	}
+	path := filepath.Join(dir, stateFileName(name))
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("auth filestore: write %s failed: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("auth filestore: rename %s failed: %w", name, err)
+	}
+	return nil
+}
+
+// LoadRuntimeStates reads persisted cooldowns and model states from the auth directory.
+func (s *FileTokenStore) LoadRuntimeStates(ctx context.Context) (map[string]*cliproxyauth.RuntimeState, error) {
+	return cliproxyauth.LoadRuntimeStatesFrom(ctx, s)
+}
+
+// SaveRuntimeStates writes cooldowns and model states to the auth directory.
+func (s *FileTokenStore) SaveRuntimeStates(ctx context.Context, states map[string]*cliproxyauth.RuntimeState) error {
+	return cliproxyauth.SaveRuntimeStatesTo(ctx, s, states)
+}
+
 func (s *FileTokenStore) resolveDeletePath(id string) (string, error) {
 	if strings.ContainsRune(id, os.PathSeparator) || filepath.IsAbs(id) {
 		return id, nil
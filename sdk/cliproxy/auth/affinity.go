@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// SessionKeyMetadataKey carries the derived session key in execution options metadata.
+const SessionKeyMetadataKey = "session_key"
+
+// SessionIDHeader lets clients pin a conversation to one credential explicitly.
+const SessionIDHeader = "X-Session-Id"
+
+// DefaultSessionAffinityTTL bounds how long an idle session stays pinned.
+const DefaultSessionAffinityTTL = time.Hour
+
+// sessionLeadingMessages is the number of leading messages hashed when no explicit key exists.
+// Only the opening turn is stable across a conversation, so later turns map to the same key.
+// Payloads with no more than this many messages are one-shot requests and get no key, so
+// unrelated requests that happen to share an opening prompt are not pinned together.
+const sessionLeadingMessages = 1
+
+// affinitySweepThreshold triggers a sweep of expired pins once the table grows past it.
+const affinitySweepThreshold = 4096
+
+// SessionKeyFromRequest derives a stable session key for credential affinity. Sources are tried
+// in order: the X-Session-Id header, the Codex prompt_cache_key, the Claude metadata.user_id,
+// and finally a hash of the calling client key, the system prompt and the opening conversation
+// message of a multi-turn conversation.
+func SessionKeyFromRequest(headers http.Header, payload []byte, clientKey string) string {
+	if headers != nil {
+		if v := strings.TrimSpace(headers.Get(SessionIDHeader)); v != "" {
+			return "header:" + v
+		}
+	}
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return ""
+	}
+	if v := strings.TrimSpace(gjson.GetBytes(payload, "prompt_cache_key").String()); v != "" {
+		return "codex:" + v
+	}
+	if v := strings.TrimSpace(gjson.GetBytes(payload, "metadata.user_id").String()); v != "" {
+		return "claude:" + v
+	}
+	return leadingMessagesKey(payload, clientKey)
+}
+
+// leadingMessagesKey hashes the client key, the system prompt and the opening conversation turn
+// across the OpenAI/Claude (messages), Responses (input) and Gemini (contents) schemas.
+func leadingMessagesKey(payload []byte, clientKey string) string {
+	var messages gjson.Result
+	for _, path := range []string{"messages", "input", "contents", "request.contents"} {
+		if candidate := gjson.GetBytes(payload, path); candidate.IsArray() {
+			messages = candidate
+			break
+		}
+	}
+	if !messages.Exists() {
+		return ""
+	}
+	items := messages.Array()
+	if len(items) <= sessionLeadingMessages {
+		return ""
+	}
+	items = items[:sessionLeadingMessages]
+	hasher := sha256.New()
+	hasher.Write([]byte(clientKey))
+	for _, path := range []string{"system", "instructions", "systemInstruction", "system_instruction", "request.systemInstruction"} {
+		if system := gjson.GetBytes(payload, path); system.Exists() {
+			hasher.Write([]byte(system.Raw))
+			break
+		}
+	}
+	for _, item := range items {
+		hasher.Write([]byte{0})
+		hasher.Write([]byte(item.Raw))
+	}
+	return "hash:" + hex.EncodeToString(hasher.Sum(nil))[:32]
+}
+
+// sessionKeyFromMetadata extracts the session key attached by the API handlers.
+func sessionKeyFromMetadata(meta map[string]any) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	if v, ok := meta[SessionKeyMetadataKey].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+type affinityEntry struct {
+	authID  string
+	expires time.Time
+}
+
+// sessionAffinity pins session keys to auth IDs with a sliding TTL.
+type sessionAffinity struct {
+	mu      sync.Mutex
+	enabled bool
+	ttl     time.Duration
+	entries map[string]affinityEntry
+}
+
+func newSessionAffinity() *sessionAffinity {
+	return &sessionAffinity{ttl: DefaultSessionAffinityTTL, entries: make(map[string]affinityEntry)}
+}
+
+func (a *sessionAffinity) configure(enabled bool, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultSessionAffinityTTL
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enabled = enabled
+	a.ttl = ttl
+	if !enabled {
+		a.entries = make(map[string]affinityEntry)
+	}
+}
+
+func (a *sessionAffinity) active() bool {
+	if a == nil {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enabled
+}
+
+// lookup returns the auth ID pinned to key when the pin has not expired.
+func (a *sessionAffinity) lookup(key string, now time.Time) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, ok := a.entries[key]
+	if !ok {
+		return ""
+	}
+	if !entry.expires.After(now) {
+		delete(a.entries, key)
+		return ""
+	}
+	return entry.authID
+}
+
+// pin records or refreshes the auth ID for key.
+func (a *sessionAffinity) pin(key, authID string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.enabled {
+		return
+	}
+	if len(a.entries) >= affinitySweepThreshold {
+		for k, entry := range a.entries {
+			if !entry.expires.After(now) {
+				delete(a.entries, k)
+			}
+		}
+	}
+	a.entries[key] = affinityEntry{authID: authID, expires: now.Add(a.ttl)}
+}
+
+// SetSessionAffinity enables or disables sticky session routing. Pinned sessions expire after
+// ttl without traffic; a non-positive ttl selects DefaultSessionAffinityTTL.
+func (m *Manager) SetSessionAffinity(enabled bool, ttl time.Duration) {
+	if m == nil || m.affinity == nil {
+		return
+	}
+	m.affinity.configure(enabled, ttl)
+}
+
+// SessionAffinityEnabled reports whether sticky session routing is active.
+func (m *Manager) SessionAffinityEnabled() bool {
+	if m == nil {
+		return false
+	}
+	return m.affinity.active()
+}
+
+// pickPinned returns the pinned candidate for the session when it is still usable for the model.
+func (m *Manager) pickPinned(key, model string, candidates []*Auth, now time.Time) *Auth {
+	authID := m.affinity.lookup(key, now)
+	if authID == "" {
+		return nil
+	}
+	for _, candidate := range candidates {
+		if candidate.ID != authID {
+			continue
+		}
+		if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+			return nil
+		}
+		if predictedExhausted(candidate, model, now) {
+			return nil
+		}
+		return candidate
+	}
+	return nil
+}
+
+func affinityKey(provider, model, sessionKey string) string {
+	return provider + ":" + model + ":" + sessionKey
+}
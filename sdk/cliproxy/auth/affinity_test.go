@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+func TestSessionKeyFromRequest_Precedence(t *testing.T) {
+	headers := http.Header{}
+	headers.Set(SessionIDHeader, "abc")
+	payload := []byte(`{"prompt_cache_key":"pck","metadata":{"user_id":"u1"},"messages":[{"role":"user","content":"hi"}]}`)
+
+	if got := SessionKeyFromRequest(headers, payload, ""); got != "header:abc" {
+		t.Fatalf("expected header key, got %q", got)
+	}
+	if got := SessionKeyFromRequest(nil, payload, ""); got != "codex:pck" {
+		t.Fatalf("expected codex key, got %q", got)
+	}
+	if got := SessionKeyFromRequest(nil, []byte(`{"metadata":{"user_id":"u1"}}`), ""); got != "claude:u1" {
+		t.Fatalf("expected claude key, got %q", got)
+	}
+
+	if got := SessionKeyFromRequest(nil, []byte(`{"system":"s","messages":[{"role":"user","content":"hi"}]}`), "key-a"); got != "" {
+		t.Fatalf("expected no hash key for a single-turn request, got %q", got)
+	}
+	second := SessionKeyFromRequest(nil, []byte(`{"system":"s","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"yo"}]}`), "key-a")
+	later := SessionKeyFromRequest(nil, []byte(`{"system":"s","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"yo"},{"role":"user","content":"more"}]}`), "key-a")
+	if !strings.HasPrefix(second, "hash:") || second != later {
+		t.Fatalf("expected stable hash key across turns, got %q and %q", second, later)
+	}
+	other := SessionKeyFromRequest(nil, []byte(`{"system":"s","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"yo"}]}`), "key-b")
+	if other == second {
+		t.Fatalf("expected different client keys to get different hash keys")
+	}
+}
+
+func TestManagerSessionAffinity_PinsAndFallsBack(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(stubExecutor{provider: "claude"})
+	m.SetSessionAffinity(true, time.Minute)
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := m.Register(ctx, &Auth{ID: id, Provider: "claude"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{SessionKeyMetadataKey: "header:s1"}}
+
+	first, _, err := m.pickNext(ctx, "claude", "", opts, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("pick: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		next, _, errPick := m.pickNext(ctx, "claude", "", opts, map[string]struct{}{})
+		if errPick != nil {
+			t.Fatalf("pick: %v", errPick)
+		}
+		if next.ID != first.ID {
+			t.Fatalf("expected session pinned to %s, got %s", first.ID, next.ID)
+		}
+	}
+
+	pinned, _ := m.GetByID(first.ID)
+	pinned.Unavailable = true
+	pinned.NextRetryAfter = time.Now().Add(time.Minute)
+	pinned.Quota = QuotaState{Exceeded: true, NextRecoverAt: pinned.NextRetryAfter}
+	if _, err = m.Update(ctx, pinned); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	moved, _, err := m.pickNext(ctx, "claude", "", opts, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("pick: %v", err)
+	}
+	if moved.ID == first.ID {
+		t.Fatalf("expected fallback away from cooling auth %s", first.ID)
+	}
+	again, _, err := m.pickNext(ctx, "claude", "", opts, map[string]struct{}{})
+	if err != nil {
+		t.Fatalf("pick: %v", err)
+	}
+	if again.ID != moved.ID {
+		t.Fatalf("expected session re-pinned to %s, got %s", moved.ID, again.ID)
+	}
+}
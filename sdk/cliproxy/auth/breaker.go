@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Circuit breaker states.
+const (
+	BreakerStateClosed   = "closed"
+	BreakerStateOpen     = "open"
+	BreakerStateHalfOpen = "half-open"
+)
+
+// transportErrorCode tags executor failures where no response arrived from the provider.
+const transportErrorCode = "transport_error"
+
+// Default circuit breaker settings applied when a value is not configured.
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerOpenDuration     = 30 * time.Second
+	DefaultBreakerHalfOpenProbes   = 1
+)
+
+// BreakerSettings controls the provider-level circuit breaker.
+type BreakerSettings struct {
+	// Enabled turns the breaker on.
+	Enabled bool
+	// FailureThreshold is the number of consecutive upstream failures that opens the breaker.
+	FailureThreshold int
+	// OpenDuration is how long the breaker stays open before admitting probes.
+	OpenDuration time.Duration
+	// HalfOpenProbes caps concurrent probe requests while half-open.
+	HalfOpenProbes int
+}
+
+func (s BreakerSettings) normalized() BreakerSettings {
+	if s.FailureThreshold <= 0 {
+		s.FailureThreshold = DefaultBreakerFailureThreshold
+	}
+	if s.OpenDuration <= 0 {
+		s.OpenDuration = DefaultBreakerOpenDuration
+	}
+	if s.HalfOpenProbes <= 0 {
+		s.HalfOpenProbes = DefaultBreakerHalfOpenProbes
+	}
+	return s
+}
+
+// BreakerStatus is a point-in-time view of one provider breaker.
+type BreakerStatus struct {
+	Provider            string    `json:"provider"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	ProbesInFlight      int       `json:"probes_in_flight"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+	LastFailureAt       time.Time `json:"last_failure_at,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	Trips               int64     `json:"trips"`
+}
+
+type providerBreaker struct {
+	state          string
+	failures       int
+	probesInFlight int
+	openedAt       time.Time
+	lastFailureAt  time.Time
+	lastError      string
+	trips          int64
+}
+
+// breakerRegistry tracks one circuit breaker per provider.
+type breakerRegistry struct {
+	mu       sync.Mutex
+	settings BreakerSettings
+	entries  map[string]*providerBreaker
+}
+
+func newBreakerRegistry() *breakerRegistry {
+	return &breakerRegistry{settings: BreakerSettings{}.normalized(), entries: make(map[string]*providerBreaker)}
+}
+
+func (r *breakerRegistry) configure(settings BreakerSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings = settings.normalized()
+	if !settings.Enabled {
+		r.entries = make(map[string]*providerBreaker)
+	}
+}
+
+func (r *breakerRegistry) entryLocked(provider string) *providerBreaker {
+	entry := r.entries[provider]
+	if entry == nil {
+		entry = &providerBreaker{state: BreakerStateClosed}
+		r.entries[provider] = entry
+	}
+	return entry
+}
+
+// allow reports whether a request may be sent to provider. While half-open it admits at most
+// HalfOpenProbes concurrent probes; the returned function must be called when the attempt ends.
+func (r *breakerRegistry) allow(provider string, now time.Time) (bool, func()) {
+	noop := func() {}
+	if r == nil {
+		return true, noop
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.settings.Enabled {
+		return true, noop
+	}
+	entry := r.entries[provider]
+	if entry == nil || entry.state == BreakerStateClosed {
+		return true, noop
+	}
+	if entry.state == BreakerStateOpen {
+		if now.Before(entry.openedAt.Add(r.settings.OpenDuration)) {
+			return false, noop
+		}
+		entry.state = BreakerStateHalfOpen
+		entry.probesInFlight = 0
+	}
+	if entry.probesInFlight >= r.settings.HalfOpenProbes {
+		return false, noop
+	}
+	entry.probesInFlight++
+	var once sync.Once
+	return true, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			if current := r.entries[provider]; current == entry && entry.probesInFlight > 0 {
+				entry.probesInFlight--
+			}
+			r.mu.Unlock()
+		})
+	}
+}
+
+// record folds an execution outcome into the provider breaker. Only upstream failures (5xx,
+// 408 and transport errors without a status) count; credential-specific errors such as 401 or
+// 429, and local failures that never reached the provider, are left alone.
+func (r *breakerRegistry) record(provider string, success bool, err *Error, now time.Time) {
+	if r == nil || provider == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.settings.Enabled {
+		return
+	}
+	if success {
+		if entry := r.entries[provider]; entry != nil {
+			entry.state = BreakerStateClosed
+			entry.failures = 0
+		}
+		return
+	}
+	if !isUpstreamFailure(err) {
+		return
+	}
+	entry := r.entryLocked(provider)
+	entry.failures++
+	entry.lastFailureAt = now
+	entry.lastError = breakerMessage(err)
+	switch entry.state {
+	case BreakerStateHalfOpen:
+		entry.state = BreakerStateOpen
+		entry.openedAt = now
+		entry.trips++
+	case BreakerStateClosed:
+		if entry.failures >= r.settings.FailureThreshold {
+			entry.state = BreakerStateOpen
+			entry.openedAt = now
+			entry.trips++
+		}
+	}
+}
+
+// isUpstreamFailure reports whether err points at the provider rather than the credential or
+// the proxy itself. Errors without a status count only when tagged as transport errors.
+func isUpstreamFailure(err *Error) bool {
+	status := statusCodeFromResult(err)
+	if status == 0 {
+		return err != nil && err.Code == transportErrorCode
+	}
+	return status >= http.StatusInternalServerError || status == http.StatusRequestTimeout
+}
+
+// isTransportError reports whether err means no response arrived from the provider: a dial,
+// TLS or timeout error, a reset connection or a body cut short.
+func isTransportError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (r *breakerRegistry) reset(provider string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if provider == "" {
+		r.entries = make(map[string]*providerBreaker)
+		return true
+	}
+	if _, ok := r.entries[provider]; !ok {
+		return false
+	}
+	delete(r.entries, provider)
+	return true
+}
+
+func (r *breakerRegistry) snapshot(now time.Time) []BreakerStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]BreakerStatus, 0, len(r.entries))
+	for provider, entry := range r.entries {
+		state := entry.state
+		status := BreakerStatus{
+			Provider:            provider,
+			State:               state,
+			ConsecutiveFailures: entry.failures,
+			ProbesInFlight:      entry.probesInFlight,
+			OpenedAt:            entry.openedAt,
+			LastFailureAt:       entry.lastFailureAt,
+			LastError:           entry.lastError,
+			Trips:               entry.trips,
+		}
+		if state == BreakerStateOpen {
+			status.RetryAt = entry.openedAt.Add(r.settings.OpenDuration)
+			if !now.Before(status.RetryAt) {
+				status.State = BreakerStateHalfOpen
+			}
+		}
+		out = append(out, status)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+func breakerMessage(err *Error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Message
+}
+
+func newCircuitOpenError(provider string) *Error {
+	return &Error{
+		Code:       "circuit_open",
+		Message:    fmt.Sprintf("circuit breaker open for provider %s", provider),
+		Retryable:  true,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
+
+// SetBreakerSettings configures the provider circuit breaker. Disabling it clears all state.
+func (m *Manager) SetBreakerSettings(settings BreakerSettings) {
+	if m == nil || m.breakers == nil {
+		return
+	}
+	m.breakers.configure(settings)
+}
+
+// BreakerStatuses returns the state of every provider breaker that has recorded failures.
+func (m *Manager) BreakerStatuses() []BreakerStatus {
+	if m == nil || m.breakers == nil {
+		return nil
+	}
+	return m.breakers.snapshot(time.Now())
+}
+
+// ResetBreaker closes the breaker for provider, or every breaker when provider is empty.
+// It reports whether a matching breaker existed.
+func (m *Manager) ResetBreaker(provider string) bool {
+	if m == nil || m.breakers == nil {
+		return false
+	}
+	return m.breakers.reset(strings.ToLower(strings.TrimSpace(provider)))
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+func TestBreakerRegistry_OpensProbesAndCloses(t *testing.T) {
+	r := newBreakerRegistry()
+	r.configure(BreakerSettings{Enabled: true, FailureThreshold: 2, OpenDuration: time.Minute, HalfOpenProbes: 1})
+	now := time.Now()
+
+	r.record("claude", false, &Error{Message: "rate limited", HTTPStatus: http.StatusTooManyRequests}, now)
+	r.record("claude", false, &Error{Message: "bad gateway", HTTPStatus: http.StatusBadGateway}, now)
+	if ok, _ := r.allow("claude", now); !ok {
+		t.Fatalf("expected breaker closed below threshold")
+	}
+	r.record("claude", false, &Error{Message: "bad gateway", HTTPStatus: http.StatusBadGateway}, now)
+	if ok, _ := r.allow("claude", now.Add(time.Second)); ok {
+		t.Fatalf("expected breaker open after threshold")
+	}
+
+	later := now.Add(2 * time.Minute)
+	ok, release := r.allow("claude", later)
+	if !ok {
+		t.Fatalf("expected half-open probe to be admitted")
+	}
+	if second, _ := r.allow("claude", later); second {
+		t.Fatalf("expected probe limit to reject a second concurrent probe")
+	}
+	release()
+	r.record("claude", false, &Error{Message: "unavailable", HTTPStatus: http.StatusServiceUnavailable}, later)
+	if ok, _ = r.allow("claude", later.Add(time.Second)); ok {
+		t.Fatalf("expected failed probe to reopen the breaker")
+	}
+
+	ok, release = r.allow("claude", later.Add(2*time.Minute))
+	if !ok {
+		t.Fatalf("expected probe after reopen window")
+	}
+	release()
+	r.record("claude", true, nil, later.Add(2*time.Minute))
+	statuses := r.snapshot(later.Add(2 * time.Minute))
+	if len(statuses) != 1 || statuses[0].State != BreakerStateClosed || statuses[0].Trips != 2 {
+		t.Fatalf("unexpected breaker snapshot: %+v", statuses)
+	}
+}
+
+func TestManagerMarkResult_CountsTransportErrorsButNotCancellations(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetBreakerSettings(BreakerSettings{Enabled: true, FailureThreshold: 1, OpenDuration: time.Minute})
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	m.MarkResult(canceled, Result{AuthID: "a", Provider: "claude", Error: &Error{Message: "context canceled"}})
+	if ok, _ := m.breakers.allow("claude", time.Now()); !ok {
+		t.Fatalf("expected a client cancellation to leave the breaker closed")
+	}
+	m.MarkResult(context.Background(), Result{AuthID: "a", Provider: "claude", Error: executorError(errors.New("translate request: unsupported content part"))})
+	if ok, _ := m.breakers.allow("claude", time.Now()); !ok {
+		t.Fatalf("expected a local error without a status to leave the breaker closed")
+	}
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	m.MarkResult(context.Background(), Result{AuthID: "a", Provider: "claude", Error: executorError(dialErr)})
+	if ok, _ := m.breakers.allow("claude", time.Now()); ok {
+		t.Fatalf("expected a transport error to open the breaker")
+	}
+}
+
+func TestManagerExecute_SkipsOpenProvider(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(nil, nil, nil)
+	m.SetBreakerSettings(BreakerSettings{Enabled: true, FailureThreshold: 1, OpenDuration: time.Minute})
+	var claudeCalls atomic.Int32
+	m.RegisterExecutor(stubExecutor{provider: "claude", execute: func(context.Context, *Auth, cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		claudeCalls.Add(1)
+		return cliproxyexecutor.Response{}, nil
+	}})
+	m.RegisterExecutor(stubExecutor{provider: "gemini"})
+	if _, err := m.Register(ctx, &Auth{ID: "c1", Provider: "claude"}); err != nil {
+		t.Fatalf("register claude: %v", err)
+	}
+	if _, err := m.Register(ctx, &Auth{ID: "g1", Provider: "gemini"}); err != nil {
+		t.Fatalf("register gemini: %v", err)
+	}
+	m.MarkResult(ctx, Result{AuthID: "c1", Provider: "claude", Error: &Error{Message: "boom", HTTPStatus: http.StatusInternalServerError}})
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.Execute(ctx, []string{"claude", "gemini"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+	}
+	if claudeCalls.Load() != 0 {
+		t.Fatalf("expected open provider to be skipped, got %d calls", claudeCalls.Load())
+	}
+	if !m.ResetBreaker("Claude") {
+		t.Fatalf("expected reset to find the claude breaker")
+	}
+	if len(m.BreakerStatuses()) != 0 {
+		t.Fatalf("expected no breakers after reset")
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/radityprtama/proxygate/v6/internal/util"
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// SetModelFallbacks replaces the model fallback chains. Each key maps a requested model to the
+// ordered list of substitutes tried when every credential for it is unavailable.
+func (m *Manager) SetModelFallbacks(fallbacks map[string][]string) {
+	if m == nil {
+		return
+	}
+	cloned := make(map[string][]string, len(fallbacks))
+	for model, chain := range fallbacks {
+		key := strings.ToLower(strings.TrimSpace(model))
+		if key == "" || len(chain) == 0 {
+			continue
+		}
+		cloned[key] = append([]string(nil), chain...)
+	}
+	m.modelFallbacks.Store(cloned)
+}
+
+// fallbacksFor returns the configured fallback chain for model. A credential prefix on model
+// ("team/claude-opus-4-5") is stripped for the lookup and put back on each fallback, so the
+// request stays on the credentials of that prefix.
+func (m *Manager) fallbacksFor(model string) []string {
+	fallbacks, _ := m.modelFallbacks.Load().(map[string][]string)
+	if len(fallbacks) == 0 {
+		return nil
+	}
+	model = strings.TrimSpace(model)
+	if chain, ok := fallbacks[strings.ToLower(model)]; ok {
+		return chain
+	}
+	prefix, base, ok := strings.Cut(model, "/")
+	if !ok || prefix == "" {
+		return nil
+	}
+	chain := fallbacks[strings.ToLower(base)]
+	if len(chain) == 0 {
+		return nil
+	}
+	prefixed := make([]string, 0, len(chain))
+	for _, fallback := range chain {
+		prefixed = append(prefixed, prefix+"/"+strings.TrimSpace(fallback))
+	}
+	return prefixed
+}
+
+// shouldFallback reports whether err means the requested model is currently unservable:
+// every credential is cooling down or unavailable, or the upstream failed with a 5xx.
+func shouldFallback(err error) bool {
+	if err == nil {
+		return false
+	}
+	var cooldownErr *modelCooldownError
+	if errors.As(err, &cooldownErr) {
+		return true
+	}
+	var authErr *Error
+	if errors.As(err, &authErr) && authErr != nil {
+		switch authErr.Code {
+		case "auth_unavailable", "auth_not_found":
+			return true
+		}
+	}
+	return statusCodeFromError(err) >= http.StatusInternalServerError
+}
+
+// prepareFallback rewrites the request for a fallback model. The payload keeps the inbound
+// schema; the executor of the fallback provider re-translates it from opts.SourceFormat.
+// Fallbacks the calling client key may not reach are skipped.
+func prepareFallback(ctx context.Context, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, fallback string) (context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options, bool) {
+	model, thinking := util.NormalizeThinkingModel(strings.TrimSpace(fallback))
+	if model == "" {
+		return ctx, nil, req, opts, false
+	}
+	providers := util.GetProviderName(model)
+	if len(providers) == 0 {
+		log.Debugf("model fallback %s skipped: no provider available", model)
+		return ctx, nil, req, opts, false
+	}
+	if policy := clientPolicyFromMetadata(opts.Metadata); policy != nil {
+		if err := policy.CheckModel(model, fallback); err != nil {
+			log.Debugf("model fallback %s skipped: %v", model, err)
+			return ctx, nil, req, opts, false
+		}
+		if err := policy.CheckPrefix(model); err != nil {
+			log.Debugf("model fallback %s skipped: %v", model, err)
+			return ctx, nil, req, opts, false
+		}
+		if providers = policy.FilterProviders(providers); len(providers) == 0 {
+			log.Debugf("model fallback %s skipped: client key may not use its providers", model)
+			return ctx, nil, req, opts, false
+		}
+	}
+	fbReq := req
+	fbReq.Model = model
+	fbReq.Metadata = fallbackMetadata(req.Metadata, thinking)
+	fbReq.Payload = rewritePayloadModel(req.Payload, model)
+	fbOpts := opts
+	fbOpts.Metadata = fallbackMetadata(opts.Metadata, thinking)
+	fbOpts.OriginalRequest = rewritePayloadModel(opts.OriginalRequest, model)
+	return withRequestedModel(ctx, req.Model), providers, fbReq, fbOpts, true
+}
+
+// fallbackMetadata drops model-specific hints from the original request and applies the
+// thinking hints parsed from the fallback model name.
+func fallbackMetadata(src map[string]any, thinking map[string]any) map[string]any {
+	out := make(map[string]any, len(src)+len(thinking))
+	for k, v := range src {
+		switch k {
+		case util.ThinkingOriginalModelMetadataKey, util.GeminiOriginalModelMetadataKey:
+			continue
+		}
+		out[k] = v
+	}
+	for k, v := range thinking {
+		out[k] = v
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func rewritePayloadModel(payload []byte, model string) []byte {
+	if len(payload) == 0 || !gjson.GetBytes(payload, "model").Exists() {
+		return payload
+	}
+	updated, err := sjson.SetBytes(payload, "model", model)
+	if err != nil {
+		return payload
+	}
+	return updated
+}
+
+// executeWithFallbacks runs exec for the requested model and walks the configured fallback
+// chain when the model cannot be served. The original error is returned if every fallback fails.
+func executeWithFallbacks[T any](m *Manager, ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, exec func(context.Context, []string, cliproxyexecutor.Request, cliproxyexecutor.Options) (T, error)) (T, error) {
+	out, err := exec(ctx, providers, req, opts)
+	if err == nil || !shouldFallback(err) {
+		return out, err
+	}
+	for _, fallback := range m.fallbacksFor(req.Model) {
+		fbCtx, fbProviders, fbReq, fbOpts, ok := prepareFallback(ctx, req, opts, fallback)
+		if !ok || strings.EqualFold(fbReq.Model, req.Model) {
+			continue
+		}
+		log.Debugf("model %s unavailable (%v), falling back to %s", req.Model, err, fbReq.Model)
+		fbOut, errFallback := exec(fbCtx, fbProviders, fbReq, fbOpts)
+		if errFallback == nil {
+			return fbOut, nil
+		}
+		if errCtx := ctx.Err(); errCtx != nil {
+			return fbOut, errCtx
+		}
+	}
+	return out, err
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/radityprtama/proxygate/v6/internal/registry"
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+type stubStatusError struct{ code int }
+
+func (e stubStatusError) Error() string   { return http.StatusText(e.code) }
+func (e stubStatusError) StatusCode() int { return e.code }
+
+func TestManagerExecute_WalksModelFallbacks(t *testing.T) {
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("fallback-primary", "claude", []*registry.ModelInfo{{ID: "fallback-test-opus"}})
+	reg.RegisterClient("fallback-secondary", "gemini", []*registry.ModelInfo{{ID: "fallback-test-pro"}})
+	defer reg.UnregisterClient("fallback-primary")
+	defer reg.UnregisterClient("fallback-secondary")
+
+	m := NewManager(nil, nil, nil)
+	m.SetModelFallbacks(map[string][]string{"fallback-test-opus": {"fallback-test-pro"}})
+	m.RegisterExecutor(stubExecutor{provider: "claude", execute: func(context.Context, *Auth, cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		return cliproxyexecutor.Response{}, stubStatusError{code: http.StatusServiceUnavailable}
+	}})
+	var served cliproxyexecutor.Request
+	var requested string
+	m.RegisterExecutor(stubExecutor{provider: "gemini", execute: func(execCtx context.Context, _ *Auth, req cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		served = req
+		requested = RequestedModelFromContext(execCtx)
+		return cliproxyexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+	}})
+	if _, err := m.Register(ctx, &Auth{ID: "fallback-primary", Provider: "claude"}); err != nil {
+		t.Fatalf("register primary: %v", err)
+	}
+	if _, err := m.Register(ctx, &Auth{ID: "fallback-secondary", Provider: "gemini"}); err != nil {
+		t.Fatalf("register secondary: %v", err)
+	}
+
+	routeCtx, route := WithRouteInfo(ctx)
+	req := cliproxyexecutor.Request{Model: "fallback-test-opus", Payload: []byte(`{"model":"fallback-test-opus"}`)}
+	if _, err := m.Execute(routeCtx, []string{"claude"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if served.Model != "fallback-test-pro" {
+		t.Fatalf("expected fallback model, got %s", served.Model)
+	}
+	if got := gjson.GetBytes(served.Payload, "model").String(); got != "fallback-test-pro" {
+		t.Fatalf("expected payload model rewritten, got %s", got)
+	}
+	if requested != "fallback-test-opus" {
+		t.Fatalf("expected requested model in context, got %q", requested)
+	}
+	result := route.Result()
+	if !result.Fallback() || result.Model != "fallback-test-pro" || result.AuthID != "fallback-secondary" {
+		t.Fatalf("unexpected route result: %+v", result)
+	}
+}
+
+func TestManagerExecute_FallbackKeepsCredentialPrefix(t *testing.T) {
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("prefixed-primary", "claude", []*registry.ModelInfo{{ID: "team/prefixed-test-opus"}})
+	reg.RegisterClient("prefixed-secondary", "gemini", []*registry.ModelInfo{{ID: "team/prefixed-test-pro"}})
+	defer reg.UnregisterClient("prefixed-primary")
+	defer reg.UnregisterClient("prefixed-secondary")
+
+	m := NewManager(nil, nil, nil)
+	m.SetModelFallbacks(map[string][]string{"prefixed-test-opus": {"prefixed-test-pro"}})
+	m.RegisterExecutor(stubExecutor{provider: "claude", execute: func(context.Context, *Auth, cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		return cliproxyexecutor.Response{}, stubStatusError{code: http.StatusServiceUnavailable}
+	}})
+	var served cliproxyexecutor.Request
+	var servedBy string
+	m.RegisterExecutor(stubExecutor{provider: "gemini", execute: func(_ context.Context, auth *Auth, req cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		served, servedBy = req, auth.ID
+		return cliproxyexecutor.Response{}, nil
+	}})
+	for _, auth := range []*Auth{
+		{ID: "prefixed-primary", Provider: "claude", Prefix: "team"},
+		{ID: "prefixed-secondary", Provider: "gemini", Prefix: "team"},
+	} {
+		if _, err := m.Register(ctx, auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+	}
+
+	req := cliproxyexecutor.Request{Model: "team/prefixed-test-opus"}
+	if _, err := m.Execute(ctx, []string{"claude"}, req, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("expected prefixed fallback to succeed, got %v", err)
+	}
+	if servedBy != "prefixed-secondary" || served.Model != "prefixed-test-pro" {
+		t.Fatalf("expected team/prefixed-test-pro on the prefixed credential, got %s via %s", served.Model, servedBy)
+	}
+	if got := m.fallbacksFor("team/prefixed-test-opus"); len(got) != 1 || got[0] != "team/prefixed-test-pro" {
+		t.Fatalf("unexpected prefixed fallback chain %v", got)
+	}
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Routing hint headers let trusted clients steer credential selection for a single request.
+const (
+	// ProviderHintHeader restricts a request to one provider.
+	ProviderHintHeader = "X-ProxyGate-Provider"
+	// AuthHintHeader pins a request to one credential, by auth ID or runtime index.
+	AuthHintHeader = "X-ProxyGate-Auth"
+	// ExcludeAuthHintHeader lists credentials, by auth ID or index, that must not serve the request.
+	ExcludeAuthHintHeader = "X-ProxyGate-Exclude-Auth"
+	// AuthIndexHeader echoes the runtime index of the serving credential.
+	AuthIndexHeader = "X-ProxyGate-Auth-Index"
+)
+
+// Execution options metadata keys carrying routing hints to pickNext.
+const (
+	PinnedAuthMetadataKey    = "pinned_auth"
+	ExcludedAuthsMetadataKey = "excluded_auths"
+	// AllowedPrefixesMetadataKey confines selection to credentials whose prefix is listed. It
+	// carries the tenant prefixes of the calling client key.
+	AllowedPrefixesMetadataKey = "allowed_prefixes"
+	// ClientPolicyMetadataKey carries the calling client key's ClientPolicy so model fallbacks
+	// stay within what the key may reach.
+	ClientPolicyMetadataKey = "client_policy"
+)
+
+// ClientPolicy is the part of a client key policy deciding which models and providers a
+// request may be routed to.
+type ClientPolicy interface {
+	CheckModel(names ...string) error
+	CheckPrefix(model string) error
+	FilterProviders(providers []string) []string
+}
+
+// clientPolicyFromMetadata returns the client key policy attached to the request, if any.
+func clientPolicyFromMetadata(meta map[string]any) ClientPolicy {
+	policy, _ := meta[ClientPolicyMetadataKey].(ClientPolicy)
+	return policy
+}
+
+// RoutingHints holds the routing hint headers of a request.
+type RoutingHints struct {
+	Provider     string
+	Auth         string
+	ExcludeAuths []string
+}
+
+// Empty reports whether no hint was supplied.
+func (h RoutingHints) Empty() bool {
+	return h.Provider == "" && h.Auth == "" && len(h.ExcludeAuths) == 0
+}
+
+// RoutingHintsFromRequest reads the routing hint headers. Exclusions may be repeated or
+// comma-separated.
+func RoutingHintsFromRequest(headers http.Header) RoutingHints {
+	var hints RoutingHints
+	if headers == nil {
+		return hints
+	}
+	hints.Provider = strings.ToLower(strings.TrimSpace(headers.Get(ProviderHintHeader)))
+	hints.Auth = strings.TrimSpace(headers.Get(AuthHintHeader))
+	for _, value := range headers.Values(ExcludeAuthHintHeader) {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				hints.ExcludeAuths = append(hints.ExcludeAuths, part)
+			}
+		}
+	}
+	return hints
+}
+
+// ApplyMetadata records the credential hints in execution metadata, returning the updated map.
+func (h RoutingHints) ApplyMetadata(meta map[string]any) map[string]any {
+	if h.Auth == "" && len(h.ExcludeAuths) == 0 {
+		return meta
+	}
+	if meta == nil {
+		meta = make(map[string]any, 2)
+	}
+	if h.Auth != "" {
+		meta[PinnedAuthMetadataKey] = h.Auth
+	}
+	if len(h.ExcludeAuths) > 0 {
+		meta[ExcludedAuthsMetadataKey] = append([]string(nil), h.ExcludeAuths...)
+	}
+	return meta
+}
+
+// authHintsFromMetadata returns the pinned and excluded credential references in metadata.
+func authHintsFromMetadata(meta map[string]any) (string, []string) {
+	if len(meta) == 0 {
+		return "", nil
+	}
+	pinned, _ := meta[PinnedAuthMetadataKey].(string)
+	var excluded []string
+	switch v := meta[ExcludedAuthsMetadataKey].(type) {
+	case []string:
+		excluded = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				excluded = append(excluded, s)
+			}
+		}
+	}
+	return strings.TrimSpace(pinned), excluded
+}
+
+// authMatchesRef reports whether ref names auth by ID or by its assigned runtime index.
+func authMatchesRef(auth *Auth, ref string) bool {
+	if auth == nil || ref == "" {
+		return false
+	}
+	if auth.ID == ref {
+		return true
+	}
+	return auth.indexAssigned && strconv.FormatUint(auth.Index, 10) == ref
+}
+
+// authAllowedByHints applies the pinned and excluded credential hints to a candidate.
+func authAllowedByHints(auth *Auth, pinned string, excluded []string) bool {
+	for _, ref := range excluded {
+		if authMatchesRef(auth, ref) {
+			return false
+		}
+	}
+	return pinned == "" || authMatchesRef(auth, pinned)
+}
+
+// allowedPrefixesFromMetadata returns the credential prefixes the request is confined to.
+func allowedPrefixesFromMetadata(meta map[string]any) []string {
+	switch v := meta[AllowedPrefixesMetadataKey].(type) {
+	case []string:
+		return v
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// authAllowedByPrefixes reports whether auth belongs to one of the allowed credential prefixes.
+// Unprefixed credentials are excluded once a request is confined.
+func authAllowedByPrefixes(auth *Auth, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	prefix := strings.Trim(strings.TrimSpace(auth.Prefix), "/")
+	if prefix == "" {
+		return false
+	}
+	for _, allowed := range prefixes {
+		if strings.EqualFold(strings.Trim(strings.TrimSpace(allowed), "/"), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/radityprtama/proxygate/v6/internal/registry"
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+func TestRoutingHintsFromRequest(t *testing.T) {
+	headers := http.Header{}
+	headers.Set(ProviderHintHeader, " Claude ")
+	headers.Set(AuthHintHeader, "auth-a")
+	headers.Add(ExcludeAuthHintHeader, "auth-b, 7")
+	headers.Add(ExcludeAuthHintHeader, "auth-c")
+	hints := RoutingHintsFromRequest(headers)
+	if hints.Provider != "claude" || hints.Auth != "auth-a" {
+		t.Fatalf("unexpected hints %+v", hints)
+	}
+	if len(hints.ExcludeAuths) != 3 || hints.ExcludeAuths[1] != "7" {
+		t.Fatalf("unexpected exclusions %v", hints.ExcludeAuths)
+	}
+	if !(RoutingHints{}).Empty() || hints.Empty() {
+		t.Fatalf("unexpected Empty result")
+	}
+}
+
+func TestManagerPickNext_HonoursAuthHints(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(stubExecutor{provider: "claude"})
+	ids := []string{"hint-a", "hint-b", "hint-c"}
+	for _, id := range ids {
+		if _, err := manager.Register(ctx, &Auth{ID: id, Provider: "claude"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "hint-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	pick := func(hints RoutingHints) (*Auth, error) {
+		opts := cliproxyexecutor.Options{Metadata: hints.ApplyMetadata(nil)}
+		auth, _, err := manager.pickNext(ctx, "claude", "hint-model", opts, map[string]struct{}{})
+		return auth, err
+	}
+
+	for i := 0; i < 3; i++ {
+		picked, err := pick(RoutingHints{Auth: "hint-b"})
+		if err != nil || picked.ID != "hint-b" {
+			t.Fatalf("expected pinned hint-b, got %v (%v)", picked, err)
+		}
+	}
+	pinned, _ := manager.GetByID("hint-c")
+	picked, err := pick(RoutingHints{Auth: strconv.FormatUint(pinned.Index, 10)})
+	if err != nil || picked.ID != "hint-c" {
+		t.Fatalf("expected index pin to select hint-c, got %v (%v)", picked, err)
+	}
+	for i := 0; i < 4; i++ {
+		picked, err = pick(RoutingHints{ExcludeAuths: []string{"hint-a", "hint-c"}})
+		if err != nil || picked.ID != "hint-b" {
+			t.Fatalf("expected exclusions to leave hint-b, got %v (%v)", picked, err)
+		}
+	}
+	if _, err = pick(RoutingHints{Auth: "hint-a", ExcludeAuths: []string{"hint-a"}}); err == nil {
+		t.Fatalf("expected no auth when the pinned credential is excluded")
+	}
+}
+
+func TestManagerPickNext_ConfinesToAllowedPrefixes(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(stubExecutor{provider: "claude"})
+	prefixes := map[string]string{"tenant-a": "teamA", "tenant-b": "teamB", "tenant-none": ""}
+	for id, prefix := range prefixes {
+		if _, err := manager.Register(ctx, &Auth{ID: id, Provider: "claude", Prefix: prefix}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "tenant-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{AllowedPrefixesMetadataKey: []string{"teamA"}}}
+	for i := 0; i < 4; i++ {
+		picked, _, err := manager.pickNext(ctx, "claude", "tenant-model", opts, map[string]struct{}{})
+		if err != nil || picked.ID != "tenant-a" {
+			t.Fatalf("expected only the teamA credential, got %v (%v)", picked, err)
+		}
+	}
+	if _, _, err := manager.pickNext(ctx, "claude", "tenant-model", opts, map[string]struct{}{"tenant-a": {}}); err == nil {
+		t.Fatalf("expected no fallback to other tenants once teamA is exhausted")
+	}
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// ttfbEWMAAlpha weights the newest time-to-first-byte sample in the rolling average.
+const ttfbEWMAAlpha = 0.3
+
+// AuthLoad is a point-in-time view of the live load on an auth for a model.
+type AuthLoad struct {
+	// InFlight counts requests currently executing on the auth.
+	InFlight int64 `json:"in_flight"`
+	// TTFB is the exponentially weighted average time to first byte.
+	TTFB time.Duration `json:"-"`
+	// Samples counts the successful streaming requests folded into TTFB.
+	Samples int64 `json:"samples"`
+	// LastSampleAt records when TTFB was last updated.
+	LastSampleAt time.Time `json:"last_sample_at,omitempty"`
+}
+
+// LoadSource reports live per-auth load figures to load-aware selectors.
+type LoadSource interface {
+	AuthLoad(authID, model string) AuthLoad
+}
+
+// LoadAwareSelector is implemented by selectors that consult live load figures.
+// The manager binds itself as the load source when such a selector is installed.
+type LoadAwareSelector interface {
+	Selector
+	SetLoadSource(source LoadSource)
+}
+
+type loadEntry struct {
+	inFlight     int64
+	ttfb         float64
+	samples      int64
+	lastSampleAt time.Time
+}
+
+// loadTracker keeps in-flight counters and TTFB averages keyed by auth ID, then model.
+type loadTracker struct {
+	mu      sync.Mutex
+	entries map[string]map[string]*loadEntry
+}
+
+func newLoadTracker() *loadTracker {
+	return &loadTracker{entries: make(map[string]map[string]*loadEntry)}
+}
+
+func (t *loadTracker) entryLocked(authID, model string) *loadEntry {
+	models := t.entries[authID]
+	if models == nil {
+		models = make(map[string]*loadEntry)
+		t.entries[authID] = models
+	}
+	entry := models[model]
+	if entry == nil {
+		entry = &loadEntry{}
+		models[model] = entry
+	}
+	return entry
+}
+
+// begin marks a request as in flight and returns a function that releases it exactly once.
+func (t *loadTracker) begin(authID, model string) func() {
+	if t == nil || authID == "" {
+		return func() {}
+	}
+	model = strings.TrimSpace(model)
+	t.mu.Lock()
+	entry := t.entryLocked(authID, model)
+	entry.inFlight++
+	t.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			if entry.inFlight > 0 {
+				entry.inFlight--
+			}
+			t.mu.Unlock()
+		})
+	}
+}
+
+// forget drops every entry for authID. Requests still in flight release their own entry.
+func (t *loadTracker) forget(authID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.entries, authID)
+	t.mu.Unlock()
+}
+
+// observeTTFB folds a time-to-first-byte sample into the rolling average.
+func (t *loadTracker) observeTTFB(authID, model string, d time.Duration) {
+	if t == nil || authID == "" || d < 0 {
+		return
+	}
+	model = strings.TrimSpace(model)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entryLocked(authID, model)
+	if entry.samples == 0 {
+		entry.ttfb = float64(d)
+	} else {
+		entry.ttfb = ttfbEWMAAlpha*float64(d) + (1-ttfbEWMAAlpha)*entry.ttfb
+	}
+	entry.samples++
+	entry.lastSampleAt = time.Now()
+}
+
+func (t *loadTracker) load(authID, model string) AuthLoad {
+	if t == nil {
+		return AuthLoad{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	models := t.entries[authID]
+	if models == nil {
+		return AuthLoad{}
+	}
+	entry := models[strings.TrimSpace(model)]
+	if entry == nil {
+		return AuthLoad{}
+	}
+	return entry.snapshot()
+}
+
+func (t *loadTracker) loads(authID string) map[string]AuthLoad {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	models := t.entries[authID]
+	if len(models) == 0 {
+		return nil
+	}
+	out := make(map[string]AuthLoad, len(models))
+	for model, entry := range models {
+		out[model] = entry.snapshot()
+	}
+	return out
+}
+
+func (e *loadEntry) snapshot() AuthLoad {
+	return AuthLoad{
+		InFlight:     e.inFlight,
+		TTFB:         time.Duration(e.ttfb),
+		Samples:      e.samples,
+		LastSampleAt: e.lastSampleAt,
+	}
+}
+
+// AuthLoad returns the live load figures for an auth and model.
+func (m *Manager) AuthLoad(authID, model string) AuthLoad {
+	if m == nil {
+		return AuthLoad{}
+	}
+	return m.loads.load(authID, model)
+}
+
+// AuthLoads returns the live load figures for every model an auth has served.
+func (m *Manager) AuthLoads(authID string) map[string]AuthLoad {
+	if m == nil {
+		return nil
+	}
+	return m.loads.loads(authID)
+}
@@ -12,9 +12,11 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/radityprtama/proxygate/v6/internal/metrics"
 	"github.com/radityprtama/proxygate/v6/internal/registry"
 	"github.com/radityprtama/proxygate/v6/internal/util"
 	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+	coreusage "github.com/radityprtama/proxygate/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -105,10 +107,24 @@ type Manager struct {
 	auths     map[string]*Auth
 	// providerOffsets tracks per-model provider rotation state for multi-provider routing.
 	providerOffsets map[string]int
+	// loads tracks in-flight requests and time-to-first-byte per auth and model.
+	loads *loadTracker
+	// affinity pins client sessions to a credential when sticky routing is enabled.
+	affinity *sessionAffinity
+	// modelFallbacks holds map[string][]string chains of substitute models.
+	modelFallbacks atomic.Value
+	// breakers holds the per-provider circuit breakers.
+	breakers *breakerRegistry
+	// runtimeState persists cooldowns and model states through the store.
+	runtimeState *runtimeStatePersister
+	// throttle enforces per-auth concurrency caps and request spacing.
+	throttle *authThrottle
 
 	// Retry controls request retry behavior.
 	requestRetry     atomic.Int32
 	maxRetryInterval atomic.Int64
+	// maxQueueWait bounds the wait for a throttled credential, in nanoseconds.
+	maxQueueWait atomic.Int64
 
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
@@ -125,14 +141,23 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 	if hook == nil {
 		hook = NoopHook{}
 	}
-	return &Manager{
+	m := &Manager{
 		store:           store,
 		executors:       make(map[string]ProviderExecutor),
 		selector:        selector,
 		hook:            hook,
 		auths:           make(map[string]*Auth),
 		providerOffsets: make(map[string]int),
+		loads:           newLoadTracker(),
+		affinity:        newSessionAffinity(),
+		breakers:        newBreakerRegistry(),
+		runtimeState:    newRuntimeStatePersister(),
+		throttle:        newAuthThrottle(),
 	}
+	if loadAware, ok := selector.(LoadAwareSelector); ok {
+		loadAware.SetLoadSource(m)
+	}
+	return m
 }
 
 // SetStore swaps the underlying persistence store.
@@ -142,6 +167,22 @@ func (m *Manager) SetStore(store Store) {
 	m.store = store
 }
 
+// SetSelector swaps the credential selection strategy. A nil selector restores round-robin.
+func (m *Manager) SetSelector(selector Selector) {
+	if m == nil {
+		return
+	}
+	if selector == nil {
+		selector = &RoundRobinSelector{}
+	}
+	if loadAware, ok := selector.(LoadAwareSelector); ok {
+		loadAware.SetLoadSource(m)
+	}
+	m.mu.Lock()
+	m.selector = selector
+	m.mu.Unlock()
+}
+
 // SetRoundTripperProvider register a provider that returns a per-auth RoundTripper.
 func (m *Manager) SetRoundTripperProvider(p RoundTripperProvider) {
 	m.mu.Lock()
@@ -164,6 +205,18 @@ func (m *Manager) SetRetryConfig(retry int, maxRetryInterval time.Duration) {
 	m.maxRetryInterval.Store(maxRetryInterval.Nanoseconds())
 }
 
+// SetMaxQueueWait bounds how long a request waits for a slot on a throttled credential before
+// moving on to the next one. Non-positive values restore the default.
+func (m *Manager) SetMaxQueueWait(wait time.Duration) {
+	if m == nil {
+		return
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	m.maxQueueWait.Store(wait.Nanoseconds())
+}
+
 // RegisterExecutor registers a provider executor with the manager.
 func (m *Manager) RegisterExecutor(executor ProviderExecutor) {
 	if executor == nil {
@@ -194,6 +247,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	if auth.ID == "" {
 		auth.ID = uuid.NewString()
 	}
+	m.applyPendingRuntimeState(auth)
 	m.mu.Lock()
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
@@ -202,6 +256,12 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	return auth.Clone(), nil
 }
 
+// forgetAuthActivity drops the in-flight, TTFB and throttle state kept for authID.
+func (m *Manager) forgetAuthActivity(authID string) {
+	m.loads.forget(authID)
+	m.throttle.forget(authID)
+}
+
 // Update replaces an existing auth entry and notifies hooks.
 func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	if auth == nil || auth.ID == "" {
@@ -213,8 +273,14 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 		auth.indexAssigned = existing.indexAssigned
 	}
 	auth.EnsureIndex()
+	m.applyPendingRuntimeState(auth)
 	m.auths[auth.ID] = auth.Clone()
 	m.mu.Unlock()
+	// A disabled auth is how the service removes a credential; its load and throttle
+	// bookkeeping would otherwise linger for the life of the process.
+	if auth.Disabled {
+		m.forgetAuthActivity(auth.ID)
+	}
 	_ = m.persist(ctx, auth)
 	m.hook.OnAuthUpdated(ctx, auth.Clone())
 	return auth.Clone(), nil
@@ -222,6 +288,7 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 
 // Load resets manager state from the backing store.
 func (m *Manager) Load(ctx context.Context) error {
+	states := m.loadRuntimeStates(ctx)
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if m.store == nil {
@@ -231,6 +298,7 @@ func (m *Manager) Load(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	previous := m.auths
 	m.auths = make(map[string]*Auth, len(items))
 	for _, auth := range items {
 		if auth == nil || auth.ID == "" {
@@ -239,12 +307,23 @@ func (m *Manager) Load(ctx context.Context) error {
 		auth.EnsureIndex()
 		m.auths[auth.ID] = auth.Clone()
 	}
+	for id := range previous {
+		if _, ok := m.auths[id]; !ok {
+			m.forgetAuthActivity(id)
+		}
+	}
+	m.applyRuntimeStatesLocked(states)
 	return nil
 }
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model cannot be served, configured model fallbacks are tried in order.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return executeWithFallbacks(m, ctx, providers, req, opts, m.executeModel)
+}
+
+func (m *Manager) executeModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -322,7 +401,12 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When the model cannot be served, configured model fallbacks are tried in order.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	return executeWithFallbacks(m, ctx, providers, req, opts, m.executeStreamModel)
+}
+
+func (m *Manager) executeStreamModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -392,21 +476,33 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 		}
 
 		tried[auth.ID] = struct{}{}
+		coreusage.TimingFromContext(ctx).BeginAttempt(auth.ID)
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = withRateLimitObserver(execCtx, m, auth.ID, routeModel)
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execCtx, releaseSlot, errThrottle := m.acquireThrottle(execCtx, auth)
+		if errThrottle != nil {
+			if isQueueTimeout(errThrottle) {
+				lastErr = errThrottle
+				continue
+			}
+			return cliproxyexecutor.Response{}, errThrottle
+		}
+		// Only streams feed the time-to-first-byte average; a non-streaming call is timed to
+		// its last byte, which tracks response length rather than credential latency.
+		release := m.loads.begin(auth.ID, routeModel)
 		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		release()
+		releaseSlot()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
-			result.Error = &Error{Message: errExec.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errExec, &se) && se != nil {
-				result.Error.HTTPStatus = se.StatusCode()
-			}
+			result.Error = executorError(errExec)
+			metrics.RecordExecutorError(provider, result.Error.HTTPStatus)
 			if ra := retryAfterFromError(errExec); ra != nil {
 				result.RetryAfter = ra
 			}
@@ -415,6 +511,7 @@ func (m *Manager) executeWithProvider(ctx context.Context, provider string, req
 			continue
 		}
 		m.MarkResult(execCtx, result)
+		recordRoute(ctx, provider, routeModel, auth)
 		return resp, nil
 	}
 }
@@ -452,21 +549,29 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 		}
 
 		tried[auth.ID] = struct{}{}
+		coreusage.TimingFromContext(ctx).BeginAttempt(auth.ID)
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = withRateLimitObserver(execCtx, m, auth.ID, routeModel)
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execCtx, releaseSlot, errThrottle := m.acquireThrottle(execCtx, auth)
+		if errThrottle != nil {
+			if isQueueTimeout(errThrottle) {
+				lastErr = errThrottle
+				continue
+			}
+			return cliproxyexecutor.Response{}, errThrottle
+		}
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		releaseSlot()
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
-			result.Error = &Error{Message: errExec.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errExec, &se) && se != nil {
-				result.Error.HTTPStatus = se.StatusCode()
-			}
+			result.Error = executorError(errExec)
+			metrics.RecordExecutorError(provider, result.Error.HTTPStatus)
 			if ra := retryAfterFromError(errExec); ra != nil {
 				result.RetryAfter = ra
 			}
@@ -475,6 +580,7 @@ func (m *Manager) executeCountWithProvider(ctx context.Context, provider string,
 			continue
 		}
 		m.MarkResult(execCtx, result)
+		recordRoute(ctx, provider, routeModel, auth)
 		return resp, nil
 	}
 }
@@ -512,38 +618,74 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 		}
 
 		tried[auth.ID] = struct{}{}
+		coreusage.TimingFromContext(ctx).BeginAttempt(auth.ID)
 		execCtx := ctx
 		if rt := m.roundTripperFor(auth); rt != nil {
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		execCtx = withRateLimitObserver(execCtx, m, auth.ID, routeModel)
 		execReq := req
 		execReq.Model, execReq.Metadata = rewriteModelForAuth(routeModel, req.Metadata, auth)
+		execCtx, releaseSlot, errThrottle := m.acquireThrottle(execCtx, auth)
+		if errThrottle != nil {
+			if isQueueTimeout(errThrottle) {
+				lastErr = errThrottle
+				continue
+			}
+			return nil, errThrottle
+		}
+		releaseLoad := m.loads.begin(auth.ID, routeModel)
+		release := func() {
+			releaseLoad()
+			releaseSlot()
+		}
+		started := time.Now()
 		chunks, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
 		if errStream != nil {
-			rerr := &Error{Message: errStream.Error()}
-			var se cliproxyexecutor.StatusError
-			if errors.As(errStream, &se) && se != nil {
-				rerr.HTTPStatus = se.StatusCode()
-			}
+			release()
+			rerr := executorError(errStream)
+			metrics.RecordExecutorError(provider, rerr.HTTPStatus)
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(execCtx, result)
 			lastErr = errStream
 			continue
 		}
+		// Hold the stream until the first payload arrives so failures surfaced before any byte
+		// reaches the client can fail over to the next credential like the non-streaming path.
+		pending, errFirst := awaitFirstStreamPayload(ctx, chunks)
+		if errFirst != nil {
+			release()
+			go drainStreamChunks(chunks)
+			if errCtx := ctx.Err(); errCtx != nil {
+				return nil, errCtx
+			}
+			rerr := executorError(errFirst)
+			metrics.RecordExecutorError(provider, rerr.HTTPStatus)
+			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+			result.RetryAfter = retryAfterFromError(errFirst)
+			m.MarkResult(execCtx, result)
+			log.Debugf("stream for model %s failed before first byte, trying next credential: %v", routeModel, errFirst)
+			lastErr = errFirst
+			continue
+		}
+		if len(pending) > 0 && len(pending[len(pending)-1].Payload) > 0 {
+			m.loads.observeTTFB(auth.ID, routeModel, time.Since(started))
+		}
 		out := make(chan cliproxyexecutor.StreamChunk)
 		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
 			defer close(out)
+			defer release()
+			for _, chunk := range pending {
+				out <- chunk
+			}
 			var failed bool
 			for chunk := range streamChunks {
 				if chunk.Err != nil && !failed {
 					failed = true
-					rerr := &Error{Message: chunk.Err.Error()}
-					var se cliproxyexecutor.StatusError
-					if errors.As(chunk.Err, &se) && se != nil {
-						rerr.HTTPStatus = se.StatusCode()
-					}
+					rerr := executorError(chunk.Err)
+					metrics.RecordExecutorError(streamProvider, rerr.HTTPStatus)
 					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
 				}
 				out <- chunk
@@ -552,10 +694,41 @@ func (m *Manager) executeStreamWithProvider(ctx context.Context, provider string
 				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
 			}
 		}(execCtx, auth.Clone(), provider, chunks)
+		recordRoute(ctx, provider, routeModel, auth)
 		return out, nil
 	}
 }
 
+// awaitFirstStreamPayload reads chunks until the first non-empty payload and returns everything
+// read so far. It returns an error when the stream fails, or ctx ends, before any payload.
+// A stream that closes without payload or error yields the chunks read and no error.
+func awaitFirstStreamPayload(ctx context.Context, chunks <-chan cliproxyexecutor.StreamChunk) ([]cliproxyexecutor.StreamChunk, error) {
+	var pending []cliproxyexecutor.StreamChunk
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case chunk, ok := <-chunks:
+			if !ok {
+				return pending, nil
+			}
+			if chunk.Err != nil {
+				return nil, chunk.Err
+			}
+			pending = append(pending, chunk)
+			if len(chunk.Payload) > 0 {
+				return pending, nil
+			}
+		}
+	}
+}
+
+// drainStreamChunks discards the rest of an abandoned stream so its producer can exit.
+func drainStreamChunks(chunks <-chan cliproxyexecutor.StreamChunk) {
+	for range chunks {
+	}
+}
+
 func rewriteModelForAuth(model string, metadata map[string]any, auth *Auth) (string, map[string]any) {
 	if auth == nil || model == "" {
 		return model, metadata
@@ -746,7 +919,13 @@ func (m *Manager) executeProvidersOnce(ctx context.Context, providers []string,
 	}
 	var lastErr error
 	for _, provider := range providers {
+		allowed, release := m.breakers.allow(provider, time.Now())
+		if !allowed {
+			lastErr = newCircuitOpenError(provider)
+			continue
+		}
 		resp, errExec := fn(ctx, provider)
+		release()
 		if errExec == nil {
 			return resp, nil
 		}
@@ -764,7 +943,13 @@ func (m *Manager) executeStreamProvidersOnce(ctx context.Context, providers []st
 	}
 	var lastErr error
 	for _, provider := range providers {
+		allowed, release := m.breakers.allow(provider, time.Now())
+		if !allowed {
+			lastErr = newCircuitOpenError(provider)
+			continue
+		}
 		chunks, errExec := fn(ctx, provider)
+		release()
 		if errExec == nil {
 			return chunks, nil
 		}
@@ -781,20 +966,33 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	if result.AuthID == "" {
 		return
 	}
+	// A request abandoned by its client says nothing about the provider's health.
+	if result.Success || ctx == nil || !errors.Is(ctx.Err(), context.Canceled) {
+		m.breakers.record(strings.ToLower(result.Provider), result.Success, result.Error, time.Now())
+	}
 
 	shouldResumeModel := false
 	shouldSuspendModel := false
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	runtimeChanged := !result.Success
+	marked := false
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
+		marked = true
 		now := time.Now()
 
 		if result.Success {
+			if auth.Unavailable {
+				runtimeChanged = true
+			}
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
+				if state.Unavailable {
+					runtimeChanged = true
+				}
 				resetModelState(state, now)
 				updateAggregatedAvailability(auth, now)
 				if !hasModelError(auth, now) {
@@ -843,6 +1041,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 					backoffLevel := state.Quota.BackoffLevel
 					if result.RetryAfter != nil {
 						next = now.Add(*result.RetryAfter)
+						state.RateLimit = exhaustedSnapshot(RateLimitSourceRetryAfter, *result.RetryAfter, now)
 					} else {
 						cooldown, nextLevel := nextQuotaCooldown(backoffLevel)
 						if cooldown > 0 {
@@ -879,6 +1078,16 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	}
 	m.mu.Unlock()
 
+	if marked && !result.Success {
+		statusCode := statusCodeFromResult(result.Error)
+		metrics.RecordRetry(result.Provider, statusCode)
+		if reason := cooldownReason(statusCode); reason != "" {
+			metrics.RecordCooldown(result.Provider, reason)
+		}
+	}
+	if runtimeChanged {
+		m.scheduleRuntimeStateSave()
+	}
 	if clearModelQuota && result.Model != "" {
 		registry.GetGlobalRegistry().ClearModelQuotaExceeded(result.AuthID, result.Model)
 	}
@@ -894,6 +1103,24 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	m.hook.OnResult(ctx, result)
 }
 
+// cooldownReason names the cooldown MarkResult applies for an upstream status, or "" when the
+// credential is not cooled down.
+func cooldownReason(statusCode int) string {
+	switch statusCode {
+	case 401:
+		return "unauthorized"
+	case 402, 403:
+		return "payment_required"
+	case 404:
+		return "not_found"
+	case 429:
+		return "quota"
+	case 408, 500, 502, 503, 504:
+		return "transient"
+	}
+	return ""
+}
+
 func ensureModelState(auth *Auth, model string) *ModelState {
 	if auth == nil || model == "" {
 		return nil
@@ -1064,6 +1291,20 @@ func retryAfterFromError(err error) *time.Duration {
 	return &val
 }
 
+// executorError converts an executor failure into a result error, keeping the upstream status
+// and tagging failures where no response arrived so the breaker can tell them from local ones.
+func executorError(err error) *Error {
+	rerr := &Error{Message: err.Error()}
+	var se cliproxyexecutor.StatusError
+	if errors.As(err, &se) && se != nil {
+		rerr.HTTPStatus = se.StatusCode()
+	}
+	if rerr.HTTPStatus == 0 && isTransportError(err) {
+		rerr.Code = transportErrorCode
+	}
+	return rerr
+}
+
 func statusCodeFromResult(err *Error) int {
 	if err == nil {
 		return 0
@@ -1175,6 +1416,8 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	candidates := make([]*Auth, 0, len(m.auths))
 	modelKey := strings.TrimSpace(model)
 	registryRef := registry.GetGlobalRegistry()
+	pinnedAuth, excludedAuths := authHintsFromMetadata(opts.Metadata)
+	allowedPrefixes := allowedPrefixesFromMetadata(opts.Metadata)
 	for _, candidate := range m.auths {
 		if candidate.Provider != provider || candidate.Disabled {
 			continue
@@ -1182,6 +1425,12 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
+		if !authAllowedByHints(candidate, pinnedAuth, excludedAuths) {
+			continue
+		}
+		if !authAllowedByPrefixes(candidate, allowedPrefixes) {
+			continue
+		}
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
@@ -1191,14 +1440,29 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		m.mu.RUnlock()
 		return nil, nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
-	selected, errPick := m.selector.Pick(ctx, provider, model, opts, candidates)
-	if errPick != nil {
-		m.mu.RUnlock()
-		return nil, nil, errPick
+	candidates = m.preferThrottleReady(modelKey, candidates, time.Now())
+	var selected *Auth
+	sessionKey := ""
+	if m.affinity.active() {
+		if key := sessionKeyFromMetadata(opts.Metadata); key != "" {
+			sessionKey = affinityKey(provider, modelKey, key)
+			selected = m.pickPinned(sessionKey, model, candidates, time.Now())
+		}
 	}
 	if selected == nil {
-		m.mu.RUnlock()
-		return nil, nil, &Error{Code: "auth_not_found", Message: "selector returned no auth"}
+		var errPick error
+		selected, errPick = m.selector.Pick(ctx, provider, model, opts, candidates)
+		if errPick != nil {
+			m.mu.RUnlock()
+			return nil, nil, errPick
+		}
+		if selected == nil {
+			m.mu.RUnlock()
+			return nil, nil, &Error{Code: "auth_not_found", Message: "selector returned no auth"}
+		}
+	}
+	if sessionKey != "" {
+		m.affinity.pin(sessionKey, selected.ID, time.Now())
 	}
 	authCopy := selected.Clone()
 	m.mu.RUnlock()
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+type stubExecutor struct {
+	provider string
+	execute  func(ctx context.Context, auth *Auth, req cliproxyexecutor.Request) (cliproxyexecutor.Response, error)
+	stream   func(ctx context.Context, auth *Auth, req cliproxyexecutor.Request) (<-chan cliproxyexecutor.StreamChunk, error)
+}
+
+func (e stubExecutor) Identifier() string { return e.provider }
+
+func (e stubExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.execute != nil {
+		return e.execute(ctx, auth, req)
+	}
+	return cliproxyexecutor.Response{}, nil
+}
+
+func (e stubExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (<-chan cliproxyexecutor.StreamChunk, error) {
+	if e.stream != nil {
+		return e.stream(ctx, auth, req)
+	}
+	ch := make(chan cliproxyexecutor.StreamChunk)
+	close(ch)
+	return ch, nil
+}
+
+func (e stubExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e stubExecutor) CountTokens(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestManagerExecuteStream_FailsOverBeforeFirstByte(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(stubExecutor{provider: "codex", stream: func(_ context.Context, auth *Auth, _ cliproxyexecutor.Request) (<-chan cliproxyexecutor.StreamChunk, error) {
+		ch := make(chan cliproxyexecutor.StreamChunk, 3)
+		if auth.ID == "a" {
+			ch <- cliproxyexecutor.StreamChunk{}
+			ch <- cliproxyexecutor.StreamChunk{Err: stubStatusError{code: http.StatusTooManyRequests}}
+		} else {
+			ch <- cliproxyexecutor.StreamChunk{Payload: []byte("data: ok")}
+			ch <- cliproxyexecutor.StreamChunk{Payload: []byte("data: done")}
+		}
+		close(ch)
+		return ch, nil
+	}})
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Register(ctx, &Auth{ID: id, Provider: "codex"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		chunks, err := m.ExecuteStream(ctx, []string{"codex"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{Stream: true})
+		if err != nil {
+			t.Fatalf("expected failover to succeed, got %v", err)
+		}
+		var payloads []string
+		for chunk := range chunks {
+			if chunk.Err != nil {
+				t.Fatalf("unexpected stream error: %v", chunk.Err)
+			}
+			if len(chunk.Payload) > 0 {
+				payloads = append(payloads, string(chunk.Payload))
+			}
+		}
+		if len(payloads) != 2 || payloads[0] != "data: ok" {
+			t.Fatalf("unexpected payloads: %v", payloads)
+		}
+	}
+	failed, _ := m.GetByID("a")
+	if failed.LastError == nil || failed.LastError.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("expected failed credential to be marked, got %+v", failed.LastError)
+	}
+}
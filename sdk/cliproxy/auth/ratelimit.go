@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limit snapshot sources.
+const (
+	RateLimitSourceAnthropic  = "anthropic"
+	RateLimitSourceOpenAI     = "openai"
+	RateLimitSourceCodex      = "codex"
+	RateLimitSourceRetryAfter = "retry-after"
+)
+
+// defaultRateLimitWindow bounds how long an exhausted snapshot without a reset time is trusted.
+const defaultRateLimitWindow = time.Minute
+
+// RateLimitSnapshot is the latest upstream rate-limit view for one credential and model.
+// Snapshots are immutable once stored; updates replace the pointer.
+type RateLimitSnapshot struct {
+	// RequestsLimit is the request allowance of the current window.
+	RequestsLimit *int64 `json:"requests_limit,omitempty"`
+	// RequestsRemaining is the number of requests left in the current window.
+	RequestsRemaining *int64 `json:"requests_remaining,omitempty"`
+	// RequestsReset is when the request allowance is replenished.
+	RequestsReset time.Time `json:"requests_reset,omitempty"`
+	// TokensLimit is the token allowance of the current window.
+	TokensLimit *int64 `json:"tokens_limit,omitempty"`
+	// TokensRemaining is the number of tokens left in the current window.
+	TokensRemaining *int64 `json:"tokens_remaining,omitempty"`
+	// TokensReset is when the token allowance is replenished.
+	TokensReset time.Time `json:"tokens_reset,omitempty"`
+	// Source names the header family the snapshot was parsed from.
+	Source string `json:"source"`
+	// ObservedAt is when the upstream response was received.
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// Exhausted reports whether the snapshot predicts the next request will be rate limited,
+// and when the allowance is expected to recover.
+func (s *RateLimitSnapshot) Exhausted(now time.Time) (bool, time.Time) {
+	if s == nil {
+		return false, time.Time{}
+	}
+	exhausted := false
+	var recoverAt time.Time
+	check := func(remaining *int64, reset time.Time) {
+		if remaining == nil || *remaining > 0 {
+			return
+		}
+		if reset.IsZero() {
+			reset = s.ObservedAt.Add(defaultRateLimitWindow)
+		}
+		if !now.Before(reset) {
+			return
+		}
+		exhausted = true
+		if reset.After(recoverAt) {
+			recoverAt = reset
+		}
+	}
+	check(s.RequestsRemaining, s.RequestsReset)
+	check(s.TokensRemaining, s.TokensReset)
+	return exhausted, recoverAt
+}
+
+// ParseRateLimitHeaders extracts a rate-limit snapshot from upstream response headers.
+// It understands anthropic-ratelimit-*, x-ratelimit-* (OpenAI-compatible), x-codex-* usage
+// windows and, for 429 responses, Retry-After. It returns nil when no rate-limit data is present.
+func ParseRateLimitHeaders(status int, headers http.Header, now time.Time) *RateLimitSnapshot {
+	if len(headers) == 0 {
+		return nil
+	}
+	if snapshot := parseAnthropicRateLimits(headers, now); snapshot != nil {
+		return snapshot
+	}
+	if snapshot := parseOpenAIRateLimits(headers, now); snapshot != nil {
+		return snapshot
+	}
+	if snapshot := parseCodexRateLimits(headers, now); snapshot != nil {
+		return snapshot
+	}
+	if status == http.StatusTooManyRequests {
+		if wait, ok := parseResetValue(headers.Get("Retry-After"), now); ok {
+			return exhaustedSnapshot(RateLimitSourceRetryAfter, wait, now)
+		}
+	}
+	return nil
+}
+
+func parseAnthropicRateLimits(headers http.Header, now time.Time) *RateLimitSnapshot {
+	snapshot := &RateLimitSnapshot{Source: RateLimitSourceAnthropic, ObservedAt: now}
+	found := false
+	if limit, ok := headerInt(headers, "anthropic-ratelimit-requests-limit"); ok {
+		snapshot.RequestsLimit, found = &limit, true
+	}
+	if remaining, ok := headerInt(headers, "anthropic-ratelimit-requests-remaining"); ok {
+		snapshot.RequestsRemaining, found = &remaining, true
+	}
+	if reset, ok := parseTimestamp(headers.Get("anthropic-ratelimit-requests-reset")); ok {
+		snapshot.RequestsReset = reset
+	}
+	// tokens-* reports the most restrictive of the input and output buckets; fall back to the
+	// individual buckets when it is absent.
+	for _, prefix := range []string{"anthropic-ratelimit-tokens", "anthropic-ratelimit-input-tokens", "anthropic-ratelimit-output-tokens"} {
+		remaining, ok := headerInt(headers, prefix+"-remaining")
+		if !ok {
+			continue
+		}
+		if snapshot.TokensRemaining != nil && *snapshot.TokensRemaining <= remaining {
+			continue
+		}
+		snapshot.TokensRemaining, found = &remaining, true
+		if limit, okLimit := headerInt(headers, prefix+"-limit"); okLimit {
+			snapshot.TokensLimit = &limit
+		}
+		if reset, okReset := parseTimestamp(headers.Get(prefix + "-reset")); okReset {
+			snapshot.TokensReset = reset
+		}
+	}
+	// Subscription (OAuth) credentials report a unified window instead of per-bucket counts.
+	if status := strings.ToLower(strings.TrimSpace(headers.Get("anthropic-ratelimit-unified-status"))); status != "" {
+		found = true
+		if status == "rejected" {
+			zero := int64(0)
+			snapshot.RequestsRemaining = &zero
+			if reset, ok := parseTimestamp(headers.Get("anthropic-ratelimit-unified-reset")); ok {
+				snapshot.RequestsReset = reset
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	return snapshot
+}
+
+func parseOpenAIRateLimits(headers http.Header, now time.Time) *RateLimitSnapshot {
+	snapshot := &RateLimitSnapshot{Source: RateLimitSourceOpenAI, ObservedAt: now}
+	found := false
+	if limit, ok := headerInt(headers, "x-ratelimit-limit-requests"); ok {
+		snapshot.RequestsLimit, found = &limit, true
+	}
+	if remaining, ok := headerInt(headers, "x-ratelimit-remaining-requests"); ok {
+		snapshot.RequestsRemaining, found = &remaining, true
+	}
+	if wait, ok := parseResetValue(headers.Get("x-ratelimit-reset-requests"), now); ok {
+		snapshot.RequestsReset = now.Add(wait)
+	}
+	if limit, ok := headerInt(headers, "x-ratelimit-limit-tokens"); ok {
+		snapshot.TokensLimit, found = &limit, true
+	}
+	if remaining, ok := headerInt(headers, "x-ratelimit-remaining-tokens"); ok {
+		snapshot.TokensRemaining, found = &remaining, true
+	}
+	if wait, ok := parseResetValue(headers.Get("x-ratelimit-reset-tokens"), now); ok {
+		snapshot.TokensReset = now.Add(wait)
+	}
+	if !found {
+		return nil
+	}
+	return snapshot
+}
+
+// parseCodexRateLimits maps the Codex primary/secondary usage windows onto the request bucket.
+// Only saturated windows are recorded since the headers report percentages, not counts.
+func parseCodexRateLimits(headers http.Header, now time.Time) *RateLimitSnapshot {
+	var snapshot *RateLimitSnapshot
+	for _, window := range []string{"primary", "secondary"} {
+		raw := strings.TrimSpace(headers.Get("x-codex-" + window + "-used-percent"))
+		if raw == "" {
+			continue
+		}
+		used, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			continue
+		}
+		if snapshot == nil {
+			snapshot = &RateLimitSnapshot{Source: RateLimitSourceCodex, ObservedAt: now}
+		}
+		if used < 100 {
+			continue
+		}
+		zero := int64(0)
+		snapshot.RequestsRemaining = &zero
+		if wait, ok := parseResetValue(headers.Get("x-codex-"+window+"-reset-after-seconds"), now); ok {
+			if reset := now.Add(wait); reset.After(snapshot.RequestsReset) {
+				snapshot.RequestsReset = reset
+			}
+		}
+	}
+	return snapshot
+}
+
+func exhaustedSnapshot(source string, wait time.Duration, now time.Time) *RateLimitSnapshot {
+	zero := int64(0)
+	return &RateLimitSnapshot{
+		RequestsRemaining: &zero,
+		RequestsReset:     now.Add(wait),
+		Source:            source,
+		ObservedAt:        now,
+	}
+}
+
+func headerInt(headers http.Header, key string) (int64, bool) {
+	raw := strings.TrimSpace(headers.Get(key))
+	if raw == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		if f, errFloat := strconv.ParseFloat(raw, 64); errFloat == nil {
+			return int64(f), true
+		}
+		return 0, false
+	}
+	return value, true
+}
+
+// parseTimestamp accepts RFC 3339 timestamps and Unix epoch seconds.
+func parseTimestamp(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, true
+	}
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0), true
+	}
+	return time.Time{}, false
+}
+
+// parseResetValue converts a reset header into a wait duration. It accepts Go durations
+// ("6m0s", "20ms"), plain seconds, Unix epoch seconds, RFC 3339 and HTTP dates.
+func parseResetValue(raw string, now time.Time) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return clampWait(d), true
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		// Values this large are absolute epoch timestamps rather than relative seconds.
+		if f > 1e9 {
+			return clampWait(time.Unix(int64(f), 0).Sub(now)), true
+		}
+		return clampWait(time.Duration(f * float64(time.Second))), true
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return clampWait(ts.Sub(now)), true
+	}
+	if ts, err := http.ParseTime(raw); err == nil {
+		return clampWait(ts.Sub(now)), true
+	}
+	return 0, false
+}
+
+func clampWait(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+type rateLimitObserverContextKey struct{}
+
+type rateLimitObserver struct {
+	manager *Manager
+	authID  string
+	model   string
+}
+
+// withRateLimitObserver routes rate-limit headers seen by the executor back to the manager.
+func withRateLimitObserver(ctx context.Context, m *Manager, authID, model string) context.Context {
+	return context.WithValue(ctx, rateLimitObserverContextKey{}, &rateLimitObserver{manager: m, authID: authID, model: model})
+}
+
+// ObserveRateLimitHeaders parses upstream rate-limit headers and records them on the credential
+// and model currently executing in ctx. Executors call it for every upstream response.
+func ObserveRateLimitHeaders(ctx context.Context, status int, headers http.Header) {
+	if ctx == nil {
+		return
+	}
+	observer, _ := ctx.Value(rateLimitObserverContextKey{}).(*rateLimitObserver)
+	if observer == nil || observer.manager == nil {
+		return
+	}
+	snapshot := ParseRateLimitHeaders(status, headers, time.Now())
+	if snapshot == nil {
+		return
+	}
+	observer.manager.recordRateLimit(observer.authID, observer.model, snapshot)
+}
+
+// recordRateLimit stores snapshot as the latest rate-limit view for the auth and model.
+func (m *Manager) recordRateLimit(authID, model string, snapshot *RateLimitSnapshot) {
+	if m == nil || authID == "" || model == "" || snapshot == nil {
+		return
+	}
+	m.mu.Lock()
+	auth, ok := m.auths[authID]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return
+	}
+	ensureModelState(auth, model).RateLimit = snapshot
+	m.mu.Unlock()
+	if exhausted, _ := snapshot.Exhausted(time.Now()); exhausted {
+		m.scheduleRuntimeStateSave()
+	}
+}
+
+// predictedExhausted reports whether the latest rate-limit snapshot for the model says the
+// auth has no allowance left.
+func predictedExhausted(auth *Auth, model string, now time.Time) bool {
+	if auth == nil || model == "" || len(auth.ModelStates) == 0 {
+		return false
+	}
+	state := auth.ModelStates[model]
+	if state == nil {
+		return false
+	}
+	exhausted, _ := state.RateLimit.Exhausted(now)
+	return exhausted
+}
+
+// preferUnexhausted drops candidates predicted to be rate limited while at least one other
+// candidate still has allowance. Exhausted credentials are kept as a last resort because the
+// prediction may be stale.
+func preferUnexhausted(model string, auths []*Auth, now time.Time) []*Auth {
+	if model == "" || len(auths) < 2 {
+		return auths
+	}
+	fresh := auths[:0:0]
+	for _, candidate := range auths {
+		if !predictedExhausted(candidate, model, now) {
+			fresh = append(fresh, candidate)
+		}
+	}
+	if len(fresh) == 0 {
+		return auths
+	}
+	return fresh
+}
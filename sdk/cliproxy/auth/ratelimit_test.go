@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/radityprtama/proxygate/v6/internal/registry"
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	anthropic := http.Header{}
+	anthropic.Set("anthropic-ratelimit-requests-limit", "50")
+	anthropic.Set("anthropic-ratelimit-requests-remaining", "0")
+	anthropic.Set("anthropic-ratelimit-requests-reset", "2025-01-01T12:00:30Z")
+	anthropic.Set("anthropic-ratelimit-tokens-remaining", "1200")
+	snapshot := ParseRateLimitHeaders(http.StatusOK, anthropic, now)
+	if snapshot == nil || snapshot.Source != RateLimitSourceAnthropic || *snapshot.RequestsRemaining != 0 || *snapshot.TokensRemaining != 1200 {
+		t.Fatalf("unexpected anthropic snapshot: %+v", snapshot)
+	}
+	if exhausted, recoverAt := snapshot.Exhausted(now); !exhausted || !recoverAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("expected exhausted until reset, got %v %v", exhausted, recoverAt)
+	}
+	if exhausted, _ := snapshot.Exhausted(now.Add(time.Minute)); exhausted {
+		t.Fatalf("expected snapshot to recover after reset")
+	}
+
+	openai := http.Header{}
+	openai.Set("x-ratelimit-remaining-requests", "10")
+	openai.Set("x-ratelimit-remaining-tokens", "0")
+	openai.Set("x-ratelimit-reset-tokens", "6m0s")
+	snapshot = ParseRateLimitHeaders(http.StatusOK, openai, now)
+	if snapshot == nil || snapshot.Source != RateLimitSourceOpenAI || !snapshot.TokensReset.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("unexpected openai snapshot: %+v", snapshot)
+	}
+
+	retry := http.Header{}
+	retry.Set("Retry-After", "12")
+	snapshot = ParseRateLimitHeaders(http.StatusTooManyRequests, retry, now)
+	if snapshot == nil || !snapshot.RequestsReset.Equal(now.Add(12*time.Second)) {
+		t.Fatalf("unexpected retry-after snapshot: %+v", snapshot)
+	}
+	if ParseRateLimitHeaders(http.StatusOK, retry, now) != nil {
+		t.Fatalf("expected Retry-After to be ignored outside 429 responses")
+	}
+}
+
+func TestManagerSkipsPredictedExhaustedAuth(t *testing.T) {
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	for _, id := range []string{"ratelimit-a", "ratelimit-b"} {
+		reg.RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "ratelimit-test-model"}})
+		defer reg.UnregisterClient(id)
+	}
+
+	m := NewManager(nil, nil, nil)
+	hits := make(map[string]int)
+	m.RegisterExecutor(stubExecutor{provider: "claude", execute: func(execCtx context.Context, auth *Auth, _ cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		hits[auth.ID]++
+		if auth.ID == "ratelimit-a" {
+			headers := http.Header{}
+			headers.Set("anthropic-ratelimit-requests-remaining", "0")
+			headers.Set("anthropic-ratelimit-requests-reset", time.Now().Add(time.Minute).Format(time.RFC3339))
+			ObserveRateLimitHeaders(execCtx, http.StatusOK, headers)
+		}
+		return cliproxyexecutor.Response{}, nil
+	}})
+	for _, id := range []string{"ratelimit-a", "ratelimit-b"} {
+		if _, err := m.Register(ctx, &Auth{ID: id, Provider: "claude"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	req := cliproxyexecutor.Request{Model: "ratelimit-test-model"}
+	for i := 0; i < 4; i++ {
+		if _, err := m.Execute(ctx, []string{"claude"}, req, cliproxyexecutor.Options{}); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+	}
+	if hits["ratelimit-a"] != 1 {
+		t.Fatalf("expected exhausted auth to be skipped after its snapshot, got %d hits", hits["ratelimit-a"])
+	}
+	exhausted, _ := m.GetByID("ratelimit-a")
+	if state := exhausted.ModelStates["ratelimit-test-model"]; state == nil || state.RateLimit == nil {
+		t.Fatalf("expected rate limit snapshot to be recorded")
+	}
+}
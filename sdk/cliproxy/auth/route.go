@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+// FallbackModelHeader reports the substitute model when a model fallback served the request.
+const FallbackModelHeader = "X-ProxyGate-Fallback-Model"
+
+type routeInfoContextKey struct{}
+
+type requestedModelContextKey struct{}
+
+// RouteResult describes the model and credential that ultimately served a request.
+type RouteResult struct {
+	// RequestedModel is the model the client asked for.
+	RequestedModel string
+	// Model is the model that served the request; it differs from RequestedModel after a fallback.
+	Model string
+	// Provider is the provider key of the serving credential.
+	Provider string
+	// AuthID identifies the serving credential.
+	AuthID string
+	// AuthIndex is the runtime index of the serving credential.
+	AuthIndex uint64
+}
+
+// Fallback reports whether a fallback model served the request.
+func (r RouteResult) Fallback() bool {
+	return r.RequestedModel != "" && r.Model != "" && r.Model != r.RequestedModel
+}
+
+// RouteInfo collects routing decisions made by the manager so API handlers can echo them back.
+type RouteInfo struct {
+	mu     sync.Mutex
+	result RouteResult
+}
+
+// WithRouteInfo attaches a fresh RouteInfo to ctx.
+func WithRouteInfo(ctx context.Context) (context.Context, *RouteInfo) {
+	info := &RouteInfo{}
+	return context.WithValue(ctx, routeInfoContextKey{}, info), info
+}
+
+// Result returns a copy of the recorded routing decision.
+func (r *RouteInfo) Result() RouteResult {
+	if r == nil {
+		return RouteResult{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.result
+}
+
+func routeInfoFromContext(ctx context.Context) *RouteInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(routeInfoContextKey{}).(*RouteInfo)
+	return info
+}
+
+// recordRoute stores the serving credential on the RouteInfo attached to ctx, if any.
+func recordRoute(ctx context.Context, provider, model string, auth *Auth) {
+	info := routeInfoFromContext(ctx)
+	if info == nil || auth == nil {
+		return
+	}
+	requested := RequestedModelFromContext(ctx)
+	if requested == "" {
+		requested = model
+	}
+	info.mu.Lock()
+	info.result = RouteResult{
+		RequestedModel: requested,
+		Model:          model,
+		Provider:       provider,
+		AuthID:         auth.ID,
+		AuthIndex:      auth.Index,
+	}
+	info.mu.Unlock()
+}
+
+// withRequestedModel marks ctx as executing a fallback for the client-requested model.
+func withRequestedModel(ctx context.Context, model string) context.Context {
+	if RequestedModelFromContext(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestedModelContextKey{}, model)
+}
+
+// RequestedModelFromContext returns the client-requested model when ctx is executing a
+// fallback model, or an empty string otherwise.
+func RequestedModelFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	model, _ := ctx.Value(requestedModelContextKey{}).(string)
+	return model
+}
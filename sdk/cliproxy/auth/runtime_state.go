@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// runtimeStateFlushInterval throttles how often runtime availability state is written to the store.
+const runtimeStateFlushInterval = 30 * time.Second
+
+// runtimeStateSaveTimeout bounds a single runtime state write.
+const runtimeStateSaveTimeout = 30 * time.Second
+
+// RuntimeState is the persisted subset of an auth's availability: cooldowns, quota backoff and
+// per-model states. It lets a restarted process keep honouring upstream blocks.
+type RuntimeState struct {
+	Status         Status                 `json:"status,omitempty"`
+	StatusMessage  string                 `json:"status_message,omitempty"`
+	Unavailable    bool                   `json:"unavailable,omitempty"`
+	NextRetryAfter time.Time              `json:"next_retry_after,omitempty"`
+	Quota          QuotaState             `json:"quota"`
+	ModelStates    map[string]*ModelState `json:"model_states,omitempty"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// RuntimeStateStore is implemented by stores that can persist runtime availability state,
+// keyed by auth ID, alongside the credentials themselves.
+type RuntimeStateStore interface {
+	// LoadRuntimeStates returns the last saved runtime states.
+	LoadRuntimeStates(ctx context.Context) (map[string]*RuntimeState, error)
+	// SaveRuntimeStates replaces the saved runtime states.
+	SaveRuntimeStates(ctx context.Context, states map[string]*RuntimeState) error
+}
+
+// RuntimeStateDocument is the StateStore document name holding runtime states.
+const RuntimeStateDocument = "runtime-state"
+
+// StateStore is implemented by stores that can persist named JSON documents, such as runtime
+// state or usage budgets, alongside the credentials themselves.
+type StateStore interface {
+	// LoadState returns the saved document, or nil when it does not exist.
+	LoadState(ctx context.Context, name string) ([]byte, error)
+	// SaveState replaces the saved document.
+	SaveState(ctx context.Context, name string, data []byte) error
+}
+
+// StateFlusher is implemented by StateStores that defer writing saved documents through to
+// their backend and must be flushed before shutdown.
+type StateFlusher interface {
+	FlushState(ctx context.Context) error
+}
+
+// LoadRuntimeStatesFrom decodes the runtime state document of a StateStore.
+func LoadRuntimeStatesFrom(ctx context.Context, store StateStore) (map[string]*RuntimeState, error) {
+	data, err := store.LoadState(ctx, RuntimeStateDocument)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	states := make(map[string]*RuntimeState)
+	if len(data) == 0 {
+		return states, nil
+	}
+	if err = json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("unmarshal runtime state: %w", err)
+	}
+	return states, nil
+}
+
+// SaveRuntimeStatesTo encodes runtime states into the StateStore document.
+func SaveRuntimeStatesTo(ctx context.Context, store StateStore, states map[string]*RuntimeState) error {
+	raw, err := json.Marshal(states)
+	if err != nil {
+		return fmt.Errorf("marshal runtime state: %w", err)
+	}
+	return store.SaveState(ctx, RuntimeStateDocument, raw)
+}
+
+// StateStore returns the manager's store when it can persist named documents.
+func (m *Manager) StateStore() StateStore {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	store, _ := m.store.(StateStore)
+	return store
+}
+
+// captureRuntimeState returns the state worth persisting for auth, or nil when every cooldown
+// has expired and no rate-limit snapshot predicts exhaustion.
+func captureRuntimeState(auth *Auth, now time.Time) *RuntimeState {
+	if auth == nil {
+		return nil
+	}
+	state := &RuntimeState{UpdatedAt: now}
+	keep := false
+	if auth.NextRetryAfter.After(now) {
+		state.Status = auth.Status
+		state.StatusMessage = auth.StatusMessage
+		state.Unavailable = auth.Unavailable
+		state.NextRetryAfter = auth.NextRetryAfter
+		state.Quota = auth.Quota
+		keep = true
+	}
+	for model, modelState := range auth.ModelStates {
+		if !modelStateActive(modelState, now) {
+			continue
+		}
+		if state.ModelStates == nil {
+			state.ModelStates = make(map[string]*ModelState)
+		}
+		state.ModelStates[model] = modelState.Clone()
+		keep = true
+	}
+	if !keep {
+		return nil
+	}
+	return state
+}
+
+// modelStateActive reports whether a model state still carries a live cooldown or an
+// exhausted rate-limit prediction.
+func modelStateActive(state *ModelState, now time.Time) bool {
+	if state == nil {
+		return false
+	}
+	if state.NextRetryAfter.After(now) {
+		return true
+	}
+	exhausted, _ := state.RateLimit.Exhausted(now)
+	return exhausted
+}
+
+// active reports whether any part of the state has not expired yet.
+func (s *RuntimeState) active(now time.Time) bool {
+	if s == nil {
+		return false
+	}
+	if s.NextRetryAfter.After(now) {
+		return true
+	}
+	for _, modelState := range s.ModelStates {
+		if modelStateActive(modelState, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreRuntimeState applies a persisted state to auth, discarding expired entries. Live state
+// already tracked on auth takes precedence.
+func restoreRuntimeState(auth *Auth, state *RuntimeState, now time.Time) {
+	if auth == nil || state == nil || len(auth.ModelStates) > 0 || auth.NextRetryAfter.After(now) {
+		return
+	}
+	for model, modelState := range state.ModelStates {
+		if !modelStateActive(modelState, now) {
+			continue
+		}
+		restored := modelState.Clone()
+		if !restored.NextRetryAfter.After(now) {
+			restored.Unavailable = false
+			restored.NextRetryAfter = time.Time{}
+			restored.Quota = QuotaState{}
+		}
+		if auth.ModelStates == nil {
+			auth.ModelStates = make(map[string]*ModelState)
+		}
+		auth.ModelStates[model] = restored
+	}
+	if len(auth.ModelStates) > 0 {
+		updateAggregatedAvailability(auth, now)
+		if auth.Unavailable || hasModelError(auth, now) {
+			auth.Status = StatusError
+		}
+		return
+	}
+	if state.NextRetryAfter.After(now) {
+		auth.Status = state.Status
+		auth.StatusMessage = state.StatusMessage
+		auth.Unavailable = state.Unavailable
+		auth.NextRetryAfter = state.NextRetryAfter
+		auth.Quota = state.Quota
+	}
+}
+
+// runtimeStatePersister throttles runtime state writes and holds restored states until the
+// matching auth is registered.
+type runtimeStatePersister struct {
+	mu       sync.Mutex
+	pending  map[string]*RuntimeState
+	timer    *time.Timer
+	interval time.Duration
+}
+
+func newRuntimeStatePersister() *runtimeStatePersister {
+	return &runtimeStatePersister{pending: make(map[string]*RuntimeState), interval: runtimeStateFlushInterval}
+}
+
+// take removes and returns the restored state for id.
+func (p *runtimeStatePersister) take(id string) *RuntimeState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state := p.pending[id]
+	delete(p.pending, id)
+	return state
+}
+
+func (m *Manager) runtimeStateStore() RuntimeStateStore {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	store, _ := m.store.(RuntimeStateStore)
+	return store
+}
+
+// loadRuntimeStates reads the persisted runtime states from the store. It does store I/O and
+// must be called without m.mu held; a nil result means there is nothing to restore.
+func (m *Manager) loadRuntimeStates(ctx context.Context) map[string]*RuntimeState {
+	store := m.runtimeStateStore()
+	if store == nil {
+		return nil
+	}
+	states, err := store.LoadRuntimeStates(ctx)
+	if err != nil {
+		log.Warnf("failed to load auth runtime state: %v", err)
+		return nil
+	}
+	if states == nil {
+		states = make(map[string]*RuntimeState)
+	}
+	return states
+}
+
+// applyRuntimeStatesLocked restores loaded runtime states onto loaded auths and keeps them
+// pending for auths registered later (for example by the file watcher). Callers hold m.mu.
+func (m *Manager) applyRuntimeStatesLocked(states map[string]*RuntimeState) {
+	if states == nil {
+		return
+	}
+	now := time.Now()
+	pending := make(map[string]*RuntimeState, len(states))
+	for id, state := range states {
+		if !state.active(now) {
+			continue
+		}
+		if auth, exists := m.auths[id]; exists {
+			restoreRuntimeState(auth, state, now)
+		}
+		pending[id] = state
+	}
+	m.runtimeState.mu.Lock()
+	m.runtimeState.pending = pending
+	m.runtimeState.mu.Unlock()
+	if len(pending) > 0 {
+		log.Debugf("restored runtime state for %d auth(s)", len(pending))
+	}
+}
+
+// applyPendingRuntimeState restores a persisted state the first time an auth is registered or
+// updated after Load, since watcher-synthesized auths arrive without temporal fields.
+func (m *Manager) applyPendingRuntimeState(auth *Auth) {
+	if auth == nil || m.runtimeState == nil {
+		return
+	}
+	if state := m.runtimeState.take(auth.ID); state != nil {
+		restoreRuntimeState(auth, state, time.Now())
+	}
+}
+
+// scheduleRuntimeStateSave queues a throttled write of runtime state to the store.
+func (m *Manager) scheduleRuntimeStateSave() {
+	if m.runtimeState == nil || m.runtimeStateStore() == nil {
+		return
+	}
+	p := m.runtimeState
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timer != nil {
+		return
+	}
+	p.timer = time.AfterFunc(p.interval, func() {
+		p.mu.Lock()
+		p.timer = nil
+		p.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), runtimeStateSaveTimeout)
+		defer cancel()
+		if err := m.saveRuntimeStates(ctx); err != nil {
+			log.Warnf("failed to persist auth runtime state: %v", err)
+		}
+	})
+}
+
+// FlushRuntimeState writes the current runtime availability state to the store immediately,
+// cancelling any pending throttled write.
+func (m *Manager) FlushRuntimeState(ctx context.Context) error {
+	if m == nil || m.runtimeState == nil {
+		return nil
+	}
+	m.runtimeState.mu.Lock()
+	if m.runtimeState.timer != nil {
+		m.runtimeState.timer.Stop()
+		m.runtimeState.timer = nil
+	}
+	m.runtimeState.mu.Unlock()
+	return m.saveRuntimeStates(ctx)
+}
+
+func (m *Manager) saveRuntimeStates(ctx context.Context) error {
+	store := m.runtimeStateStore()
+	if store == nil {
+		return nil
+	}
+	now := time.Now()
+	m.mu.RLock()
+	states := make(map[string]*RuntimeState)
+	for id, auth := range m.auths {
+		if state := captureRuntimeState(auth, now); state != nil {
+			states[id] = state
+		}
+	}
+	m.mu.RUnlock()
+	// Keep restored states whose auths have not been registered yet.
+	m.runtimeState.mu.Lock()
+	for id, state := range m.runtimeState.pending {
+		if _, exists := states[id]; exists {
+			continue
+		}
+		if state.active(now) {
+			states[id] = state
+		}
+	}
+	m.runtimeState.mu.Unlock()
+	return store.SaveRuntimeStates(ctx, states)
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryRuntimeStore struct {
+	mu     sync.Mutex
+	auths  map[string]*Auth
+	states map[string]*RuntimeState
+}
+
+func (s *memoryRuntimeStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.auths))
+	for _, auth := range s.auths {
+		// Stores return credentials without runtime state, like the file-backed stores do.
+		out = append(out, &Auth{ID: auth.ID, Provider: auth.Provider, Status: StatusActive})
+	}
+	return out, nil
+}
+
+func (s *memoryRuntimeStore) Save(_ context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *memoryRuntimeStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.auths, id)
+	return nil
+}
+
+func (s *memoryRuntimeStore) LoadRuntimeStates(context.Context) (map[string]*RuntimeState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.states, nil
+}
+
+func (s *memoryRuntimeStore) SaveRuntimeStates(_ context.Context, states map[string]*RuntimeState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = states
+	return nil
+}
+
+func TestManagerRuntimeState_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryRuntimeStore{auths: make(map[string]*Auth)}
+	first := NewManager(store, nil, nil)
+	for _, id := range []string{"blocked", "healthy"} {
+		if _, err := first.Register(ctx, &Auth{ID: id, Provider: "claude"}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	retryAfter := time.Hour
+	first.MarkResult(ctx, Result{AuthID: "blocked", Provider: "claude", Model: "m1", RetryAfter: &retryAfter, Error: &Error{Message: "quota", HTTPStatus: http.StatusTooManyRequests}})
+	if err := first.FlushRuntimeState(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if len(store.states) != 1 || store.states["blocked"] == nil {
+		t.Fatalf("expected only the blocked auth to be persisted, got %+v", store.states)
+	}
+	store.states["expired"] = &RuntimeState{ModelStates: map[string]*ModelState{"m1": {Unavailable: true, NextRetryAfter: time.Now().Add(-time.Minute)}}}
+
+	second := NewManager(store, nil, nil)
+	if err := second.Load(ctx); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	// The watcher re-registers auths without temporal fields after Load.
+	if _, err := second.Update(ctx, &Auth{ID: "blocked", Provider: "claude"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	restored, ok := second.GetByID("blocked")
+	if !ok {
+		t.Fatalf("expected blocked auth after load")
+	}
+	state := restored.ModelStates["m1"]
+	if state == nil || !state.NextRetryAfter.After(time.Now().Add(50*time.Minute)) || !state.Quota.Exceeded {
+		t.Fatalf("expected cooldown to be restored, got %+v", state)
+	}
+	if blocked, _, _ := isAuthBlockedForModel(restored, "m1", time.Now()); !blocked {
+		t.Fatalf("expected restored auth to stay blocked for m1")
+	}
+	second.runtimeState.mu.Lock()
+	_, keptExpired := second.runtimeState.pending["expired"]
+	second.runtimeState.mu.Unlock()
+	if keptExpired {
+		t.Fatalf("expected expired runtime state to be discarded")
+	}
+}
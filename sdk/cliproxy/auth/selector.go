@@ -108,8 +108,32 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 	if s.cursors == nil {
 		s.cursors = make(map[string]int)
 	}
+	available, err := availableAuthsForModel(provider, model, auths, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	// Make round-robin deterministic even if caller's candidate order is unstable.
+	if len(available) > 1 {
+		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
+	}
+	key := provider + ":" + model
+	s.mu.Lock()
+	index := s.cursors[key]
+
+	if index >= 2_147_483_640 {
+		index = 0
+	}
+
+	s.cursors[key] = index + 1
+	s.mu.Unlock()
+	// log.Debugf("available: %d, index: %d, key: %d", len(available), index, index%len(available))
+	return available[index%len(available)], nil
+}
+
+// availableAuthsForModel filters out candidates blocked for the model. When every candidate
+// is cooling down it returns a model cooldown error carrying the earliest reset time.
+func availableAuthsForModel(provider, model string, auths []*Auth, now time.Time) ([]*Auth, error) {
 	available := make([]*Auth, 0, len(auths))
-	now := time.Now()
 	cooldownCount := 0
 	var earliest time.Time
 	for i := 0; i < len(auths); i++ {
@@ -136,22 +160,7 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 		}
 		return nil, &Error{Code: "auth_unavailable", Message: "no auth available"}
 	}
-	// Make round-robin deterministic even if caller's candidate order is unstable.
-	if len(available) > 1 {
-		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
-	}
-	key := provider + ":" + model
-	s.mu.Lock()
-	index := s.cursors[key]
-
-	if index >= 2_147_483_640 {
-		index = 0
-	}
-
-	s.cursors[key] = index + 1
-	s.mu.Unlock()
-	// log.Debugf("available: %d, index: %d, key: %d", len(available), index, index%len(available))
-	return available[index%len(available)], nil
+	return preferUnexhausted(model, available, now), nil
 }
 
 func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, blockReason, time.Time) {
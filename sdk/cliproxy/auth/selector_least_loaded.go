@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+// LeastLoadedSelector picks the healthy credential with the fewest in-flight requests for the
+// model, breaking ties by the lowest rolling time-to-first-byte. Credentials without samples
+// count as fastest so new or recovered accounts get probed. Remaining ties rotate round-robin.
+type LeastLoadedSelector struct {
+	mu      sync.Mutex
+	source  LoadSource
+	cursors map[string]int
+}
+
+// SetLoadSource binds the provider of live load figures.
+func (s *LeastLoadedSelector) SetLoadSource(source LoadSource) {
+	s.mu.Lock()
+	s.source = source
+	s.mu.Unlock()
+}
+
+// Pick selects the least-loaded available auth.
+func (s *LeastLoadedSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	_ = opts
+	if len(auths) == 0 {
+		return nil, &Error{Code: "auth_not_found", Message: "no auth candidates"}
+	}
+	available, err := availableAuthsForModel(provider, model, auths, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(available) > 1 {
+		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	best := available
+	if s.source != nil && len(available) > 1 {
+		best = make([]*Auth, 0, len(available))
+		var bestLoad AuthLoad
+		for _, candidate := range available {
+			load := s.source.AuthLoad(candidate.ID, model)
+			if len(best) == 0 || lessLoaded(load, bestLoad) {
+				best = append(best[:0], candidate)
+				bestLoad = load
+				continue
+			}
+			if !lessLoaded(bestLoad, load) {
+				best = append(best, candidate)
+			}
+		}
+	}
+	if len(best) == 1 {
+		return best[0], nil
+	}
+	if s.cursors == nil {
+		s.cursors = make(map[string]int)
+	}
+	key := provider + ":" + model
+	index := s.cursors[key]
+	if index >= 2_147_483_640 {
+		index = 0
+	}
+	s.cursors[key] = index + 1
+	return best[index%len(best)], nil
+}
+
+// lessLoaded reports whether a should be preferred over b.
+func lessLoaded(a, b AuthLoad) bool {
+	if a.InFlight != b.InFlight {
+		return a.InFlight < b.InFlight
+	}
+	return a.TTFB < b.TTFB
+}
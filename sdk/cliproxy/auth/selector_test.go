@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+func TestWeightedPrioritySelector_DistributesByWeight(t *testing.T) {
+	auths := []*Auth{
+		{ID: "a", Attributes: map[string]string{"weight": "3"}},
+		{ID: "b", Attributes: map[string]string{"weight": "1"}},
+	}
+	selector := &WeightedPrioritySelector{}
+	counts := make(map[string]int)
+	sequence := ""
+	for i := 0; i < 8; i++ {
+		picked, err := selector.Pick(context.Background(), "claude", "m", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		counts[picked.ID]++
+		sequence += picked.ID
+	}
+	if counts["a"] != 6 || counts["b"] != 2 {
+		t.Fatalf("expected 6/2 split, got %v", counts)
+	}
+	if sequence != "aabaaaba" {
+		t.Fatalf("expected deterministic sequence aabaaaba, got %s", sequence)
+	}
+}
+
+func TestWeightedPrioritySelector_FallsThroughTiers(t *testing.T) {
+	now := time.Now()
+	primary := &Auth{ID: "primary", Attributes: map[string]string{"priority": "10"}}
+	backup := &Auth{ID: "backup", Metadata: map[string]any{"priority": float64(1)}}
+	selector := &WeightedPrioritySelector{}
+
+	for i := 0; i < 3; i++ {
+		picked, err := selector.Pick(context.Background(), "codex", "m", cliproxyexecutor.Options{}, []*Auth{backup, primary})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if picked.ID != "primary" {
+			t.Fatalf("expected primary tier, got %s", picked.ID)
+		}
+	}
+
+	primary.ModelStates = map[string]*ModelState{
+		"m": {
+			Unavailable:    true,
+			NextRetryAfter: now.Add(time.Minute),
+			Quota:          QuotaState{Exceeded: true, NextRecoverAt: now.Add(time.Minute)},
+		},
+	}
+	picked, err := selector.Pick(context.Background(), "codex", "m", cliproxyexecutor.Options{}, []*Auth{backup, primary})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if picked.ID != "backup" {
+		t.Fatalf("expected fallback to backup tier, got %s", picked.ID)
+	}
+}
+
+func TestLeastLoadedSelector_PrefersIdleThenFastest(t *testing.T) {
+	m := NewManager(nil, &LeastLoadedSelector{}, nil)
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	releaseA := m.loads.begin("a", "m")
+	defer releaseA()
+	m.loads.observeTTFB("b", "m", 900*time.Millisecond)
+	m.loads.observeTTFB("c", "m", 200*time.Millisecond)
+
+	picked, err := m.selector.Pick(context.Background(), "codex", "m", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if picked.ID != "c" {
+		t.Fatalf("expected fastest idle auth c, got %s", picked.ID)
+	}
+	if load := m.AuthLoad("a", "m"); load.InFlight != 1 {
+		t.Fatalf("expected 1 in-flight request on a, got %d", load.InFlight)
+	}
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// SelectorStrategyRoundRobin rotates evenly across every available credential.
+	SelectorStrategyRoundRobin = "round-robin"
+	// SelectorStrategyWeightedPriority prefers higher priority tiers and balances by weight within a tier.
+	SelectorStrategyWeightedPriority = "weighted-priority"
+	// SelectorStrategyLeastLoaded prefers the credential with the fewest in-flight requests, then the fastest.
+	SelectorStrategyLeastLoaded = "least-loaded"
+)
+
+// DefaultAuthWeight is the weight assumed for credentials without an explicit weight.
+const DefaultAuthWeight = 1
+
+// NewSelector returns the built-in selector registered under the given strategy name.
+// Unknown or empty names fall back to round-robin.
+func NewSelector(strategy string) Selector {
+	switch NormalizeSelectorStrategy(strategy) {
+	case SelectorStrategyWeightedPriority:
+		return &WeightedPrioritySelector{}
+	case SelectorStrategyLeastLoaded:
+		return &LeastLoadedSelector{}
+	default:
+		return &RoundRobinSelector{}
+	}
+}
+
+// NormalizeSelectorStrategy canonicalises a configured strategy name.
+func NormalizeSelectorStrategy(strategy string) string {
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case "weighted-priority", "weighted_priority", "weighted", "priority":
+		return SelectorStrategyWeightedPriority
+	case "least-loaded", "least_loaded", "least-in-flight", "least_in_flight", "latency":
+		return SelectorStrategyLeastLoaded
+	default:
+		return SelectorStrategyRoundRobin
+	}
+}
+
+// WeightedPrioritySelector groups credentials into priority tiers and only falls through to a
+// lower tier when every credential in the higher tiers is cooling down or disabled. Inside a
+// tier it uses smooth weighted round-robin, which is deterministic for a given candidate set.
+type WeightedPrioritySelector struct {
+	mu sync.Mutex
+	// current holds the running smooth-WRR weights keyed by provider:model, then auth ID.
+	current map[string]map[string]int
+}
+
+// Pick selects the next auth from the highest available priority tier.
+func (s *WeightedPrioritySelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = ctx
+	_ = opts
+	if len(auths) == 0 {
+		return nil, &Error{Code: "auth_not_found", Message: "no auth candidates"}
+	}
+	available, err := availableAuthsForModel(provider, model, auths, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tier := highestPriorityTier(available)
+	if len(tier) == 1 {
+		return tier[0], nil
+	}
+	sort.Slice(tier, func(i, j int) bool { return tier[i].ID < tier[j].ID })
+
+	key := provider + ":" + model
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		s.current = make(map[string]map[string]int)
+	}
+	prev := s.current[key]
+	next := make(map[string]int, len(tier))
+	total := 0
+	var best *Auth
+	bestWeight := math.MinInt
+	for _, candidate := range tier {
+		weight := AuthWeight(candidate)
+		total += weight
+		value := prev[candidate.ID] + weight
+		next[candidate.ID] = value
+		if value > bestWeight {
+			best = candidate
+			bestWeight = value
+		}
+	}
+	next[best.ID] -= total
+	s.current[key] = next
+	return best, nil
+}
+
+// highestPriorityTier returns the candidates sharing the highest priority value.
+func highestPriorityTier(auths []*Auth) []*Auth {
+	top := math.MinInt
+	for _, candidate := range auths {
+		if p := AuthPriority(candidate); p > top {
+			top = p
+		}
+	}
+	tier := make([]*Auth, 0, len(auths))
+	for _, candidate := range auths {
+		if AuthPriority(candidate) == top {
+			tier = append(tier, candidate)
+		}
+	}
+	return tier
+}
+
+// AuthPriority reports the selection tier of an auth. Higher values are preferred; the default is 0.
+// The value is read from the "priority" attribute, falling back to auth file metadata.
+func AuthPriority(a *Auth) int {
+	if value, ok := authIntSetting(a, "priority"); ok {
+		return value
+	}
+	return 0
+}
+
+// AuthWeight reports the relative share of traffic an auth receives within its tier.
+// Missing or non-positive values resolve to DefaultAuthWeight.
+func AuthWeight(a *Auth) int {
+	if value, ok := authIntSetting(a, "weight"); ok && value > 0 {
+		return value
+	}
+	return DefaultAuthWeight
+}
+
+// authIntSetting looks up an integer setting in attributes first, then metadata.
+func authIntSetting(a *Auth, key string) (int, bool) {
+	if a == nil {
+		return 0, false
+	}
+	if a.Attributes != nil {
+		if raw := strings.TrimSpace(a.Attributes[key]); raw != "" {
+			if value, err := strconv.Atoi(raw); err == nil {
+				return value, true
+			}
+		}
+	}
+	if a.Metadata == nil {
+		return 0, false
+	}
+	switch v := a.Metadata[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		if value, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return value, true
+		}
+	}
+	return 0, false
+}
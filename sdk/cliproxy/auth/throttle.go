@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ThrottleLimits caps how hard a single credential is driven.
+type ThrottleLimits struct {
+	// MaxConcurrent is the maximum number of requests executing at once; zero means unlimited.
+	MaxConcurrent int
+	// MinInterval is the minimum spacing between request starts; zero disables pacing.
+	MinInterval time.Duration
+}
+
+func (l ThrottleLimits) enabled() bool {
+	return l.MaxConcurrent > 0 || l.MinInterval > 0
+}
+
+// QueueStats describes how long a request waited for a throttled credential.
+type QueueStats struct {
+	// Depth counts the requests already queued on the credential when this one arrived.
+	Depth int
+	// Wait is the time spent queued before the request was sent upstream.
+	Wait time.Duration
+}
+
+type queueStatsContextKey struct{}
+
+// QueueStatsFromContext returns the queue figures recorded for the credential executing ctx.
+func QueueStatsFromContext(ctx context.Context) QueueStats {
+	if ctx == nil {
+		return QueueStats{}
+	}
+	stats, _ := ctx.Value(queueStatsContextKey{}).(QueueStats)
+	return stats
+}
+
+// AuthThrottleLimits reads the max_concurrent and min_interval settings of an auth from its
+// attributes (config entries) or metadata (auth files).
+func AuthThrottleLimits(a *Auth) ThrottleLimits {
+	var limits ThrottleLimits
+	if value, ok := authIntSetting(a, "max_concurrent"); ok && value > 0 {
+		limits.MaxConcurrent = value
+	}
+	if value, ok := authDurationSetting(a, "min_interval"); ok && value > 0 {
+		limits.MinInterval = value
+	}
+	return limits
+}
+
+// authDurationSetting looks up a duration setting in attributes first, then metadata.
+// Strings use Go duration syntax ("500ms", "2s"); bare numbers are milliseconds.
+func authDurationSetting(a *Auth, key string) (time.Duration, bool) {
+	if a == nil {
+		return 0, false
+	}
+	if a.Attributes != nil {
+		if raw := strings.TrimSpace(a.Attributes[key]); raw != "" {
+			return parseThrottleInterval(raw)
+		}
+	}
+	if a.Metadata == nil {
+		return 0, false
+	}
+	switch v := a.Metadata[key].(type) {
+	case int:
+		return time.Duration(v) * time.Millisecond, true
+	case int64:
+		return time.Duration(v) * time.Millisecond, true
+	case float64:
+		return time.Duration(v * float64(time.Millisecond)), true
+	case string:
+		return parseThrottleInterval(v)
+	}
+	return 0, false
+}
+
+func parseThrottleInterval(raw string) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, true
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return d, true
+	}
+	return 0, false
+}
+
+// defaultMaxQueueWait bounds the wait for a throttled credential when none is configured.
+const defaultMaxQueueWait = 30 * time.Second
+
+// queueTimeoutCode marks the error returned when a credential stayed saturated for the whole
+// queue wait.
+const queueTimeoutCode = "queue_timeout"
+
+// errQueueTimeout is returned by acquire when no slot freed up within the wait bound.
+var errQueueTimeout = errors.New("timed out waiting for a credential slot")
+
+func isQueueTimeout(err error) bool {
+	var authErr *Error
+	return errors.As(err, &authErr) && authErr.Code == queueTimeoutCode
+}
+
+type throttleEntry struct {
+	active    int
+	waiting   int
+	nextStart time.Time
+	// wake is closed and replaced whenever a slot frees up.
+	wake chan struct{}
+}
+
+// authThrottle enforces per-auth concurrency caps and start spacing.
+type authThrottle struct {
+	mu      sync.Mutex
+	entries map[string]*throttleEntry
+}
+
+func newAuthThrottle() *authThrottle {
+	return &authThrottle{entries: make(map[string]*throttleEntry)}
+}
+
+func (t *authThrottle) entryLocked(authID string) *throttleEntry {
+	entry := t.entries[authID]
+	if entry == nil {
+		entry = &throttleEntry{wake: make(chan struct{})}
+		t.entries[authID] = entry
+	}
+	return entry
+}
+
+// forget drops the entry for authID. Holders of a slot keep releasing their own entry.
+func (t *authThrottle) forget(authID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.entries, authID)
+	t.mu.Unlock()
+}
+
+// ready reports whether a request on authID would start without queueing.
+func (t *authThrottle) ready(authID string, limits ThrottleLimits, now time.Time) bool {
+	if t == nil || !limits.enabled() {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entries[authID]
+	if entry == nil {
+		return true
+	}
+	if limits.MaxConcurrent > 0 && entry.active+entry.waiting >= limits.MaxConcurrent {
+		return false
+	}
+	return limits.MinInterval <= 0 || !now.Before(entry.nextStart)
+}
+
+// acquire blocks until authID has a free slot and its pacing interval has elapsed, or ctx ends.
+// A positive maxWait bounds the wait for a slot; errQueueTimeout is returned when it runs out.
+// The returned function releases the slot exactly once.
+func (t *authThrottle) acquire(ctx context.Context, authID string, limits ThrottleLimits, maxWait time.Duration) (QueueStats, func(), error) {
+	noop := func() {}
+	if t == nil || authID == "" || !limits.enabled() {
+		return QueueStats{}, noop, nil
+	}
+	started := time.Now()
+	var deadline <-chan time.Time
+	if maxWait > 0 {
+		timer := time.NewTimer(maxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	t.mu.Lock()
+	entry := t.entryLocked(authID)
+	stats := QueueStats{Depth: entry.waiting}
+	entry.waiting++
+	for limits.MaxConcurrent > 0 && entry.active >= limits.MaxConcurrent {
+		wake := entry.wake
+		t.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			t.mu.Lock()
+			entry.waiting--
+			t.mu.Unlock()
+			return stats, noop, ctx.Err()
+		case <-deadline:
+			t.mu.Lock()
+			entry.waiting--
+			t.mu.Unlock()
+			return stats, noop, errQueueTimeout
+		case <-wake:
+		}
+		t.mu.Lock()
+	}
+	// Reserve the next start slot so concurrent waiters are spaced out rather than released
+	// together, unless that slot lies beyond the wait bound.
+	startAt := time.Now()
+	if limits.MinInterval > 0 && entry.nextStart.After(startAt) {
+		startAt = entry.nextStart
+	}
+	entry.waiting--
+	if maxWait > 0 && startAt.Sub(started) > maxWait {
+		t.mu.Unlock()
+		return stats, noop, errQueueTimeout
+	}
+	entry.active++
+	reserved := startAt.Add(limits.MinInterval)
+	if limits.MinInterval > 0 {
+		entry.nextStart = reserved
+	}
+	t.mu.Unlock()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			t.mu.Lock()
+			if entry.active > 0 {
+				entry.active--
+			}
+			close(entry.wake)
+			entry.wake = make(chan struct{})
+			t.mu.Unlock()
+		})
+	}
+	// abandon gives the start slot back when no later request has reserved one behind it.
+	abandon := func() {
+		t.mu.Lock()
+		if limits.MinInterval > 0 && entry.nextStart.Equal(reserved) {
+			entry.nextStart = startAt
+		}
+		t.mu.Unlock()
+		release()
+	}
+	if delay := time.Until(startAt); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			abandon()
+			return stats, noop, ctx.Err()
+		case <-deadline:
+			timer.Stop()
+			abandon()
+			return stats, noop, errQueueTimeout
+		case <-timer.C:
+		}
+	}
+	stats.Wait = time.Since(started)
+	return stats, release, nil
+}
+
+// preferThrottleReady drops candidates that would have to queue while another usable candidate
+// can start immediately. Busy credentials are kept when nothing else is free so requests queue.
+func (m *Manager) preferThrottleReady(model string, auths []*Auth, now time.Time) []*Auth {
+	if m.throttle == nil || len(auths) < 2 {
+		return auths
+	}
+	ready := auths[:0:0]
+	for _, candidate := range auths {
+		if blocked, _, _ := isAuthBlockedForModel(candidate, model, now); blocked {
+			continue
+		}
+		if m.throttle.ready(candidate.ID, AuthThrottleLimits(candidate), now) {
+			ready = append(ready, candidate)
+		}
+	}
+	if len(ready) == 0 || len(ready) == len(auths) {
+		return auths
+	}
+	return ready
+}
+
+// acquireThrottle waits for a slot on auth and records the queue figures on the returned context.
+// When the slot does not free up within the queue wait bound, a queue_timeout Error is returned
+// so the caller can move on to another credential.
+func (m *Manager) acquireThrottle(ctx context.Context, auth *Auth) (context.Context, func(), error) {
+	maxWait := time.Duration(m.maxQueueWait.Load())
+	if maxWait <= 0 {
+		maxWait = defaultMaxQueueWait
+	}
+	stats, release, err := m.throttle.acquire(ctx, auth.ID, AuthThrottleLimits(auth), maxWait)
+	if errors.Is(err, errQueueTimeout) {
+		return ctx, release, &Error{
+			Code:       queueTimeoutCode,
+			Message:    "all credential slots stayed busy for " + maxWait.String(),
+			Retryable:  true,
+			HTTPStatus: http.StatusServiceUnavailable,
+		}
+	}
+	if err != nil {
+		return ctx, release, err
+	}
+	if stats.Depth > 0 || stats.Wait > 0 {
+		ctx = context.WithValue(ctx, queueStatsContextKey{}, stats)
+	}
+	return ctx, release, nil
+}
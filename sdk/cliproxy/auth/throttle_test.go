@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/radityprtama/proxygate/v6/internal/registry"
+	cliproxyexecutor "github.com/radityprtama/proxygate/v6/sdk/cliproxy/executor"
+)
+
+func TestAuthThrottle_QueuesBeyondMaxConcurrent(t *testing.T) {
+	throttle := newAuthThrottle()
+	limits := ThrottleLimits{MaxConcurrent: 1}
+	_, release, err := throttle.acquire(context.Background(), "a", limits, 0)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if throttle.ready("a", limits, time.Now()) {
+		t.Fatalf("expected saturated auth not to be ready")
+	}
+
+	done := make(chan QueueStats, 1)
+	go func() {
+		stats, releaseSecond, errSecond := throttle.acquire(context.Background(), "a", limits, 0)
+		if errSecond != nil {
+			t.Errorf("second acquire: %v", errSecond)
+		}
+		releaseSecond()
+		done <- stats
+	}()
+	time.Sleep(30 * time.Millisecond)
+	release()
+	stats := <-done
+	if stats.Wait < 20*time.Millisecond {
+		t.Fatalf("expected queued request to report wait, got %v", stats.Wait)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, hold, _ := throttle.acquire(context.Background(), "a", limits, 0)
+	cancel()
+	if _, _, errCancel := throttle.acquire(ctx, "a", limits, 0); errCancel == nil {
+		t.Fatalf("expected cancelled waiter to give up")
+	}
+	hold()
+}
+
+func TestAuthThrottle_SpacesRequestStarts(t *testing.T) {
+	throttle := newAuthThrottle()
+	limits := ThrottleLimits{MinInterval: 40 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, release, err := throttle.acquire(context.Background(), "a", limits, 0)
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("expected starts spaced by min interval, took %v", elapsed)
+	}
+}
+
+func TestAuthThrottle_PacingRespectsMaxWait(t *testing.T) {
+	throttle := newAuthThrottle()
+	limits := ThrottleLimits{MinInterval: 100 * time.Millisecond}
+	maxWait := 150 * time.Millisecond
+	start := time.Now()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	admitted, timedOut := 0, 0
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, release, err := throttle.acquire(context.Background(), "a", limits, maxWait)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				admitted++
+				release()
+			case errors.Is(err, errQueueTimeout):
+				timedOut++
+			default:
+				t.Errorf("acquire: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if admitted != 2 || timedOut != 3 {
+		t.Fatalf("expected 2 paced starts within the wait bound and 3 timeouts, got %d and %d", admitted, timedOut)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("burst waited %v, beyond the wait bound", elapsed)
+	}
+	throttle.mu.Lock()
+	next := throttle.entries["a"].nextStart
+	active := throttle.entries["a"].active
+	throttle.mu.Unlock()
+	if next.Sub(start) > 250*time.Millisecond || active != 0 {
+		t.Fatalf("timed out requests kept reservations: next start +%v, active %d", next.Sub(start), active)
+	}
+}
+
+func TestManagerUpdate_ForgetsActivityOfDisabledAuth(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(nil, nil, nil)
+	if _, err := m.Register(ctx, &Auth{ID: "gone", Provider: "claude"}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	releaseLoad := m.loads.begin("gone", "m")
+	_, releaseSlot, err := m.throttle.acquire(ctx, "gone", ThrottleLimits{MaxConcurrent: 1}, 0)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err = m.Update(ctx, &Auth{ID: "gone", Provider: "claude", Disabled: true, Status: StatusDisabled}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	releaseLoad()
+	releaseSlot()
+	if len(m.loads.entries) != 0 || len(m.throttle.entries) != 0 {
+		t.Fatalf("expected no entries after disabling, got %d load and %d throttle", len(m.loads.entries), len(m.throttle.entries))
+	}
+}
+
+func TestManagerPickNext_MovesAwayFromBusyThrottledAuth(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(stubExecutor{provider: "gemini-cli"})
+	for _, id := range []string{"busy", "idle"} {
+		auth := &Auth{ID: id, Provider: "gemini-cli", Attributes: map[string]string{"max_concurrent": "1"}}
+		if _, err := manager.Register(ctx, auth); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "gemini-cli", []*registry.ModelInfo{{ID: "throttle-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	busy, _ := manager.GetByID("busy")
+	_, release, err := manager.throttle.acquire(ctx, "busy", AuthThrottleLimits(busy), 0)
+	if err != nil {
+		t.Fatalf("acquire busy: %v", err)
+	}
+	defer release()
+
+	for i := 0; i < 4; i++ {
+		picked, _, errPick := manager.pickNext(ctx, "gemini-cli", "throttle-model", cliproxyexecutor.Options{}, map[string]struct{}{})
+		if errPick != nil {
+			t.Fatalf("pickNext: %v", errPick)
+		}
+		if picked.ID != "idle" {
+			t.Fatalf("expected idle auth while busy auth is saturated, got %s", picked.ID)
+		}
+	}
+}
+
+func TestManagerExecute_QueueWaitBoundedAcrossSaturatedAuths(t *testing.T) {
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.SetMaxQueueWait(50 * time.Millisecond)
+	executed := 0
+	manager.RegisterExecutor(stubExecutor{provider: "gemini-cli", execute: func(context.Context, *Auth, cliproxyexecutor.Request) (cliproxyexecutor.Response, error) {
+		executed++
+		return cliproxyexecutor.Response{}, nil
+	}})
+	for _, id := range []string{"saturated-a", "saturated-b"} {
+		auth := &Auth{ID: id, Provider: "gemini-cli", Attributes: map[string]string{"max_concurrent": "1"}}
+		if _, err := manager.Register(ctx, auth); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "gemini-cli", []*registry.ModelInfo{{ID: "queue-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+		_, release, err := manager.throttle.acquire(ctx, id, AuthThrottleLimits(auth), 0)
+		if err != nil {
+			t.Fatalf("acquire %s: %v", id, err)
+		}
+		defer release()
+	}
+
+	started := time.Now()
+	_, err := manager.Execute(ctx, []string{"gemini-cli"}, cliproxyexecutor.Request{Model: "queue-model"}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != queueTimeoutCode || authErr.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("expected a 503 queue_timeout error, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("expected one bounded wait per credential, took %v", elapsed)
+	}
+	if executed != 0 {
+		t.Fatalf("saturated credentials executed %d requests", executed)
+	}
+}
+
+func TestAuthThrottleLimits_ReadsAttributesAndMetadata(t *testing.T) {
+	fromConfig := AuthThrottleLimits(&Auth{Attributes: map[string]string{"max_concurrent": "2", "min_interval": "1.5s"}})
+	if fromConfig.MaxConcurrent != 2 || fromConfig.MinInterval != 1500*time.Millisecond {
+		t.Fatalf("unexpected config limits %+v", fromConfig)
+	}
+	fromFile := AuthThrottleLimits(&Auth{Metadata: map[string]any{"max_concurrent": float64(1), "min_interval": float64(250)}})
+	if fromFile.MaxConcurrent != 1 || fromFile.MinInterval != 250*time.Millisecond {
+		t.Fatalf("unexpected auth file limits %+v", fromFile)
+	}
+}
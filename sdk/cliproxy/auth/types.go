@@ -90,6 +90,8 @@ type ModelState struct {
 	LastError *Error `json:"last_error,omitempty"`
 	// Quota retains quota information if this model hit rate limits.
 	Quota QuotaState `json:"quota"`
+	// RateLimit holds the latest upstream rate-limit headers for this model.
+	RateLimit *RateLimitSnapshot `json:"rate_limit,omitempty"`
 	// UpdatedAt tracks the last update timestamp for this model state.
 	UpdatedAt time.Time `json:"updated_at"`
 }
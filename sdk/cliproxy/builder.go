@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/radityprtama/proxygate/v6/internal/api"
+	"github.com/radityprtama/proxygate/v6/internal/virtualkey"
 	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
 	sdkAuth "github.com/radityprtama/proxygate/v6/sdk/auth"
 	coreauth "github.com/radityprtama/proxygate/v6/sdk/cliproxy/auth"
@@ -189,6 +190,7 @@ func (b *Builder) Build() (*Service, error) {
 	if err != nil {
 		return nil, err
 	}
+	providers = append(providers, virtualkey.AccessProvider())
 	accessManager.SetProviders(providers)
 
 	coreManager := b.coreManager
@@ -197,7 +199,11 @@ func (b *Builder) Build() (*Service, error) {
 		if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok && b.cfg != nil {
 			dirSetter.SetBaseDir(b.cfg.AuthDir)
 		}
-		coreManager = coreauth.NewManager(tokenStore, nil, nil)
+		var selector coreauth.Selector
+		if b.cfg != nil {
+			selector = coreauth.NewSelector(b.cfg.Routing.Strategy)
+		}
+		coreManager = coreauth.NewManager(tokenStore, selector, nil)
 	}
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
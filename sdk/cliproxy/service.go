@@ -8,14 +8,19 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/radityprtama/proxygate/v6/internal/api"
+	"github.com/radityprtama/proxygate/v6/internal/budget"
+	"github.com/radityprtama/proxygate/v6/internal/clientlimit"
 	"github.com/radityprtama/proxygate/v6/internal/registry"
 	"github.com/radityprtama/proxygate/v6/internal/runtime/executor"
-	_ "github.com/radityprtama/proxygate/v6/internal/usage"
+	"github.com/radityprtama/proxygate/v6/internal/store"
+	internalusage "github.com/radityprtama/proxygate/v6/internal/usage"
+	"github.com/radityprtama/proxygate/v6/internal/virtualkey"
 	"github.com/radityprtama/proxygate/v6/internal/watcher"
 	"github.com/radityprtama/proxygate/v6/internal/wsrelay"
 	sdkaccess "github.com/radityprtama/proxygate/v6/sdk/access"
@@ -315,6 +320,179 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	}
 	maxInterval := time.Duration(cfg.MaxRetryInterval) * time.Second
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
+	s.coreManager.SetMaxQueueWait(time.Duration(cfg.MaxQueueWait) * time.Second)
+}
+
+// applySessionAffinity toggles sticky session routing from the routing config.
+func (s *Service) applySessionAffinity(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	ttl := time.Duration(cfg.Routing.SessionAffinityTTL) * time.Second
+	s.coreManager.SetSessionAffinity(cfg.Routing.SessionAffinity, ttl)
+}
+
+// applyCircuitBreaker configures the per-provider circuit breaker.
+func (s *Service) applyCircuitBreaker(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	s.coreManager.SetBreakerSettings(coreauth.BreakerSettings{
+		Enabled:          cfg.CircuitBreaker.Enabled,
+		FailureThreshold: cfg.CircuitBreaker.FailureThreshold,
+		OpenDuration:     time.Duration(cfg.CircuitBreaker.OpenSeconds) * time.Second,
+		HalfOpenProbes:   cfg.CircuitBreaker.HalfOpenProbes,
+	})
+}
+
+// applyClientRateLimits installs the default and per-key client rate limits.
+func (s *Service) applyClientRateLimits(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	perKey := make(map[string]clientlimit.Limits, len(cfg.ClientKeys))
+	for _, clientKey := range cfg.ClientKeys {
+		if clientKey.Key == "" || (clientKey.RPM == 0 && clientKey.TPM == 0) {
+			continue
+		}
+		perKey[clientKey.Key] = clientlimit.Limits{RPM: clientKey.RPM, TPM: clientKey.TPM}
+	}
+	clientlimit.Default().Configure(clientlimit.Limits{RPM: cfg.ClientRateLimit.RPM, TPM: cfg.ClientRateLimit.TPM}, perKey)
+}
+
+// applyClientBudgets installs the default and per-key token budgets.
+func (s *Service) applyClientBudgets(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	perKey := make(map[string]budget.Budget, len(cfg.ClientKeys))
+	for _, clientKey := range cfg.ClientKeys {
+		if clientKey.Key == "" || clientKey.Budget == (config.TokenBudget{}) {
+			continue
+		}
+		perKey[clientKey.Key] = budgetFromConfig(clientKey.Budget)
+	}
+	for _, key := range virtualkey.Default().List() {
+		if key.Budget != nil {
+			perKey[key.ID] = *key.Budget
+		}
+	}
+	budget.Default().Configure(budgetFromConfig(cfg.ClientBudget), perKey)
+}
+
+// applyUsageStore opens the configured persistent usage store and installs it as the usage
+// sink, replacing the previous one when the store settings changed.
+func (s *Service) applyUsageStore(oldCfg, newCfg *config.Config) {
+	if s == nil || newCfg == nil {
+		return
+	}
+	if oldCfg != nil && oldCfg.UsageStore == newCfg.UsageStore {
+		return
+	}
+	storeCfg := newCfg.UsageStore
+	retention := internalusage.Retention{
+		Raw:    retentionDays(storeCfg.RawRetentionDays, internalusage.DefaultRawRetention),
+		Hourly: retentionDays(storeCfg.HourlyRetentionDays, internalusage.DefaultHourlyRetention),
+	}
+	switch storeType := strings.ToLower(strings.TrimSpace(storeCfg.Type)); storeType {
+	case "":
+		internalusage.SetSink(nil, retention)
+	case "file":
+		dir := strings.TrimSpace(storeCfg.Path)
+		if dir == "" {
+			dir = filepath.Join(filepath.Dir(s.configPath), "usage")
+		}
+		sink, err := internalusage.NewFileSink(dir)
+		if err != nil {
+			log.Errorf("failed to open usage store: %v", err)
+			return
+		}
+		internalusage.SetSink(sink, retention)
+		log.Infof("usage statistics persisted to %s", dir)
+	case "postgres":
+		dsn := strings.TrimSpace(storeCfg.DSN)
+		if dsn == "" {
+			dsn = strings.TrimSpace(os.Getenv("PGSTORE_DSN"))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		sink, err := store.NewPostgresUsageSink(ctx, store.PostgresStoreConfig{
+			DSN:        dsn,
+			Schema:     storeCfg.Schema,
+			UsageTable: storeCfg.Table,
+		})
+		if err != nil {
+			log.Errorf("failed to open usage store: %v", err)
+			return
+		}
+		internalusage.SetSink(sink, retention)
+		log.Info("usage statistics persisted to postgres")
+	default:
+		log.Errorf("unknown usage-store type %q", storeType)
+	}
+}
+
+// applyPricing installs the configured pricing table used to cost usage records.
+func (s *Service) applyPricing(cfg *config.Config) {
+	if cfg == nil || len(cfg.Pricing) == 0 {
+		internalusage.SetPricing(nil)
+		return
+	}
+	rules := make([]internalusage.PriceRule, 0, len(cfg.Pricing))
+	for _, entry := range cfg.Pricing {
+		price := internalusage.Price{
+			Input:       entry.Input,
+			Output:      entry.Output,
+			CachedInput: entry.Input,
+			Reasoning:   entry.Output,
+		}
+		if entry.CachedInput != nil {
+			price.CachedInput = *entry.CachedInput
+		}
+		if entry.Reasoning != nil {
+			price.Reasoning = *entry.Reasoning
+		}
+		rules = append(rules, internalusage.PriceRule{Model: entry.Model, Provider: entry.Provider, Price: price})
+	}
+	internalusage.SetPricing(internalusage.NewPricing(rules))
+}
+
+func retentionDays(days int, fallback time.Duration) time.Duration {
+	switch {
+	case days == 0:
+		return fallback
+	case days < 0:
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func budgetFromConfig(b config.TokenBudget) budget.Budget {
+	return budget.Budget{
+		Daily:   budget.Limits{TotalTokens: b.Daily.TotalTokens, InputTokens: b.Daily.InputTokens, OutputTokens: b.Daily.OutputTokens},
+		Monthly: budget.Limits{TotalTokens: b.Monthly.TotalTokens, InputTokens: b.Monthly.InputTokens, OutputTokens: b.Monthly.OutputTokens},
+	}
+}
+
+// applyModelFallbacks installs the configured model fallback chains.
+func (s *Service) applyModelFallbacks(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	s.coreManager.SetModelFallbacks(cfg.ModelFallbacks)
+}
+
+// applyRoutingConfig swaps the credential selector when the configured strategy changes.
+func (s *Service) applyRoutingConfig(oldCfg, newCfg *config.Config) {
+	if s == nil || s.coreManager == nil || newCfg == nil {
+		return
+	}
+	next := coreauth.NormalizeSelectorStrategy(newCfg.Routing.Strategy)
+	if oldCfg != nil && coreauth.NormalizeSelectorStrategy(oldCfg.Routing.Strategy) == next {
+		return
+	}
+	s.coreManager.SetSelector(coreauth.NewSelector(next))
+	log.Infof("credential selection strategy set to %s", next)
 }
 
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
@@ -435,11 +613,34 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	s.applySessionAffinity(s.cfg)
+	s.applyModelFallbacks(s.cfg)
+	s.applyCircuitBreaker(s.cfg)
+	s.applyClientRateLimits(s.cfg)
+	s.applyClientBudgets(s.cfg)
+	s.applyUsageStore(nil, s.cfg)
+	s.applyPricing(s.cfg)
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
 			log.Warnf("failed to load auth store: %v", errLoad)
 		}
+		if stateStore := s.coreManager.StateStore(); stateStore != nil {
+			budget.Default().SetStore(stateStore)
+			if errLoad := budget.Default().Load(ctx); errLoad != nil {
+				log.Warnf("failed to load client key budgets: %v", errLoad)
+			}
+			virtualkey.Default().SetStore(stateStore)
+			virtualkey.Default().OnChange(func() {
+				s.cfgMu.RLock()
+				cfg := s.cfg
+				s.cfgMu.RUnlock()
+				s.applyClientBudgets(cfg)
+			})
+			if errLoad := virtualkey.Default().Load(ctx); errLoad != nil {
+				log.Warnf("failed to load virtual keys: %v", errLoad)
+			}
+		}
 	}
 
 	tokenResult, err := s.tokenProvider.Load(ctx, s.cfg)
@@ -519,6 +720,17 @@ func (s *Service) Run(ctx context.Context) error {
 			return
 		}
 		s.applyRetryConfig(newCfg)
+		s.cfgMu.RLock()
+		previousCfg := s.cfg
+		s.cfgMu.RUnlock()
+		s.applyRoutingConfig(previousCfg, newCfg)
+		s.applySessionAffinity(newCfg)
+		s.applyModelFallbacks(newCfg)
+		s.applyCircuitBreaker(newCfg)
+		s.applyClientRateLimits(newCfg)
+		s.applyClientBudgets(newCfg)
+		s.applyUsageStore(previousCfg, newCfg)
+		s.applyPricing(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -588,6 +800,9 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			if err := s.coreManager.FlushRuntimeState(ctx); err != nil {
+				log.Warnf("failed to persist auth runtime state: %v", err)
+			}
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -622,6 +837,22 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		usage.StopDefault()
+		if err := internalusage.CloseSink(ctx); err != nil {
+			log.Warnf("failed to persist usage statistics: %v", err)
+		}
+		if err := budget.Default().Flush(ctx); err != nil {
+			log.Warnf("failed to persist client key budgets: %v", err)
+		}
+		if err := virtualkey.Default().Flush(ctx); err != nil {
+			log.Warnf("failed to persist virtual keys: %v", err)
+		}
+		if s.coreManager != nil {
+			if flusher, ok := s.coreManager.StateStore().(coreauth.StateFlusher); ok {
+				if err := flusher.FlushState(ctx); err != nil {
+					log.Warnf("failed to push saved state: %v", err)
+				}
+			}
+		}
 	})
 	return shutdownErr
 }
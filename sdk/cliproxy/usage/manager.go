@@ -10,15 +10,35 @@ import (
 
 // Record contains the usage statistics captured for a single provider request.
 type Record struct {
-	Provider    string
-	Model       string
-	APIKey      string
-	AuthID      string
-	AuthIndex   uint64
-	Source      string
-	RequestedAt time.Time
-	Failed      bool
-	Detail      Detail
+	Provider       string
+	Model          string
+	RequestedModel string
+	APIKey         string
+	AuthID         string
+	AuthIndex      uint64
+	Source         string
+	RequestedAt    time.Time
+	Failed         bool
+	Detail         Detail
+	// Endpoint is the client-facing route ("POST /v1/chat/completions") the request came in on,
+	// captured while the request was still being served.
+	Endpoint string
+	// QueueDepth counts the requests already waiting on the credential when this one queued.
+	QueueDepth int
+	// QueueWait is the time spent waiting for a throttled credential.
+	QueueWait time.Duration
+	// UpstreamFirstByte is the time from sending the serving upstream request to its first
+	// response byte.
+	UpstreamFirstByte time.Duration
+	// ClientFirstChunk is the time from receiving the request to writing the first response
+	// byte to the client. Zero when nothing was written.
+	ClientFirstChunk time.Duration
+	// Duration is the time from receiving the request to finishing the response.
+	Duration time.Duration
+	// Retries counts upstream attempts after the first, across credentials and providers.
+	Retries int
+	// CredentialsTried counts the distinct credentials attempted.
+	CredentialsTried int
 }
 
 // Detail holds the token usage breakdown.
@@ -104,11 +124,29 @@ func (m *Manager) Register(plugin Plugin) {
 }
 
 // Publish enqueues a usage record for processing. If no plugin is registered
-// the record will be discarded downstream.
+// the record will be discarded downstream. Records published under a request Timing are
+// held until the request finishes and then stamped with its timings and outcome.
 func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	if timing := TimingFromContext(ctx); timing != nil {
+		if timing.hold(m, ctx, &record) {
+			return
+		}
+		ctx = detachRequest(ctx)
+	}
+	m.enqueue(ctx, record)
+}
+
+// detachRequest hides the gin context from plugins once the request has finished, since gin
+// hands the context to the next request as soon as the handler chain returns. Everything
+// plugins need from it is stamped onto the record by the Timing.
+func detachRequest(ctx context.Context) context.Context {
+	return context.WithValue(context.WithoutCancel(ctx), "gin", nil)
+}
+
+func (m *Manager) enqueue(ctx context.Context, record Record) {
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
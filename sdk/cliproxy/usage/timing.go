@@ -0,0 +1,150 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type timingContextKey struct{}
+
+// Timing follows one client request through the proxy so its usage records can tell apart time
+// spent upstream, in retries and in the proxy itself. It is created when the request is
+// received and carried in the request context. Records published while the request is in
+// flight are held until Finish, so their timings cover the whole response.
+type Timing struct {
+	mu               sync.Mutex
+	start            time.Time
+	upstreamTTFB     time.Duration
+	clientFirstChunk time.Duration
+	attempts         int
+	credentials      map[string]struct{}
+	endpoint         string
+	status           int
+	finished         bool
+	held             []heldRecord
+}
+
+type heldRecord struct {
+	manager *Manager
+	ctx     context.Context
+	record  Record
+}
+
+// NewTiming starts timing a request received at start.
+func NewTiming(start time.Time) *Timing {
+	return &Timing{start: start, credentials: make(map[string]struct{})}
+}
+
+// WithTiming returns a copy of ctx carrying t.
+func WithTiming(ctx context.Context, t *Timing) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, timingContextKey{}, t)
+}
+
+// TimingFromContext returns the request timing carried by ctx, or nil.
+func TimingFromContext(ctx context.Context) *Timing {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(timingContextKey{}).(*Timing)
+	return t
+}
+
+// BeginAttempt counts an upstream attempt on the credential authID.
+func (t *Timing) BeginAttempt(authID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.attempts++
+	if authID != "" {
+		t.credentials[authID] = struct{}{}
+	}
+	t.mu.Unlock()
+}
+
+// ObserveUpstreamFirstByte records the time from sending an upstream request to its first
+// response byte. The latest upstream request wins, so retries report the attempt that served.
+func (t *Timing) ObserveUpstreamFirstByte(d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.upstreamTTFB = d
+	t.mu.Unlock()
+}
+
+// MarkClientFirstChunk records the first write of the response to the client. Later calls are
+// ignored.
+func (t *Timing) MarkClientFirstChunk() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.clientFirstChunk == 0 {
+		t.clientFirstChunk = max(time.Since(t.start), time.Nanosecond)
+	}
+	t.mu.Unlock()
+}
+
+// SetOutcome records the client-facing route and the response status of the request, so the
+// records held for it do not have to consult the request after it has finished.
+func (t *Timing) SetOutcome(endpoint string, status int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.endpoint = endpoint
+	t.status = status
+	t.mu.Unlock()
+}
+
+// Finish ends the request and publishes the records held for it.
+func (t *Timing) Finish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.finished = true
+	held := t.held
+	t.held = nil
+	for i := range held {
+		t.stampLocked(&held[i].record)
+	}
+	t.mu.Unlock()
+	for _, item := range held {
+		item.manager.enqueue(detachRequest(item.ctx), item.record)
+	}
+}
+
+// hold keeps record until Finish, reporting false once the request has finished; the record
+// is then stamped with the timings so far.
+func (t *Timing) hold(m *Manager, ctx context.Context, record *Record) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.finished {
+		t.held = append(t.held, heldRecord{manager: m, ctx: ctx, record: *record})
+		return true
+	}
+	t.stampLocked(record)
+	return false
+}
+
+func (t *Timing) stampLocked(record *Record) {
+	record.UpstreamFirstByte = t.upstreamTTFB
+	record.ClientFirstChunk = t.clientFirstChunk
+	record.Duration = time.Since(t.start)
+	if t.attempts > 1 {
+		record.Retries = t.attempts - 1
+	}
+	record.CredentialsTried = len(t.credentials)
+	if record.Endpoint == "" {
+		record.Endpoint = t.endpoint
+	}
+	if t.status >= 400 {
+		record.Failed = true
+	}
+}
@@ -0,0 +1,63 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingPlugin struct {
+	mu      sync.Mutex
+	records []Record
+	got     chan struct{}
+}
+
+func (p *recordingPlugin) HandleUsage(_ context.Context, record Record) {
+	p.mu.Lock()
+	p.records = append(p.records, record)
+	p.mu.Unlock()
+	p.got <- struct{}{}
+}
+
+func TestTiming_HoldsRecordsUntilFinish(t *testing.T) {
+	manager := NewManager(0)
+	plugin := &recordingPlugin{got: make(chan struct{}, 1)}
+	manager.Register(plugin)
+	defer manager.Stop()
+
+	timing := NewTiming(time.Now().Add(-time.Second))
+	ctx := WithTiming(context.Background(), timing)
+	timing.BeginAttempt("auth-a")
+	timing.BeginAttempt("auth-b")
+	timing.BeginAttempt("auth-b")
+	timing.ObserveUpstreamFirstByte(300 * time.Millisecond)
+
+	manager.Publish(ctx, Record{Model: "gpt-4o"})
+	select {
+	case <-plugin.got:
+		t.Fatal("record delivered before the request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	timing.MarkClientFirstChunk()
+	timing.Finish()
+	select {
+	case <-plugin.got:
+	case <-time.After(time.Second):
+		t.Fatal("record not delivered after Finish")
+	}
+
+	plugin.mu.Lock()
+	record := plugin.records[0]
+	plugin.mu.Unlock()
+	if record.Retries != 2 || record.CredentialsTried != 2 {
+		t.Fatalf("retries = %d, credentials = %d, want 2 and 2", record.Retries, record.CredentialsTried)
+	}
+	if record.UpstreamFirstByte != 300*time.Millisecond {
+		t.Fatalf("upstream first byte = %v", record.UpstreamFirstByte)
+	}
+	if record.ClientFirstChunk < time.Second || record.Duration < record.ClientFirstChunk {
+		t.Fatalf("client first chunk = %v, duration = %v", record.ClientFirstChunk, record.Duration)
+	}
+}
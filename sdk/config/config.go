@@ -9,12 +9,20 @@ import internalconfig "github.com/radityprtama/proxygate/v6/internal/config"
 type SDKConfig = internalconfig.SDKConfig
 type AccessConfig = internalconfig.AccessConfig
 type AccessProvider = internalconfig.AccessProvider
+type ClientKey = internalconfig.ClientKey
+type ClientRateLimit = internalconfig.ClientRateLimit
+type IPFilter = internalconfig.IPFilter
+type TokenBudget = internalconfig.TokenBudget
+type BudgetLimits = internalconfig.BudgetLimits
 
 type Config = internalconfig.Config
 
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
+type UsageStoreConfig = internalconfig.UsageStoreConfig
+type MetricsConfig = internalconfig.MetricsConfig
+type ModelPrice = internalconfig.ModelPrice
 type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadModelRule = internalconfig.PayloadModelRule
@@ -32,6 +40,8 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	AccessProviderTypeConfigAPIKey = internalconfig.AccessProviderTypeConfigAPIKey
+	AccessProviderTypeJWT          = internalconfig.AccessProviderTypeJWT
+	AccessProviderTypeClientCert   = internalconfig.AccessProviderTypeClientCert
 	DefaultAccessProviderName      = internalconfig.DefaultAccessProviderName
 	DefaultPanelGitHubRepository   = internalconfig.DefaultPanelGitHubRepository
 )
@@ -3,8 +3,40 @@ package translator
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
 )
 
+// ErrorObserver is notified when a translator panics (stage "request", "stream", "non-stream"
+// or "token-count") or turns a valid JSON request into invalid JSON (stage "request").
+type ErrorObserver func(from, to Format, stage string)
+
+var errorObserver atomic.Pointer[ErrorObserver]
+
+// SetErrorObserver installs the observer notified of translator failures. Nil removes it.
+func SetErrorObserver(observer ErrorObserver) {
+	if observer == nil {
+		errorObserver.Store(nil)
+		return
+	}
+	errorObserver.Store(&observer)
+}
+
+func notifyError(from, to Format, stage string) {
+	if observer := errorObserver.Load(); observer != nil {
+		(*observer)(from, to, stage)
+	}
+}
+
+// observePanic reports a translator panic and re-raises it so callers keep their behaviour.
+func observePanic(from, to Format, stage string) {
+	if r := recover(); r != nil {
+		notifyError(from, to, stage)
+		panic(r)
+	}
+}
+
 // Registry manages translation functions across schemas.
 type Registry struct {
 	mu        sync.RWMutex
@@ -46,7 +78,12 @@ func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byt
 
 	if byTarget, ok := r.requests[from]; ok {
 		if fn, isOk := byTarget[to]; isOk && fn != nil {
-			return fn(model, rawJSON, stream)
+			defer observePanic(from, to, "request")
+			out := fn(model, rawJSON, stream)
+			if errorObserver.Load() != nil && len(out) > 0 && !gjson.ValidBytes(out) && gjson.ValidBytes(rawJSON) {
+				notifyError(from, to, "request")
+			}
+			return out
 		}
 	}
 	return rawJSON
@@ -72,6 +109,7 @@ func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model s
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
+			defer observePanic(from, to, "stream")
 			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
@@ -85,6 +123,7 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
+			defer observePanic(from, to, "non-stream")
 			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
@@ -98,6 +137,7 @@ func (r *Registry) TranslateTokenCount(ctx context.Context, from, to Format, cou
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.TokenCount != nil {
+			defer observePanic(from, to, "token-count")
 			return fn.TokenCount(ctx, count)
 		}
 	}